@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,6 +17,8 @@ import (
 	"github.com/veetmoradiya3628/go-shop/internal/events"
 	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
 	"github.com/veetmoradiya3628/go-shop/internal/logger"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
 	"github.com/veetmoradiya3628/go-shop/internal/providers"
 	"github.com/veetmoradiya3628/go-shop/internal/server"
 	"github.com/veetmoradiya3628/go-shop/internal/services"
@@ -48,6 +51,7 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
+	money.BaseCurrency = strings.ToUpper(cfg.Currency.BaseCurrency)
 
 	db, err := database.New(&cfg.Database)
 	if err != nil {
@@ -70,7 +74,16 @@ func main() {
 	gin.SetMode(cfg.Server.GinMode)
 
 	authService := services.NewAuthService(db, cfg, eventPublisher)
-	productService := services.NewProductService(db)
+	currencyService := services.NewCurrencyService(db)
+	allocationStrategy, err := services.ParseAllocationStrategy(cfg.Inventory.AllocationStrategy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid inventory config")
+	}
+	inventoryService := services.NewInventoryService(db, eventPublisher, allocationStrategy)
+	warehouseService := services.NewWarehouseService(db)
+	productService := services.NewProductService(db, currencyService, inventoryService)
+	searchService := services.NewSearchService(db, productService)
+	reviewService := services.NewReviewService(db)
 	userService := services.NewUserService(db)
 
 	var uploadProvider interfaces.UploadProvider
@@ -80,10 +93,38 @@ func main() {
 		uploadProvider = providers.NewLocalProvider(cfg.Upload.Path)
 	}
 	uploadService := services.NewUploadService(uploadProvider) // Use the selected provider for uploads
-	cartService := services.NewCartService(db)
-	orderService := services.NewOrderService(db)
 
-	srv := server.New(cfg, db, &log, authService, productService, userService, uploadService, cartService, orderService)
+	// Tax rules are read once at startup
+	var taxRates []models.TaxRate
+	if err := db.Find(&taxRates).Error; err != nil {
+		log.Fatal().Err(err).Msg("failed to load tax rates")
+	}
+	taxCalculator := providers.NewTableTaxCalculator(taxRates)
+
+	couponService := services.NewCouponService(db)
+	promotionService := services.NewPromotionService(db)
+	cartService := services.NewCartService(db, currencyService, couponService, promotionService, taxCalculator)
+	wishlistService := services.NewWishlistService(db, currencyService, cartService)
+	reservationService := services.NewReservationService(db, inventoryService, cfg.Inventory.ReservationTTL)
+
+	var paymentProvider interfaces.PaymentProvider
+	switch cfg.Payment.PaymentProvider {
+	case "fake":
+		paymentProvider = providers.NewFakePaymentProvider()
+	default:
+		log.Fatal().Str("provider", cfg.Payment.PaymentProvider).Msg("unsupported payment provider")
+	}
+	orderService := services.NewOrderService(db, eventPublisher, currencyService, couponService, promotionService, reservationService, inventoryService, taxCalculator, paymentProvider)
+	paymentService := services.NewPaymentService(db, paymentProvider, orderService, inventoryService)
+
+	// Give back the stock held by checkouts that were never completed
+	sweeperCtx, stopSweeper := context.WithCancel(ctx)
+	defer stopSweeper()
+	go reservationService.RunSweeper(sweeperCtx, cfg.Inventory.ReservationSweepInterval)
+	// Delete the guest carts whose cookie has expired
+	go cartService.RunGuestCartSweeper(sweeperCtx, cfg.Cart.GuestCartSweepInterval, cfg.Cart.GuestCartTTL)
+
+	srv := server.New(cfg, db, &log, authService, productService, userService, uploadService, cartService, orderService, paymentService, currencyService, couponService, promotionService, reservationService, inventoryService, warehouseService, searchService, reviewService, wishlistService)
 
 	router := srv.SetupRoutes()
 
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -67,7 +68,7 @@ func main() {
 	for {
 		select {
 		case msg := <-messages:
-			if err := processMessage(msg, emailNotifier); err != nil {
+			if err := processMessage(msg, emailNotifier, cfg.Inventory.AlertEmails); err != nil {
 				log.Printf("Error processing message: %v", err)
 				msg.Nack()
 			} else {
@@ -81,11 +82,19 @@ func main() {
 	}
 }
 
-func processMessage(msg *message.Message, emailNotifier *notifications.EmailNotifier) error {
+func processMessage(msg *message.Message, emailNotifier *notifications.EmailNotifier, inventoryManagers []string) error {
 	eventType := msg.Metadata.Get("event_type")
 	switch eventType {
 	case notifications.UserLoggedIn:
 		return handleUserLoggedIn(msg, emailNotifier)
+	case notifications.OrderCancelled:
+		return handleOrderCancelled(msg, emailNotifier)
+	case notifications.OrderRefunded:
+		return handleOrderRefunded(msg, emailNotifier)
+	case notifications.ProductLowStock, notifications.ProductOutOfStock:
+		return handleStockAlert(msg, eventType, emailNotifier, inventoryManagers)
+	case notifications.ProductBackInStock:
+		return handleBackInStock(msg, emailNotifier)
 	default:
 		log.Printf("Unknown event type: %s", eventType)
 		return nil
@@ -98,12 +107,93 @@ func handleUserLoggedIn(msg *message.Message, emailNotifier *notifications.Email
 		return err
 	}
 
-	userName := user.FirstName + " " + user.LastName
-	if userName == " " {
-		userName = "User"
-	}
+	userName := customerName(user.FirstName, user.LastName)
 
 	log.Printf("Sending login notification to %s", user.Email)
 
 	return emailNotifier.SendLoginNotification(user.Email, userName)
 }
+
+func handleOrderCancelled(msg *message.Message, emailNotifier *notifications.EmailNotifier) error {
+	var event notifications.OrderEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return err
+	}
+
+	log.Printf("Sending order cancelled notification for order %d to %s", event.OrderID, event.Email)
+
+	return emailNotifier.SendOrderCancelledNotification(event.Email, customerName(event.FirstName, event.LastName), event.OrderID, event.Reason)
+}
+
+func handleOrderRefunded(msg *message.Message, emailNotifier *notifications.EmailNotifier) error {
+	var event notifications.OrderEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return err
+	}
+
+	log.Printf("Sending order refunded notification for order %d to %s", event.OrderID, event.Email)
+
+	return emailNotifier.SendOrderRefundedNotification(event.Email, customerName(event.FirstName, event.LastName), event.OrderID, event.RefundAmount)
+}
+
+// handleStockAlert emails every inventory manager. The API only publishes an alert when a
+// product crosses a threshold, so each crossing arrives here once.
+func handleStockAlert(msg *message.Message, eventType string, emailNotifier *notifications.EmailNotifier, inventoryManagers []string) error {
+	var event notifications.StockAlertEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return err
+	}
+
+	if len(inventoryManagers) == 0 {
+		log.Printf("No inventory managers configured, dropping %s alert for product %d", eventType, event.ProductID)
+		return nil
+	}
+
+	// A failed recipient is not retried, redelivering would alert the others twice
+	sent := 0
+	for _, managerEmail := range inventoryManagers {
+		log.Printf("Sending %s alert for product %d to %s", eventType, event.ProductID, managerEmail)
+
+		var err error
+		if eventType == notifications.ProductOutOfStock {
+			err = emailNotifier.SendOutOfStockNotification(managerEmail, event.ProductName, event.SKU)
+		} else {
+			err = emailNotifier.SendLowStockNotification(managerEmail, event.ProductName, event.SKU, event.Stock, event.ReorderThreshold)
+		}
+		if err != nil {
+			log.Printf("Failed to send %s alert to %s: %v", eventType, managerEmail, err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return fmt.Errorf("no inventory manager could be alerted about product %d", event.ProductID)
+	}
+	return nil
+}
+
+// handleBackInStock emails every subscriber once. The API clears the subscriptions when it
+// publishes the event, so failed recipients are logged rather than retried.
+func handleBackInStock(msg *message.Message, emailNotifier *notifications.EmailNotifier) error {
+	var event notifications.BackInStockEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return err
+	}
+
+	for _, subscriber := range event.Subscribers {
+		log.Printf("Sending back in stock notification for product %d to %s", event.ProductID, subscriber.Email)
+
+		if err := emailNotifier.SendBackInStockNotification(subscriber.Email, customerName(subscriber.FirstName, subscriber.LastName), event.ProductName); err != nil {
+			log.Printf("Failed to send back in stock notification to %s: %v", subscriber.Email, err)
+		}
+	}
+	return nil
+}
+
+func customerName(firstName, lastName string) string {
+	userName := firstName + " " + lastName
+	if userName == " " {
+		userName = "User"
+	}
+	return userName
+}
@@ -0,0 +1,11 @@
+CREATE TABLE order_status_histories (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status order_status,
+    to_status order_status NOT NULL,
+    changed_by INTEGER NOT NULL REFERENCES users(id),
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_status_histories_order_id ON order_status_histories(order_id);
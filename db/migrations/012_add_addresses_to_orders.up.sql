@@ -0,0 +1,19 @@
+ALTER TABLE orders
+    ADD COLUMN shipping_first_name VARCHAR(100),
+    ADD COLUMN shipping_last_name VARCHAR(100),
+    ADD COLUMN shipping_phone VARCHAR(20),
+    ADD COLUMN shipping_line1 VARCHAR(255),
+    ADD COLUMN shipping_line2 VARCHAR(255),
+    ADD COLUMN shipping_city VARCHAR(100),
+    ADD COLUMN shipping_state VARCHAR(100),
+    ADD COLUMN shipping_postal_code VARCHAR(20),
+    ADD COLUMN shipping_country CHAR(2),
+    ADD COLUMN billing_first_name VARCHAR(100),
+    ADD COLUMN billing_last_name VARCHAR(100),
+    ADD COLUMN billing_phone VARCHAR(20),
+    ADD COLUMN billing_line1 VARCHAR(255),
+    ADD COLUMN billing_line2 VARCHAR(255),
+    ADD COLUMN billing_city VARCHAR(100),
+    ADD COLUMN billing_state VARCHAR(100),
+    ADD COLUMN billing_postal_code VARCHAR(20),
+    ADD COLUMN billing_country CHAR(2);
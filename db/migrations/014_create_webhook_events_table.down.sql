@@ -0,0 +1,4 @@
+DELETE FROM order_status_histories WHERE changed_by IS NULL;
+ALTER TABLE order_status_histories ALTER COLUMN changed_by SET NOT NULL;
+
+DROP TABLE IF EXISTS webhook_events;
@@ -0,0 +1,9 @@
+CREATE TABLE webhook_events (
+    id SERIAL PRIMARY KEY,
+    event_id VARCHAR(255) UNIQUE NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- status changes coming from the payment gateway have no user attached
+ALTER TABLE order_status_histories ALTER COLUMN changed_by DROP NOT NULL;
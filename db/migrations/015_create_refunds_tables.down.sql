@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS refund_items;
+DROP TABLE IF EXISTS refunds;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+
+-- PostgreSQL cannot drop enum values, so refunded payments fall back to captured
+UPDATE payments SET status = 'captured' WHERE status IN ('partially_refunded', 'refunded');
@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
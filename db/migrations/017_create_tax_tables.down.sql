@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS order_tax_lines;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_inclusive;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal_amount;
+
+DROP TABLE IF EXISTS tax_rates;
+
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
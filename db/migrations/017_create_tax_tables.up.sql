@@ -0,0 +1,52 @@
+ALTER TABLE products ADD COLUMN tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE tax_rates (
+    id SERIAL PRIMARY KEY,
+    country VARCHAR(2) NOT NULL,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    tax_class VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    rate DECIMAL(8,6) NOT NULL CHECK (rate >= 0),
+    inclusive BOOLEAN DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (country, region, tax_class)
+);
+
+-- EU and UK prices include VAT, US sales tax is added at checkout
+INSERT INTO tax_rates (country, region, tax_class, name, rate, inclusive) VALUES
+    ('GB', '', 'standard', 'VAT', 0.20, true),
+    ('GB', '', 'reduced', 'VAT (reduced)', 0.05, true),
+    ('DE', '', 'standard', 'MwSt', 0.19, true),
+    ('DE', '', 'reduced', 'MwSt (ermäßigt)', 0.07, true),
+    ('FR', '', 'standard', 'TVA', 0.20, true),
+    ('FR', '', 'reduced', 'TVA (réduite)', 0.055, true),
+    ('IE', '', 'standard', 'VAT', 0.23, true),
+    ('IE', '', 'reduced', 'VAT (reduced)', 0.135, true),
+    ('US', 'CA', 'standard', 'California sales tax', 0.0725, false),
+    ('US', 'NY', 'standard', 'New York sales tax', 0.04, false),
+    ('US', 'TX', 'standard', 'Texas sales tax', 0.0625, false);
+
+ALTER TABLE orders ADD COLUMN subtotal_amount DECIMAL(10,2);
+ALTER TABLE orders ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+UPDATE orders SET subtotal_amount = total_amount;
+ALTER TABLE orders ALTER COLUMN subtotal_amount SET NOT NULL;
+
+ALTER TABLE order_items ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN tax_inclusive BOOLEAN DEFAULT false;
+
+CREATE TABLE order_tax_lines (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    country VARCHAR(2) NOT NULL,
+    region VARCHAR(100),
+    tax_class VARCHAR(50) NOT NULL,
+    rate DECIMAL(8,6) NOT NULL,
+    inclusive BOOLEAN DEFAULT false,
+    taxable_amount DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_tax_lines_order_id ON order_tax_lines(order_id);
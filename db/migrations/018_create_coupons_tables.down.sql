@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS order_discounts;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS free_shipping;
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE carts DROP COLUMN IF EXISTS coupon_id;
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupon_products;
+DROP TABLE IF EXISTS coupon_categories;
+DROP TABLE IF EXISTS coupons;
+DROP TYPE IF EXISTS coupon_type;
@@ -0,0 +1,8 @@
+ALTER TABLE order_discounts DROP COLUMN IF EXISTS promotion_name;
+ALTER TABLE order_discounts DROP COLUMN IF EXISTS promotion_id;
+
+DROP TABLE IF EXISTS promotion_products;
+DROP TABLE IF EXISTS promotion_categories;
+DROP TABLE IF EXISTS promotion_tiers;
+DROP TABLE IF EXISTS promotions;
+DROP TYPE IF EXISTS promotion_type;
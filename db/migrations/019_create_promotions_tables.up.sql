@@ -0,0 +1,47 @@
+CREATE TYPE promotion_type AS ENUM ('percentage', 'fixed', 'buy_x_get_y', 'tiered', 'free_shipping');
+
+CREATE TABLE promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    type promotion_type NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    stackable BOOLEAN DEFAULT false,
+    percent_off DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (percent_off >= 0 AND percent_off <= 100),
+    amount_off DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (amount_off >= 0),
+    min_cart_value DECIMAL(10,2) NOT NULL DEFAULT 0,
+    buy_quantity INTEGER NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    get_quantity INTEGER NOT NULL DEFAULT 0 CHECK (get_quantity >= 0),
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_promotions_deleted_at ON promotions(deleted_at);
+CREATE INDEX idx_promotions_is_active ON promotions(is_active);
+
+CREATE TABLE promotion_tiers (
+    id SERIAL PRIMARY KEY,
+    promotion_id INTEGER NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    min_quantity INTEGER NOT NULL CHECK (min_quantity > 0),
+    percent_off DECIMAL(5,2) NOT NULL CHECK (percent_off > 0 AND percent_off <= 100),
+    UNIQUE (promotion_id, min_quantity)
+);
+
+CREATE TABLE promotion_categories (
+    promotion_id INTEGER NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (promotion_id, category_id)
+);
+
+CREATE TABLE promotion_products (
+    promotion_id INTEGER NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (promotion_id, product_id)
+);
+
+ALTER TABLE order_discounts ADD COLUMN promotion_id INTEGER REFERENCES promotions(id) ON DELETE SET NULL;
+ALTER TABLE order_discounts ADD COLUMN promotion_name VARCHAR(255);
@@ -0,0 +1,4 @@
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_stock_non_negative;
+
+DROP TABLE IF EXISTS stock_reservations;
+DROP TYPE IF EXISTS reservation_status;
@@ -0,0 +1,20 @@
+CREATE TYPE reservation_status AS ENUM ('active', 'committed', 'released');
+
+CREATE TABLE stock_reservations (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    status reservation_status NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stock_reservations_user_status ON stock_reservations(user_id, status);
+-- the sweeper only looks at active reservations by expiry
+CREATE INDEX idx_stock_reservations_active_expiry ON stock_reservations(expires_at) WHERE status = 'active';
+
+-- conditional decrements keep stock from going negative, the constraint guards every other writer
+ALTER TABLE products ADD CONSTRAINT products_stock_non_negative CHECK (stock >= 0);
@@ -0,0 +1,33 @@
+CREATE TYPE movement_reason AS ENUM (
+    'sale', 'cancellation', 'adjustment', 'return', 'import', 'reservation', 'reservation_release'
+);
+
+CREATE TABLE inventory_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    delta INTEGER NOT NULL CHECK (delta <> 0),
+    reason movement_reason NOT NULL,
+    reference_id INTEGER,
+    actor_id INTEGER REFERENCES users(id),
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_inventory_movements_product_id ON inventory_movements(product_id, id);
+
+-- the ledger is append-only
+CREATE FUNCTION inventory_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'inventory_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER inventory_movements_append_only
+    BEFORE UPDATE OR DELETE ON inventory_movements
+    FOR EACH ROW EXECUTE FUNCTION inventory_movements_append_only();
+
+-- open the ledger with the stock products already have
+INSERT INTO inventory_movements (product_id, delta, reason, note)
+SELECT id, stock, 'import', 'opening balance'
+FROM products
+WHERE stock <> 0;
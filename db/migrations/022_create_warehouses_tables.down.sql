@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS order_item_allocations;
+
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS warehouse_id;
+
+-- enum values cannot be dropped, 'transfer' stays in movement_reason
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS stock_levels;
+DROP TABLE IF EXISTS warehouses;
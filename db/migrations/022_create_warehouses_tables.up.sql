@@ -0,0 +1,56 @@
+CREATE TABLE warehouses (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    country VARCHAR(2),
+    region VARCHAR(100),
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_warehouses_deleted_at ON warehouses(deleted_at);
+
+-- existing stock lives in a default warehouse
+INSERT INTO warehouses (code, name, priority) VALUES ('MAIN', 'Main warehouse', 100);
+
+CREATE TABLE stock_levels (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id),
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (warehouse_id, product_id)
+);
+
+CREATE INDEX idx_stock_levels_product_id ON stock_levels(product_id);
+
+INSERT INTO stock_levels (warehouse_id, product_id, stock)
+SELECT warehouses.id, products.id, products.stock
+FROM products, warehouses
+WHERE warehouses.code = 'MAIN' AND products.stock <> 0;
+
+-- the ledger is append-only, the trigger is lifted only to place past movements
+ALTER TABLE inventory_movements ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id);
+ALTER TABLE inventory_movements DISABLE TRIGGER inventory_movements_append_only;
+UPDATE inventory_movements SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN');
+ALTER TABLE inventory_movements ENABLE TRIGGER inventory_movements_append_only;
+ALTER TABLE inventory_movements ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TYPE movement_reason ADD VALUE 'transfer';
+
+ALTER TABLE stock_reservations ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id);
+UPDATE stock_reservations SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN');
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+
+CREATE TABLE order_item_allocations (
+    id SERIAL PRIMARY KEY,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_item_allocations_order_item_id ON order_item_allocations(order_item_id);
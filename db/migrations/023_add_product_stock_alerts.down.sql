@@ -0,0 +1,4 @@
+ALTER TABLE products DROP COLUMN IF EXISTS stock_alert;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_threshold;
+
+DROP TYPE IF EXISTS stock_alert;
@@ -0,0 +1,7 @@
+CREATE TYPE stock_alert AS ENUM ('none', 'low', 'out');
+
+ALTER TABLE products ADD COLUMN reorder_threshold INTEGER NOT NULL DEFAULT 0 CHECK (reorder_threshold >= 0);
+-- the last alert sent, so each threshold crossing is only reported once
+ALTER TABLE products ADD COLUMN stock_alert stock_alert NOT NULL DEFAULT 'none';
+
+UPDATE products SET stock_alert = 'out' WHERE stock = 0;
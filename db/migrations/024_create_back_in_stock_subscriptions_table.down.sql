@@ -0,0 +1 @@
+DROP TABLE IF EXISTS back_in_stock_subscriptions;
@@ -0,0 +1,29 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_name;
+ALTER TABLE order_items DROP COLUMN IF EXISTS sku;
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
+
+-- items of the same product in different variants cannot be told apart any more
+DROP INDEX IF EXISTS idx_cart_items_cart_variant;
+DELETE FROM cart_items a USING cart_items b
+WHERE a.cart_id = b.cart_id AND a.product_id = b.product_id AND a.id > b.id;
+ALTER TABLE cart_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE cart_items ADD CONSTRAINT cart_items_cart_id_product_id_key UNIQUE (cart_id, product_id);
+
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS variant_id;
+
+-- stock of all variants folds back into one level per product and warehouse
+UPDATE stock_levels SET stock = totals.stock
+FROM (SELECT MIN(id) AS id, SUM(stock) AS stock FROM stock_levels GROUP BY warehouse_id, product_id) totals
+WHERE stock_levels.id = totals.id;
+DELETE FROM stock_levels WHERE id NOT IN (SELECT MIN(id) FROM stock_levels GROUP BY warehouse_id, product_id);
+ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_warehouse_id_variant_id_key;
+ALTER TABLE stock_levels DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_warehouse_id_product_id_key UNIQUE (warehouse_id, product_id);
+
+ALTER TABLE product_images DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS variant_option_values;
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_option_values;
+DROP TABLE IF EXISTS product_options;
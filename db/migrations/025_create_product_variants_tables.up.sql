@@ -0,0 +1,79 @@
+CREATE TABLE product_options (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (product_id, name)
+);
+
+CREATE TABLE product_option_values (
+    id SERIAL PRIMARY KEY,
+    option_id INTEGER NOT NULL REFERENCES product_options(id) ON DELETE CASCADE,
+    value VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (option_id, value)
+);
+
+CREATE TABLE product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(100) UNIQUE NOT NULL,
+    price DECIMAL(10,2),
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_product_variants_product_id ON product_variants(product_id);
+CREATE INDEX idx_product_variants_deleted_at ON product_variants(deleted_at);
+CREATE UNIQUE INDEX idx_product_variants_default ON product_variants(product_id) WHERE is_default AND deleted_at IS NULL;
+
+CREATE TABLE variant_option_values (
+    product_variant_id INTEGER NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    product_option_value_id INTEGER NOT NULL REFERENCES product_option_values(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_variant_id, product_option_value_id)
+);
+
+-- every existing product becomes a single default variant holding its SKU and stock
+INSERT INTO product_variants (product_id, sku, stock, is_default, created_at, updated_at)
+SELECT id, sku, stock, true, created_at, updated_at FROM products;
+
+ALTER TABLE product_images ADD COLUMN variant_id INTEGER REFERENCES product_variants(id) ON DELETE SET NULL;
+
+ALTER TABLE stock_levels ADD COLUMN variant_id INTEGER REFERENCES product_variants(id) ON DELETE CASCADE;
+UPDATE stock_levels SET variant_id = product_variants.id
+FROM product_variants WHERE product_variants.product_id = stock_levels.product_id;
+ALTER TABLE stock_levels ALTER COLUMN variant_id SET NOT NULL;
+ALTER TABLE stock_levels DROP CONSTRAINT stock_levels_warehouse_id_product_id_key;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_warehouse_id_variant_id_key UNIQUE (warehouse_id, variant_id);
+
+-- the ledger is append-only, the trigger is lifted only to attribute past movements
+ALTER TABLE inventory_movements ADD COLUMN variant_id INTEGER REFERENCES product_variants(id);
+ALTER TABLE inventory_movements DISABLE TRIGGER inventory_movements_append_only;
+UPDATE inventory_movements SET variant_id = product_variants.id
+FROM product_variants WHERE product_variants.product_id = inventory_movements.product_id;
+ALTER TABLE inventory_movements ENABLE TRIGGER inventory_movements_append_only;
+ALTER TABLE inventory_movements ALTER COLUMN variant_id SET NOT NULL;
+
+ALTER TABLE stock_reservations ADD COLUMN variant_id INTEGER REFERENCES product_variants(id) ON DELETE CASCADE;
+UPDATE stock_reservations SET variant_id = product_variants.id
+FROM product_variants WHERE product_variants.product_id = stock_reservations.product_id;
+ALTER TABLE stock_reservations ALTER COLUMN variant_id SET NOT NULL;
+
+ALTER TABLE cart_items ADD COLUMN variant_id INTEGER REFERENCES product_variants(id) ON DELETE CASCADE;
+UPDATE cart_items SET variant_id = product_variants.id
+FROM product_variants WHERE product_variants.product_id = cart_items.product_id;
+ALTER TABLE cart_items ALTER COLUMN variant_id SET NOT NULL;
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_cart_id_product_id_key;
+-- removed items are soft deleted, so only live items have to be unique
+CREATE UNIQUE INDEX idx_cart_items_cart_variant ON cart_items(cart_id, variant_id) WHERE deleted_at IS NULL;
+
+ALTER TABLE order_items ADD COLUMN variant_id INTEGER REFERENCES product_variants(id);
+ALTER TABLE order_items ADD COLUMN sku VARCHAR(100);
+ALTER TABLE order_items ADD COLUMN variant_name VARCHAR(255) NOT NULL DEFAULT '';
+UPDATE order_items SET variant_id = product_variants.id, sku = product_variants.sku
+FROM product_variants WHERE product_variants.product_id = order_items.product_id;
+ALTER TABLE order_items ALTER COLUMN variant_id SET NOT NULL;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS categories_search_vector_update ON categories;
+DROP FUNCTION IF EXISTS categories_search_vector_update();
+DROP TRIGGER IF EXISTS products_search_vector_update ON products;
+DROP FUNCTION IF EXISTS products_search_vector_update();
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS products_search_vector(products);
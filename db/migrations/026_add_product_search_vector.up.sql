@@ -0,0 +1,37 @@
+ALTER TABLE products ADD COLUMN search_vector tsvector;
+
+-- name and SKU weigh most, then the category name, then the description
+CREATE OR REPLACE FUNCTION products_search_vector(p products) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', coalesce(p.name, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(p.sku, '')), 'A') ||
+           setweight(to_tsvector('english', coalesce((SELECT name FROM categories WHERE id = p.category_id), '')), 'B') ||
+           setweight(to_tsvector('english', coalesce(p.description, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := products_search_vector(NEW);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_search_vector_update
+BEFORE INSERT OR UPDATE OF name, sku, description, category_id ON products
+FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+-- renaming a category changes what its products are found by
+CREATE OR REPLACE FUNCTION categories_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = products_search_vector(products) WHERE category_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER categories_search_vector_update
+AFTER UPDATE OF name ON categories
+FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+EXECUTE FUNCTION categories_search_vector_update();
+
+UPDATE products SET search_vector = products_search_vector(products);
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
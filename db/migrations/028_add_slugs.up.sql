@@ -0,0 +1,70 @@
+ALTER TABLE products ADD COLUMN slug VARCHAR(110);
+ALTER TABLE categories ADD COLUMN slug VARCHAR(110);
+
+-- slug_from_name makes the slug utils.Slugify makes of a name: accented letters lose their
+-- accent, apostrophes are dropped and anything but ASCII letters and digits becomes a single
+-- hyphen, cut to 100 characters
+CREATE FUNCTION slug_from_name(name TEXT) RETURNS TEXT AS $$
+    SELECT rtrim(left(trim(both '-' from regexp_replace(
+        translate(lower(name),
+            'ÀÁÂÃÄÅÇÈÉÊËÌÍÎÏÑÒÓÔÕÖÙÚÛÜÝàáâãäåçèéêëìíîïñòóôõöùúûüýÿĀāĂăĄąĆćĈĉĊċČčĎďĒēĔĕĖėĘęĚěĜĝĞğĠġĢģĤĥĨĩĪīĬĭĮįİĴĵĶķĹĺĻļĽľŃńŅņŇňŌōŎŏŐőŔŕŖŗŘřŚśŜŝŞşŠšŢţŤťŨũŪūŬŭŮůŰűŲųŴŵŶŷŸŹźŻżŽž''’',
+            'aaaaaaceeeeiiiinooooouuuuyaaaaaaceeeeiiiinooooouuuuyyaaaaaaccccccccddeeeeeeeeeegggggggghhiiiiiiiiijjkkllllllnnnnnnoooooorrrrrrssssssssttttuuuuuuuuuuuuwwyyyzzzzzz'),
+        '[^a-z0-9]+', '-', 'g')), 100), '-')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- existing rows get a slug from their name
+UPDATE products SET slug = slug_from_name(name);
+UPDATE products SET slug = 'product' WHERE slug = '';
+UPDATE categories SET slug = slug_from_name(name);
+UPDATE categories SET slug = 'category' WHERE slug = '';
+
+DROP FUNCTION slug_from_name(TEXT);
+
+-- where live rows share a slug, all but the oldest are numbered like the service numbers new
+-- ones, skipping numbers another slug already has
+DO $$
+DECLARE
+    dup RECORD;
+    n INTEGER;
+BEGIN
+    FOR dup IN SELECT id, slug FROM (
+        SELECT id, slug, row_number() OVER (PARTITION BY slug ORDER BY id) AS rank
+        FROM products WHERE deleted_at IS NULL
+    ) ranked WHERE rank > 1 ORDER BY id LOOP
+        n := 2;
+        WHILE EXISTS (SELECT 1 FROM products WHERE slug = dup.slug || '-' || n AND deleted_at IS NULL) LOOP
+            n := n + 1;
+        END LOOP;
+        UPDATE products SET slug = dup.slug || '-' || n WHERE id = dup.id;
+    END LOOP;
+
+    FOR dup IN SELECT id, slug FROM (
+        SELECT id, slug, row_number() OVER (PARTITION BY slug ORDER BY id) AS rank
+        FROM categories WHERE deleted_at IS NULL
+    ) ranked WHERE rank > 1 ORDER BY id LOOP
+        n := 2;
+        WHILE EXISTS (SELECT 1 FROM categories WHERE slug = dup.slug || '-' || n AND deleted_at IS NULL) LOOP
+            n := n + 1;
+        END LOOP;
+        UPDATE categories SET slug = dup.slug || '-' || n WHERE id = dup.id;
+    END LOOP;
+END $$;
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+
+-- deleted rows give up their slug
+CREATE UNIQUE INDEX idx_products_slug ON products(slug) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_categories_slug ON categories(slug) WHERE deleted_at IS NULL;
+
+-- slugs a product or category had before, so old links can be pointed at the current one
+CREATE TABLE slug_redirects (
+    id SERIAL PRIMARY KEY,
+    resource VARCHAR(20) NOT NULL CHECK (resource IN ('product', 'category')),
+    slug VARCHAR(110) NOT NULL,
+    target_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (resource, slug)
+);
+
+CREATE INDEX idx_slug_redirects_target ON slug_redirects(resource, target_id);
@@ -0,0 +1,4 @@
+ALTER TABLE products DROP COLUMN IF EXISTS rating_total;
+ALTER TABLE products DROP COLUMN IF EXISTS review_count;
+
+DROP TABLE IF EXISTS reviews;
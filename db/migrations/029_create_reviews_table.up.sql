@@ -0,0 +1,26 @@
+CREATE TABLE reviews (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    verified_purchase BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'hidden')),
+    moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- a customer reviews a product once
+CREATE UNIQUE INDEX idx_reviews_product_user ON reviews(product_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_reviews_product_status ON reviews(product_id, status);
+CREATE INDEX idx_reviews_user_id ON reviews(user_id);
+CREATE INDEX idx_reviews_deleted_at ON reviews(deleted_at);
+
+-- the approved reviews of a product, kept up to date as reviews change so reading a
+-- product never has to go over its reviews
+ALTER TABLE products ADD COLUMN review_count INTEGER NOT NULL DEFAULT 0 CHECK (review_count >= 0);
+ALTER TABLE products ADD COLUMN rating_total INTEGER NOT NULL DEFAULT 0 CHECK (rating_total >= 0);
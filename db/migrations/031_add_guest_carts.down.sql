@@ -0,0 +1,5 @@
+DELETE FROM carts WHERE user_id IS NULL;
+
+ALTER TABLE carts DROP CONSTRAINT IF EXISTS carts_user_or_token;
+ALTER TABLE carts DROP COLUMN IF EXISTS token;
+ALTER TABLE carts ALTER COLUMN user_id SET NOT NULL;
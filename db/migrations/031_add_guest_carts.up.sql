@@ -0,0 +1,4 @@
+-- guest carts belong to no user and are found by their token until the guest signs in
+ALTER TABLE carts ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE carts ADD COLUMN token VARCHAR(36) UNIQUE;
+ALTER TABLE carts ADD CONSTRAINT carts_user_or_token CHECK (user_id IS NOT NULL OR token IS NOT NULL);
@@ -0,0 +1,6 @@
+DELETE FROM refunds WHERE status <> 'completed';
+
+DROP INDEX IF EXISTS idx_refunds_pending;
+ALTER TABLE refunds DROP COLUMN IF EXISTS idempotency_key;
+ALTER TABLE refunds DROP COLUMN IF EXISTS failure_reason;
+ALTER TABLE refunds DROP COLUMN IF EXISTS status;
@@ -0,0 +1,8 @@
+-- refunds are recorded as pending before the gateway is asked, so a refund the gateway made is
+-- never lost; the idempotency key lets a retry ask again without refunding twice
+ALTER TABLE refunds ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'completed'
+    CHECK (status IN ('pending', 'completed', 'failed'));
+ALTER TABLE refunds ADD COLUMN failure_reason TEXT;
+ALTER TABLE refunds ADD COLUMN idempotency_key VARCHAR(36) UNIQUE;
+
+CREATE INDEX idx_refunds_pending ON refunds(order_id) WHERE status = 'pending';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_carts_guest_updated_at;
@@ -0,0 +1,2 @@
+-- guest carts untouched for longer than their cookie lives are deleted by the sweeper
+CREATE INDEX idx_carts_guest_updated_at ON carts(updated_at) WHERE user_id IS NULL;
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS product_variants_search_vector_update ON product_variants;
+DROP FUNCTION IF EXISTS product_variants_search_vector_update();
+
+CREATE OR REPLACE FUNCTION products_search_vector(p products) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', coalesce(p.name, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(p.sku, '')), 'A') ||
+           setweight(to_tsvector('english', coalesce((SELECT name FROM categories WHERE id = p.category_id), '')), 'B') ||
+           setweight(to_tsvector('english', coalesce(p.description, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+UPDATE products SET search_vector = products_search_vector(products);
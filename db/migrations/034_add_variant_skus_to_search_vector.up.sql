@@ -0,0 +1,29 @@
+-- name and SKUs weigh most, then the category name, then the description. Every variant has
+-- its own SKU, so products are found by all of them.
+CREATE OR REPLACE FUNCTION products_search_vector(p products) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', coalesce(p.name, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(p.sku, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce((SELECT string_agg(sku, ' ' ORDER BY id) FROM product_variants
+                                                     WHERE product_id = p.id AND deleted_at IS NULL), '')), 'A') ||
+           setweight(to_tsvector('english', coalesce((SELECT name FROM categories WHERE id = p.category_id), '')), 'B') ||
+           setweight(to_tsvector('english', coalesce(p.description, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+-- adding, renaming or removing a variant changes what its product is found by
+CREATE OR REPLACE FUNCTION product_variants_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE products SET search_vector = products_search_vector(products) WHERE id = OLD.product_id;
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.product_id <> OLD.product_id) THEN
+        UPDATE products SET search_vector = products_search_vector(products) WHERE id = NEW.product_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER product_variants_search_vector_update
+AFTER INSERT OR DELETE OR UPDATE OF sku, product_id, deleted_at ON product_variants
+FOR EACH ROW EXECUTE FUNCTION product_variants_search_vector_update();
+
+UPDATE products SET search_vector = products_search_vector(products);
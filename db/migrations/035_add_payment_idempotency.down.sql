@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS idempotency_key;
+
+-- PostgreSQL cannot drop enum values, so payments still in flight fall back to what they were
+UPDATE payments SET status = 'failed' WHERE status = 'pending';
+UPDATE payments SET status = 'authorized' WHERE status = 'capturing';
@@ -0,0 +1,6 @@
+-- payments are recorded as pending before the gateway is asked to authorize them and as
+-- capturing while it captures them, so money the gateway holds is never without its payment;
+-- the idempotency key lets a retry ask again without authorizing twice
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'pending';
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'capturing';
+ALTER TABLE payments ADD COLUMN idempotency_key VARCHAR(36) UNIQUE;
@@ -1,20 +1,26 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	AWS      AWSConfig
-	Upload   UploadConfig
-	SMTP     SMTPConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	AWS       AWSConfig
+	Upload    UploadConfig
+	SMTP      SMTPConfig
+	Payment   PaymentConfig
+	Currency  CurrencyConfig
+	Cart      CartConfig
+	Inventory InventoryConfig
 }
 
 type ServerConfig struct {
@@ -60,6 +66,28 @@ type UploadConfig struct {
 	UploadProvider string // "local" or "s3"
 }
 
+type PaymentConfig struct {
+	PaymentProvider  string // "fake"
+	WebhookSecret    string // without one the payment webhook is not served
+	WebhookTolerance time.Duration
+}
+
+type CurrencyConfig struct {
+	BaseCurrency string // ISO 4217 code product prices and order totals are stored in
+}
+
+type CartConfig struct {
+	GuestCartTTL           time.Duration // how long a guest cart is kept after items were last added
+	GuestCartSweepInterval time.Duration // how often expired guest carts are deleted
+}
+
+type InventoryConfig struct {
+	ReservationTTL           time.Duration // how long checkout holds the cart's stock
+	ReservationSweepInterval time.Duration // how often expired reservations are released
+	AllocationStrategy       string        // which warehouses orders ship from: priority or closest
+	AlertEmails              []string      // inventory managers notified about low and out of stock products
+}
+
 func Load() (*Config, error) {
 	_ = godotenv.Load()
 
@@ -67,6 +95,26 @@ func Load() (*Config, error) {
 	refreshTokenExpires, _ := time.ParseDuration(getEnv("JWT_REFRESH_TOKEN_EXPIRES_IN", "720h"))
 	maxUploadSize, _ := strconv.ParseInt(getEnv("MAX_UPLOAD_SIZE", "10485760"), 10, 64)
 	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "1025"))
+	webhookTolerance, err := getEnvDuration("PAYMENT_WEBHOOK_TOLERANCE", "5m")
+	if err != nil {
+		return nil, err
+	}
+	reservationTTL, err := getEnvDuration("RESERVATION_TTL", "15m")
+	if err != nil {
+		return nil, err
+	}
+	reservationSweepInterval, err := getEnvDuration("RESERVATION_SWEEP_INTERVAL", "1m")
+	if err != nil {
+		return nil, err
+	}
+	guestCartTTL, err := getEnvDuration("GUEST_CART_TTL", "720h")
+	if err != nil {
+		return nil, err
+	}
+	guestCartSweepInterval, err := getEnvDuration("GUEST_CART_SWEEP_INTERVAL", "1h")
+	if err != nil {
+		return nil, err
+	}
 
 	return &Config{
 		Server: ServerConfig{
@@ -106,6 +154,24 @@ func Load() (*Config, error) {
 			Password: getEnv("SMTP_PASSWORD", ""),
 			From:     getEnv("SMTP_FROM", "noreply@shop.com"),
 		},
+		Payment: PaymentConfig{
+			PaymentProvider:  getEnv("PAYMENT_PROVIDER", "fake"),
+			WebhookSecret:    getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+			WebhookTolerance: webhookTolerance,
+		},
+		Currency: CurrencyConfig{
+			BaseCurrency: getEnv("BASE_CURRENCY", "USD"),
+		},
+		Cart: CartConfig{
+			GuestCartTTL:           guestCartTTL,
+			GuestCartSweepInterval: guestCartSweepInterval,
+		},
+		Inventory: InventoryConfig{
+			ReservationTTL:           reservationTTL,
+			ReservationSweepInterval: reservationSweepInterval,
+			AllocationStrategy:       getEnv("WAREHOUSE_ALLOCATION_STRATEGY", "priority"),
+			AlertEmails:              getEnvList("INVENTORY_ALERT_EMAILS"),
+		},
 	}, nil
 }
 
@@ -115,3 +181,28 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration parses a duration variable. Durations have to be positive, a typo must not
+// turn into a zero that disables what the duration configures.
+func getEnvDuration(key, defaultValue string) (time.Duration, error) {
+	value := getEnv(key, defaultValue)
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", key, value)
+	}
+	return duration, nil
+}
+
+// getEnvList splits a comma separated variable, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
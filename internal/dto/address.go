@@ -0,0 +1,44 @@
+package dto
+
+type AddressRequest struct {
+	Label             string `json:"label"`
+	FirstName         string `json:"first_name" binding:"required"`
+	LastName          string `json:"last_name" binding:"required"`
+	Phone             string `json:"phone"`
+	Line1             string `json:"line1" binding:"required"`
+	Line2             string `json:"line2"`
+	City              string `json:"city" binding:"required"`
+	State             string `json:"state"`
+	PostalCode        string `json:"postal_code" binding:"required"`
+	Country           string `json:"country" binding:"required,iso3166_1_alpha2"`
+	IsDefaultShipping bool   `json:"is_default_shipping"`
+	IsDefaultBilling  bool   `json:"is_default_billing"`
+}
+
+type AddressResponse struct {
+	ID                uint   `json:"id"`
+	Label             string `json:"label"`
+	FirstName         string `json:"first_name"`
+	LastName          string `json:"last_name"`
+	Phone             string `json:"phone"`
+	Line1             string `json:"line1"`
+	Line2             string `json:"line2"`
+	City              string `json:"city"`
+	State             string `json:"state"`
+	PostalCode        string `json:"postal_code"`
+	Country           string `json:"country"`
+	IsDefaultShipping bool   `json:"is_default_shipping"`
+	IsDefaultBilling  bool   `json:"is_default_billing"`
+}
+
+type OrderAddressResponse struct {
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Phone      string `json:"phone"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
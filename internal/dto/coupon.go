@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+type ApplyCouponRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type CouponRequest struct {
+	Code         string      `json:"code" binding:"required,max=100"`
+	Description  string      `json:"description"`
+	Type         string      `json:"type" binding:"required,oneof=percentage fixed free_shipping"`
+	PercentOff   money.Rate  `json:"percent_off" binding:"omitempty,gt=0,lte=100"`
+	AmountOff    money.Money `json:"amount_off" binding:"min=0"`
+	MinCartValue money.Money `json:"min_cart_value" binding:"min=0"`
+	UsageLimit   *int        `json:"usage_limit" binding:"omitempty,min=1"`
+	PerUserLimit *int        `json:"per_user_limit" binding:"omitempty,min=1"`
+	StartsAt     *time.Time  `json:"starts_at"`
+	EndsAt       *time.Time  `json:"ends_at"`
+	IsActive     *bool       `json:"is_active"`
+	CategoryIDs  []uint      `json:"category_ids"`
+	ProductIDs   []uint      `json:"product_ids"`
+}
+
+type CouponResponse struct {
+	ID           uint        `json:"id"`
+	Code         string      `json:"code"`
+	Description  string      `json:"description"`
+	Type         string      `json:"type"`
+	PercentOff   money.Rate  `json:"percent_off"`
+	AmountOff    money.Money `json:"amount_off"`
+	MinCartValue money.Money `json:"min_cart_value"`
+	UsageLimit   *int        `json:"usage_limit"`
+	PerUserLimit *int        `json:"per_user_limit"`
+	UsedCount    int         `json:"used_count"`
+	StartsAt     *time.Time  `json:"starts_at"`
+	EndsAt       *time.Time  `json:"ends_at"`
+	IsActive     bool        `json:"is_active"`
+	CategoryIDs  []uint      `json:"category_ids"`
+	ProductIDs   []uint      `json:"product_ids"`
+	CreatedAt    string      `json:"created_at"`
+}
+
+// DiscountLineResponse is one discount on a cart or order. Coupon discounts carry the code,
+// automatic promotions the promotion that produced them.
+type DiscountLineResponse struct {
+	Code          string      `json:"code,omitempty"`
+	PromotionID   *uint       `json:"promotion_id,omitempty"`
+	PromotionName string      `json:"promotion_name,omitempty"`
+	Description   string      `json:"description"`
+	Amount        money.Money `json:"amount"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
+type SetExchangeRateRequest struct {
+	Currency string     `json:"currency" binding:"required,iso4217"`
+	Rate     money.Rate `json:"rate" binding:"required,gt=0"`
+}
+
+type ExchangeRateResponse struct {
+	Currency  string     `json:"currency"`
+	Rate      money.Rate `json:"rate"`
+	UpdatedAt string     `json:"updated_at"`
+}
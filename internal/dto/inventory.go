@@ -0,0 +1,50 @@
+package dto
+
+type InventoryMovementResponse struct {
+	ID          uint   `json:"id"`
+	ProductID   uint   `json:"product_id"`
+	WarehouseID uint   `json:"warehouse_id"`
+	Delta       int    `json:"delta"`
+	Reason      string `json:"reason"`
+	ReferenceID *uint  `json:"reference_id"`
+	ActorID     *uint  `json:"actor_id"`
+	Note        string `json:"note"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// StockDiscrepancyResponse is a product whose stock does not match the sum of its ledger, of
+// its warehouse stock levels or of its variants' stock
+type StockDiscrepancyResponse struct {
+	ProductID      uint   `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	SKU            string `json:"sku"`
+	Stock          int    `json:"stock"`
+	LedgerStock    int    `json:"ledger_stock"`
+	WarehouseStock int    `json:"warehouse_stock"`
+	VariantStock   int    `json:"variant_stock"`
+	Difference     int    `json:"difference"`
+}
+
+type StockLevelResponse struct {
+	VariantID     uint   `json:"variant_id"`
+	SKU           string `json:"sku"`
+	WarehouseID   uint   `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	WarehouseName string `json:"warehouse_name"`
+	Stock         int    `json:"stock"`
+}
+
+type SetStockLevelRequest struct {
+	VariantID uint   `json:"variant_id"`
+	Stock     int    `json:"stock" binding:"min=0"`
+	Note      string `json:"note"`
+}
+
+type StockTransferRequest struct {
+	ProductID       uint   `json:"product_id" binding:"required"`
+	VariantID       uint   `json:"variant_id"`
+	FromWarehouseID uint   `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint   `json:"to_warehouse_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required,min=1"`
+	Note            string `json:"note"`
+}
@@ -1,7 +1,15 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
 type AddToCartRequest struct {
 	ProductID uint `json:"product_id" binding:"required"`
+	// VariantID picks the variant to buy, the product's default variant when left out
+	VariantID uint `json:"variant_id"`
 	Quantity  int  `json:"quantity" binding:"required,min=1"`
 }
 
@@ -10,31 +18,114 @@ type UpdateCartItemRequest struct {
 }
 
 type CartResponse struct {
-	ID        uint               `json:"id"`
-	UserID    uint               `json:"user_id"`
-	CartItems []CartItemResponse `json:"cart_items"`
-	Total     float64            `json:"total"`
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id,omitempty"`
+	// Token identifies a guest cart, it is sent back in the X-Cart-Token header or the
+	// cart_token cookie
+	Token         string                 `json:"token,omitempty"`
+	CartItems     []CartItemResponse     `json:"cart_items"`
+	Subtotal      money.Money            `json:"subtotal"`
+	CouponCode    string                 `json:"coupon_code,omitempty"`
+	CouponError   string                 `json:"coupon_error,omitempty"`
+	Discounts     []DiscountLineResponse `json:"discounts"`
+	DiscountTotal money.Money            `json:"discount_total"`
+	FreeShipping  bool                   `json:"free_shipping"`
+	TaxLines      []TaxLineResponse      `json:"tax_lines"`
+	TaxTotal      money.Money            `json:"tax_total"`
+	Total         money.Money            `json:"total"`
+	Currency      string                 `json:"currency"`
 }
 
 type CartItemResponse struct {
-	ID       uint            `json:"id"`
-	Product  ProductResponse `json:"product"`
-	Quantity int             `json:"quantity"`
-	Subtotal float64         `json:"subtotal"`
+	ID          uint            `json:"id"`
+	Product     ProductResponse `json:"product"`
+	VariantID   uint            `json:"variant_id"`
+	SKU         string          `json:"sku"`
+	VariantName string          `json:"variant_name"`
+	Price       money.Money     `json:"price"`
+	Quantity    int             `json:"quantity"`
+	Subtotal    money.Money     `json:"subtotal"`
+}
+
+type CreateOrderRequest struct {
+	ShippingAddressID uint `json:"shipping_address_id"`
+	BillingAddressID  uint `json:"billing_address_id"`
+}
+
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending confirmed shipped delivered cancelled"`
+	Note   string `json:"note"`
+}
+
+type AdminOrderListQuery struct {
+	Status   string       `form:"status" binding:"omitempty,oneof=pending confirmed shipped delivered cancelled"`
+	UserID   uint         `form:"user_id"`
+	From     time.Time    `form:"from" time_format:"2006-01-02"`
+	To       time.Time    `form:"to" time_format:"2006-01-02"`
+	MinTotal *money.Money `form:"min_total" binding:"omitempty,min=0"`
+	MaxTotal *money.Money `form:"max_total" binding:"omitempty,min=0"`
+	Sort     string       `form:"sort" binding:"omitempty,oneof=newest oldest total_asc total_desc"`
+	Page     int          `form:"page"`
+	Limit    int          `form:"limit"`
 }
 
 type OrderResponse struct {
-	ID          uint                `json:"id"`
-	UserID      uint                `json:"user_id"`
-	Status      string              `json:"status"`
-	TotalAmount float64             `json:"total_amount"`
-	OrderItems  []OrderItemResponse `json:"order_items"`
-	CreatedAt   string              `json:"created_at"`
+	ID              uint                         `json:"id"`
+	UserID          uint                         `json:"user_id"`
+	Status          string                       `json:"status"`
+	SubtotalAmount  money.Money                  `json:"subtotal_amount"`
+	Discounts       []DiscountLineResponse       `json:"discounts"`
+	DiscountAmount  money.Money                  `json:"discount_amount"`
+	FreeShipping    bool                         `json:"free_shipping"`
+	TaxLines        []TaxLineResponse            `json:"tax_lines"`
+	TaxAmount       money.Money                  `json:"tax_amount"`
+	TotalAmount     money.Money                  `json:"total_amount"`
+	Currency        string                       `json:"currency"`
+	ExchangeRate    money.Rate                   `json:"exchange_rate"`
+	BaseTotalAmount money.Money                  `json:"base_total_amount"`
+	OrderItems      []OrderItemResponse          `json:"order_items"`
+	ShippingAddress OrderAddressResponse         `json:"shipping_address"`
+	BillingAddress  OrderAddressResponse         `json:"billing_address"`
+	StatusHistory   []OrderStatusHistoryResponse `json:"status_history"`
+	Payments        []PaymentResponse            `json:"payments"`
+	Refunds         []RefundResponse             `json:"refunds"`
+	CreatedAt       string                       `json:"created_at"`
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	ChangedBy  *uint  `json:"changed_by"`
+	Note       string `json:"note"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type AdminOrderResponse struct {
+	OrderResponse
+	Customer UserResponse `json:"customer"`
 }
 
 type OrderItemResponse struct {
-	ID       uint            `json:"id"`
-	Product  ProductResponse `json:"product"`
-	Quantity int             `json:"quantity"`
-	Price    float64         `json:"price"`
+	ID             uint            `json:"id"`
+	Product        ProductResponse `json:"product"`
+	VariantID      uint            `json:"variant_id"`
+	SKU            string          `json:"sku"`
+	VariantName    string          `json:"variant_name"`
+	Quantity       int             `json:"quantity"`
+	Price          money.Money     `json:"price"`
+	DiscountAmount money.Money     `json:"discount_amount"`
+	TaxAmount      money.Money     `json:"tax_amount"`
+	TaxInclusive   bool            `json:"tax_inclusive"`
+	// Allocations are the warehouses the item ships from
+	Allocations []OrderItemAllocationResponse `json:"allocations"`
+}
+
+// TaxLineResponse is one tax in a cart or order. Inclusive taxes are already part of the
+// subtotal, exclusive ones are added to it.
+type TaxLineResponse struct {
+	Name          string      `json:"name"`
+	Rate          money.Rate  `json:"rate"`
+	Inclusive     bool        `json:"inclusive"`
+	TaxableAmount money.Money `json:"taxable_amount"`
+	Amount        money.Money `json:"amount"`
 }
@@ -0,0 +1,63 @@
+package dto
+
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
+type CreatePaymentRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required"`
+	Capture       bool   `json:"capture"`
+}
+
+type PaymentResponse struct {
+	ID             uint        `json:"id"`
+	OrderID        uint        `json:"order_id"`
+	Provider       string      `json:"provider"`
+	TransactionID  string      `json:"transaction_id"`
+	Amount         money.Money `json:"amount"`
+	RefundedAmount money.Money `json:"refunded_amount"`
+	Currency       string      `json:"currency"`
+	Status         string      `json:"status"`
+	FailureReason  string      `json:"failure_reason"`
+	CreatedAt      string      `json:"created_at"`
+}
+
+type PaymentWebhookEvent struct {
+	ID   string                  `json:"id" binding:"required"`
+	Type string                  `json:"type" binding:"required"`
+	Data PaymentWebhookEventData `json:"data"`
+}
+
+type PaymentWebhookEventData struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+}
+
+type CreateRefundRequest struct {
+	Items   []RefundItemRequest `json:"items" binding:"omitempty,dive"`
+	Reason  string              `json:"reason"`
+	Restock bool                `json:"restock"`
+}
+
+type RefundItemRequest struct {
+	OrderItemID uint `json:"order_item_id" binding:"required"`
+	Quantity    int  `json:"quantity" binding:"required,min=1"`
+}
+
+type RefundResponse struct {
+	ID            uint                 `json:"id"`
+	OrderID       uint                 `json:"order_id"`
+	PaymentID     uint                 `json:"payment_id"`
+	Amount        money.Money          `json:"amount"`
+	Currency      string               `json:"currency"`
+	Reason        string               `json:"reason"`
+	Restocked     bool                 `json:"restocked"`
+	Status        string               `json:"status"`
+	FailureReason string               `json:"failure_reason,omitempty"`
+	CreatedBy     uint                 `json:"created_by"`
+	Items         []RefundItemResponse `json:"items"`
+	CreatedAt     string               `json:"created_at"`
+}
+
+type RefundItemResponse struct {
+	OrderItemID uint        `json:"order_item_id"`
+	Quantity    int         `json:"quantity"`
+	Amount      money.Money `json:"amount"`
+}
@@ -1,57 +1,143 @@
 package dto
 
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
 type CreateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+	Name     string `json:"name" binding:"required"`
+	// Slug is generated from the name when left out
+	Slug        string `json:"slug" binding:"omitempty,slug"`
 	Description string `json:"description"`
 }
 
 type UpdateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
+	// ParentID moves the category under another one, 0 moves it to the top level. The
+	// parent stays as it is when left out.
+	ParentID *uint  `json:"parent_id"`
+	Name     string `json:"name" binding:"required"`
+	// Slug replaces the category's slug. When left out, renaming the category generates a
+	// new one. Old slugs keep leading to the category.
+	Slug        string `json:"slug" binding:"omitempty,slug"`
 	Description string `json:"description"`
 	IsActive    *bool  `json:"is_active"`
 }
 
 type CategoryResponse struct {
 	ID          uint   `json:"id"`
+	ParentID    *uint  `json:"parent_id,omitempty"`
 	Name        string `json:"name"`
+	Slug        string `json:"slug"`
 	Description string `json:"description"`
 	IsActive    bool   `json:"is_active"`
 }
 
+type CategoryTreeResponse struct {
+	CategoryResponse
+	Children []CategoryTreeResponse `json:"children"`
+}
+
 type CreateProductRequest struct {
-	CategoryID  uint    `json:"category_id" binding:"required"`
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	Stock       int     `json:"stock" binding:"min=0"`
-	SKU         string  `json:"sku" binding:"required"`
+	CategoryID       uint        `json:"category_id" binding:"required"`
+	Name             string      `json:"name" binding:"required"`
+	Slug             string      `json:"slug" binding:"omitempty,slug"` // generated from the name when left out
+	Description      string      `json:"description"`
+	Price            money.Money `json:"price" binding:"required,gt=0"`
+	Stock            int         `json:"stock" binding:"min=0"`
+	SKU              string      `json:"sku" binding:"required"`
+	TaxClass         string      `json:"tax_class" binding:"omitempty,oneof=standard reduced zero"`
+	ReorderThreshold int         `json:"reorder_threshold" binding:"min=0"`
+	// Variants replace the single default variant the product gets otherwise, the first one
+	// becomes the default. Their stock replaces Stock.
+	Variants []ProductVariantRequest `json:"variants" binding:"omitempty,dive"`
 }
 
 type UpdateProductRequest struct {
-	CategoryID  uint    `json:"category_id" binding:"required"`
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	Stock       int     `json:"stock" binding:"min=0"`
-	IsActive    *bool   `json:"is_active"`
+	CategoryID       uint        `json:"category_id" binding:"required"`
+	Name             string      `json:"name" binding:"required"`
+	Slug             string      `json:"slug" binding:"omitempty,slug"` // renaming generates a new slug when left out
+	Description      string      `json:"description"`
+	Price            money.Money `json:"price" binding:"required,gt=0"`
+	Stock            int         `json:"stock" binding:"min=0"`
+	TaxClass         string      `json:"tax_class" binding:"omitempty,oneof=standard reduced zero"`
+	IsActive         *bool       `json:"is_active"`
+	ReorderThreshold int         `json:"reorder_threshold" binding:"min=0"`
+}
+
+// ProductVariantRequest describes a variant by its option values, such as
+// {"size": "M", "color": "red"}. Options and values a product does not have yet are added.
+type ProductVariantRequest struct {
+	SKU      string            `json:"sku" binding:"required"`
+	Price    *money.Money      `json:"price" binding:"omitempty,gt=0"`
+	Stock    int               `json:"stock" binding:"min=0"`
+	Options  map[string]string `json:"options"`
+	IsActive *bool             `json:"is_active"`
+}
+
+// ProductListQuery filters and sorts the storefront product listing. Prices are in the base
+// currency.
+type ProductListQuery struct {
+	CategoryID uint         `form:"category_id"`
+	MinPrice   *money.Money `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice   *money.Money `form:"max_price" binding:"omitempty,min=0"`
+	InStock    bool         `form:"in_stock"`
+	Q          string       `form:"q" binding:"max=100"`
+	Sort       string       `form:"sort" binding:"omitempty,oneof=price_asc price_desc newest name"`
+	Page       int          `form:"page"`
+	Limit      int          `form:"limit"`
 }
 
 type ProductResponse struct {
-	ID          uint                   `json:"id"`
-	CategoryID  uint                   `json:"category_id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Price       float64                `json:"price"`
-	Stock       int                    `json:"stock"`
-	SKU         string                 `json:"sku"`
-	IsActive    bool                   `json:"is_active"`
-	Category    CategoryResponse       `json:"category"`
-	Images      []ProductImageResponse `json:"images"`
+	ID               uint                   `json:"id"`
+	CategoryID       uint                   `json:"category_id"`
+	Name             string                 `json:"name"`
+	Slug             string                 `json:"slug"`
+	Description      string                 `json:"description"`
+	Price            money.Money            `json:"price"`
+	Currency         string                 `json:"currency"`
+	Stock            int                    `json:"stock"`
+	SKU              string                 `json:"sku"`
+	TaxClass         string                 `json:"tax_class"`
+	IsActive         bool                   `json:"is_active"`
+	Category         CategoryResponse       `json:"category"`
+	Images           []ProductImageResponse `json:"images"`
+	ReorderThreshold int                    `json:"reorder_threshold,omitempty"`
+	AverageRating    float64                `json:"average_rating"`
+	ReviewCount      int                    `json:"review_count"`
+	// Options are the option matrix, the values each option can take
+	Options  []ProductOptionResponse  `json:"options"`
+	Variants []ProductVariantResponse `json:"variants"`
+}
+
+type ProductOptionResponse struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductVariantResponse struct {
+	ID        uint                   `json:"id"`
+	SKU       string                 `json:"sku"`
+	Price     money.Money            `json:"price"`
+	Stock     int                    `json:"stock"`
+	IsDefault bool                   `json:"is_default"`
+	IsActive  bool                   `json:"is_active"`
+	Options   map[string]string      `json:"options"`
+	Images    []ProductImageResponse `json:"images"`
 }
 
 type ProductImageResponse struct {
 	ID        uint   `json:"id"`
+	VariantID *uint  `json:"variant_id,omitempty"`
 	URL       string `json:"url"`
 	AltText   string `json:"alt_text"`
 	IsPrimary bool   `json:"is_primary"`
 }
+
+type BackInStockSubscriptionResponse struct {
+	ProductID uint   `json:"product_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SlugRedirectResponse points from a slug a product or category had before to its current one
+type SlugRedirectResponse struct {
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+type PromotionTierRequest struct {
+	MinQuantity int        `json:"min_quantity" binding:"required,min=1"`
+	PercentOff  money.Rate `json:"percent_off" binding:"required,gt=0,lte=100"`
+}
+
+type PromotionRequest struct {
+	Name         string                 `json:"name" binding:"required,max=255"`
+	Description  string                 `json:"description"`
+	Type         string                 `json:"type" binding:"required,oneof=percentage fixed buy_x_get_y tiered free_shipping"`
+	Priority     int                    `json:"priority"`
+	Stackable    bool                   `json:"stackable"`
+	PercentOff   money.Rate             `json:"percent_off" binding:"omitempty,gt=0,lte=100"`
+	AmountOff    money.Money            `json:"amount_off" binding:"min=0"`
+	MinCartValue money.Money            `json:"min_cart_value" binding:"min=0"`
+	BuyQuantity  int                    `json:"buy_quantity" binding:"min=0"`
+	GetQuantity  int                    `json:"get_quantity" binding:"min=0"`
+	Tiers        []PromotionTierRequest `json:"tiers" binding:"dive"`
+	StartsAt     *time.Time             `json:"starts_at"`
+	EndsAt       *time.Time             `json:"ends_at"`
+	IsActive     *bool                  `json:"is_active"`
+	CategoryIDs  []uint                 `json:"category_ids"`
+	ProductIDs   []uint                 `json:"product_ids"`
+}
+
+type PromotionTierResponse struct {
+	MinQuantity int        `json:"min_quantity"`
+	PercentOff  money.Rate `json:"percent_off"`
+}
+
+type PromotionResponse struct {
+	ID           uint                    `json:"id"`
+	Name         string                  `json:"name"`
+	Description  string                  `json:"description"`
+	Type         string                  `json:"type"`
+	Priority     int                     `json:"priority"`
+	Stackable    bool                    `json:"stackable"`
+	PercentOff   money.Rate              `json:"percent_off"`
+	AmountOff    money.Money             `json:"amount_off"`
+	MinCartValue money.Money             `json:"min_cart_value"`
+	BuyQuantity  int                     `json:"buy_quantity"`
+	GetQuantity  int                     `json:"get_quantity"`
+	Tiers        []PromotionTierResponse `json:"tiers"`
+	StartsAt     *time.Time              `json:"starts_at"`
+	EndsAt       *time.Time              `json:"ends_at"`
+	IsActive     bool                    `json:"is_active"`
+	CategoryIDs  []uint                  `json:"category_ids"`
+	ProductIDs   []uint                  `json:"product_ids"`
+	CreatedAt    string                  `json:"created_at"`
+}
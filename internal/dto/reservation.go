@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type ReservationItemResponse struct {
+	ProductID   uint   `json:"product_id"`
+	VariantID   uint   `json:"variant_id"`
+	SKU         string `json:"sku"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// CheckoutResponse lists the stock held for the user until ExpiresAt
+type CheckoutResponse struct {
+	Items     []ReservationItemResponse `json:"items"`
+	ExpiresAt time.Time                 `json:"expires_at"`
+}
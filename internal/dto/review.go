@@ -0,0 +1,36 @@
+package dto
+
+type ReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"required,max=255"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+type ReviewListQuery struct {
+	Rating int    `form:"rating" binding:"omitempty,min=1,max=5"`
+	Sort   string `form:"sort" binding:"omitempty,oneof=newest oldest rating_desc rating_asc"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+type AdminReviewListQuery struct {
+	Status    string `form:"status" binding:"omitempty,oneof=pending approved hidden"`
+	ProductID uint   `form:"product_id"`
+	Page      int    `form:"page"`
+	Limit     int    `form:"limit"`
+}
+
+type ReviewResponse struct {
+	ID        uint `json:"id"`
+	ProductID uint `json:"product_id"`
+	UserID    uint `json:"user_id"`
+	// Author is the first name and last initial of the reviewer
+	Author           string `json:"author"`
+	Rating           int    `json:"rating"`
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	VerifiedPurchase bool   `json:"verified_purchase"`
+	Status           string `json:"status"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
+}
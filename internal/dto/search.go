@@ -0,0 +1,47 @@
+package dto
+
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
+type SearchQuery struct {
+	Q     string `form:"q" binding:"required,max=100"`
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+}
+
+// SearchResponse is a page of search results with facets counted over all matches
+type SearchResponse struct {
+	Results []SearchResultResponse `json:"results"`
+	Facets  SearchFacetsResponse   `json:"facets"`
+}
+
+type SearchResultResponse struct {
+	Product ProductResponse `json:"product"`
+	Rank    float64         `json:"rank"`
+	// Highlights are the HTML escaped name and description with the matching words wrapped in
+	// <mark> tags
+	Highlights SearchHighlightsResponse `json:"highlights"`
+}
+
+type SearchHighlightsResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SearchFacetsResponse struct {
+	Categories   []CategoryFacetResponse `json:"categories"`
+	PriceBuckets []PriceBucketResponse   `json:"price_buckets"`
+}
+
+type CategoryFacetResponse struct {
+	CategoryID uint   `json:"category_id"`
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+}
+
+// PriceBucketResponse counts the matches priced from Min up to but excluding Max, in the base
+// currency. The last bucket has no Max.
+type PriceBucketResponse struct {
+	Min   money.Money  `json:"min"`
+	Max   *money.Money `json:"max"`
+	Count int64        `json:"count"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+type WarehouseRequest struct {
+	Code     string `json:"code" binding:"required,max=50"`
+	Name     string `json:"name" binding:"required,max=255"`
+	Country  string `json:"country" binding:"omitempty,len=2"`
+	Region   string `json:"region"`
+	Priority int    `json:"priority"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type WarehouseResponse struct {
+	ID         uint   `json:"id"`
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Country    string `json:"country"`
+	Region     string `json:"region"`
+	Priority   int    `json:"priority"`
+	IsActive   bool   `json:"is_active"`
+	TotalStock int    `json:"total_stock"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type OrderItemAllocationResponse struct {
+	WarehouseID   uint   `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	Quantity      int    `json:"quantity"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
+type WishlistRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type AddWishlistItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	// VariantID picks a variant, the product's default variant when left out
+	VariantID uint `json:"variant_id"`
+}
+
+type MoveToCartRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1"` // 1 when left out
+}
+
+type WishlistResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	// ShareToken is the token of the public link while the wishlist is shared, only shown
+	// to its owner
+	ShareToken string                 `json:"share_token,omitempty"`
+	Items      []WishlistItemResponse `json:"items"`
+	CreatedAt  string                 `json:"created_at"`
+	UpdatedAt  string                 `json:"updated_at"`
+}
+
+type WishlistItemResponse struct {
+	ID          uint            `json:"id"`
+	Product     ProductResponse `json:"product"`
+	VariantID   uint            `json:"variant_id,omitempty"`
+	SKU         string          `json:"sku"`
+	VariantName string          `json:"variant_name"`
+	Price       money.Money     `json:"price"`
+	InStock     bool            `json:"in_stock"`
+	AddedAt     string          `json:"added_at"`
+}
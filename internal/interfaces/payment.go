@@ -0,0 +1,38 @@
+package interfaces
+
+import (
+	"errors"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+var (
+	// ErrPaymentDeclined is returned when the gateway refuses the payment method
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrPaymentTimeout is returned when the gateway did not answer in time
+	ErrPaymentTimeout = errors.New("payment gateway timeout")
+)
+
+type PaymentRequest struct {
+	OrderID       uint
+	Amount        money.Money
+	PaymentMethod string
+	// IdempotencyKey makes an authorization asked for again with the same key answered without
+	// authorizing twice
+	IdempotencyKey string
+}
+
+type PaymentResult struct {
+	TransactionID string
+	Amount        money.Money
+}
+
+type PaymentProvider interface {
+	Name() string
+	Authorize(req *PaymentRequest) (*PaymentResult, error)
+	Capture(transactionID string, amount money.Money) (*PaymentResult, error)
+	Void(transactionID string) (*PaymentResult, error)
+	// Refund gives money back for a captured transaction. A refund asked for again with the
+	// same idempotency key is answered without refunding twice.
+	Refund(transactionID string, amount money.Money, idempotencyKey string) (*PaymentResult, error)
+}
@@ -0,0 +1,50 @@
+package interfaces
+
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
+// TaxAddress is the destination taxes are calculated for
+type TaxAddress struct {
+	Country string // ISO 3166-1 alpha-2
+	Region  string // state or province, optional
+}
+
+// TaxableItem is a cart or order line
+type TaxableItem struct {
+	TaxClass string
+	Amount   money.Money // line total as priced in the catalogue
+}
+
+type TaxRequest struct {
+	Address TaxAddress
+	Items   []TaxableItem
+}
+
+// TaxedItem is the tax on one TaxableItem, in the same order as the request
+type TaxedItem struct {
+	Tax       money.Money
+	Inclusive bool
+}
+
+// TaxLine is the total of one tax rule across all items
+type TaxLine struct {
+	Name          string
+	Country       string
+	Region        string
+	TaxClass      string
+	Rate          money.Rate
+	Inclusive     bool
+	TaxableAmount money.Money // net amount the rate was applied to
+	Amount        money.Money
+}
+
+type TaxResult struct {
+	Subtotal money.Money // sum of the item amounts as priced
+	Items    []TaxedItem
+	Lines    []TaxLine
+	Tax      money.Money // included and added tax
+	Total    money.Money // subtotal plus the tax that is added on top
+}
+
+type TaxCalculator interface {
+	Calculate(req *TaxRequest) (*TaxResult, error)
+}
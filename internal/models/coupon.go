@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+)
+
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixed        CouponType = "fixed"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+)
+
+// Coupon is a discount code customers can apply to their cart. When Categories or Products
+// are set the discount only applies to matching items.
+type Coupon struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Code         string         `json:"code" gorm:"uniqueIndex;not null"`
+	Description  string         `json:"description"`
+	Type         CouponType     `json:"type" gorm:"not null"`
+	PercentOff   money.Rate     `json:"percent_off" gorm:"type:decimal(5,2);not null;default:0"`
+	AmountOff    money.Money    `json:"amount_off" gorm:"not null;default:0"`
+	MinCartValue money.Money    `json:"min_cart_value" gorm:"not null;default:0"`
+	UsageLimit   *int           `json:"usage_limit"`
+	PerUserLimit *int           `json:"per_user_limit"`
+	UsedCount    int            `json:"used_count" gorm:"default:0"`
+	StartsAt     *time.Time     `json:"starts_at"`
+	EndsAt       *time.Time     `json:"ends_at"`
+	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Categories []Category `json:"categories" gorm:"many2many:coupon_categories"`
+	Products   []Product  `json:"products" gorm:"many2many:coupon_products"`
+}
+
+// CouponRedemption records a coupon used by an order
+type CouponRedemption struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	CouponID  uint        `json:"coupon_id" gorm:"not null"`
+	UserID    uint        `json:"user_id" gorm:"not null"`
+	OrderID   uint        `json:"order_id" gorm:"uniqueIndex;not null"`
+	Amount    money.Money `json:"amount" gorm:"not null"`
+	CreatedAt time.Time   `json:"created_at"`
+
+	// Relationships
+	Coupon Coupon `json:"-"`
+	Order  Order  `json:"-"`
+}
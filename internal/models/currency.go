@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+// ExchangeRate is the admin-managed rate used to display base currency prices in another currency
+type ExchangeRate struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Currency  string     `json:"currency" gorm:"size:3;uniqueIndex;not null"`
+	Rate      money.Rate `json:"rate" gorm:"type:decimal(18,8);not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
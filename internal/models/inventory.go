@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationStatusActive    ReservationStatus = "active"
+	ReservationStatusCommitted ReservationStatus = "committed"
+	ReservationStatusReleased  ReservationStatus = "released"
+)
+
+// StockReservation holds stock for a user between the start of checkout and the order.
+// The reserved quantity is taken off Product.Stock while the reservation is active, and
+// given back when it is released or expires.
+type StockReservation struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	UserID      uint              `json:"user_id" gorm:"not null"`
+	ProductID   uint              `json:"product_id" gorm:"not null"`
+	VariantID   uint              `json:"variant_id" gorm:"not null"`
+	WarehouseID uint              `json:"warehouse_id" gorm:"not null"`
+	Quantity    int               `json:"quantity" gorm:"not null"`
+	Status      ReservationStatus `json:"status" gorm:"not null;default:active"`
+	ExpiresAt   time.Time         `json:"expires_at" gorm:"not null"`
+	OrderID     *uint             `json:"order_id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	// Relationships
+	User    User    `json:"-"`
+	Product Product `json:"product"`
+}
+
+type MovementReason string
+
+const (
+	MovementReasonSale               MovementReason = "sale"
+	MovementReasonCancellation       MovementReason = "cancellation"
+	MovementReasonAdjustment         MovementReason = "adjustment"
+	MovementReasonReturn             MovementReason = "return"
+	MovementReasonImport             MovementReason = "import"
+	MovementReasonReservation        MovementReason = "reservation"
+	MovementReasonReservationRelease MovementReason = "reservation_release"
+	MovementReasonTransfer           MovementReason = "transfer"
+)
+
+// InventoryMovement is one change to a product's stock. The ledger is append-only, so the
+// sum of a product's movements always explains its current Product.Stock. ReferenceID
+// points at the order, refund or reservation the reason implies.
+type InventoryMovement struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	ProductID   uint           `json:"product_id" gorm:"not null"`
+	VariantID   uint           `json:"variant_id" gorm:"not null"`
+	WarehouseID uint           `json:"warehouse_id" gorm:"not null"`
+	Delta       int            `json:"delta" gorm:"not null"`
+	Reason      MovementReason `json:"reason" gorm:"not null"`
+	ReferenceID *uint          `json:"reference_id"`
+	ActorID     *uint          `json:"actor_id"`
+	Note        string         `json:"note"`
+	CreatedAt   time.Time      `json:"created_at"`
+
+	// Relationships
+	Product   Product   `json:"-"`
+	Warehouse Warehouse `json:"-"`
+	Actor     *User     `json:"-" gorm:"foreignKey:ActorID"`
+}
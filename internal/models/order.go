@@ -3,21 +3,35 @@ package models
 import (
 	"time"
 
+	"github.com/veetmoradiya3628/go-shop/internal/money"
 	"gorm.io/gorm"
 )
 
 type Order struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	UserID      uint           `json:"user_id" gorm:"not null"`
-	Status      OrderStatus    `json:"status" gorm:"default:pending"`
-	TotalAmount float64        `json:"total_amount" gorm:"not null"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	UserID          uint            `json:"user_id" gorm:"not null"`
+	Status          OrderStatus     `json:"status" gorm:"default:pending"`
+	SubtotalAmount  money.Money     `json:"subtotal_amount" gorm:"not null"`
+	DiscountAmount  money.Money     `json:"discount_amount" gorm:"not null;default:0"`
+	FreeShipping    bool            `json:"free_shipping" gorm:"default:false"`
+	TaxAmount       money.Money     `json:"tax_amount" gorm:"not null"`
+	TotalAmount     money.Money     `json:"total_amount" gorm:"not null"`
+	Currency        string          `json:"currency" gorm:"size:3;not null;default:USD"`
+	ExchangeRate    money.Rate      `json:"exchange_rate" gorm:"type:decimal(18,8);not null;default:1"`
+	ShippingAddress AddressSnapshot `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
+	BillingAddress  AddressSnapshot `json:"billing_address" gorm:"embedded;embeddedPrefix:billing_"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	// Relationships
-	User       User        `json:"user"`
-	OrderItems []OrderItem `json:"order_items"`
+	User          User                 `json:"user"`
+	OrderItems    []OrderItem          `json:"order_items"`
+	StatusHistory []OrderStatusHistory `json:"status_history"`
+	Discounts     []OrderDiscount      `json:"discounts"`
+	TaxLines      []OrderTaxLine       `json:"tax_lines"`
+	Payments      []Payment            `json:"payments"`
+	Refunds       []Refund             `json:"refunds"`
 }
 
 type OrderStatus string
@@ -30,41 +44,103 @@ const (
 	OrderStatusCancelled OrderStatus = "cancelled"
 )
 
+type OrderStatusHistory struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	OrderID    uint         `json:"order_id" gorm:"not null"`
+	FromStatus *OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus  `json:"to_status" gorm:"not null"`
+	ChangedBy  *uint        `json:"changed_by"`
+	Note       string       `json:"note"`
+	CreatedAt  time.Time    `json:"created_at"`
+
+	// Relationships
+	Order Order `json:"-"`
+}
+
+// OrderDiscount is a discount applied to an order at checkout
+type OrderDiscount struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	OrderID       uint        `json:"order_id" gorm:"not null"`
+	CouponID      *uint       `json:"coupon_id"`
+	PromotionID   *uint       `json:"promotion_id"`
+	PromotionName string      `json:"promotion_name"`
+	Code          string      `json:"code"`
+	Description   string      `json:"description"`
+	Amount        money.Money `json:"amount" gorm:"not null"`
+	CreatedAt     time.Time   `json:"created_at"`
+
+	// Relationships
+	Order Order `json:"-"`
+}
+
+// OrderTaxLine is the tax breakdown persisted on an order at checkout
+type OrderTaxLine struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	OrderID       uint        `json:"order_id" gorm:"not null"`
+	Name          string      `json:"name" gorm:"not null"`
+	Country       string      `json:"country" gorm:"size:2;not null"`
+	Region        string      `json:"region"`
+	TaxClass      string      `json:"tax_class" gorm:"not null"`
+	Rate          money.Rate  `json:"rate" gorm:"type:decimal(8,6);not null"`
+	Inclusive     bool        `json:"inclusive" gorm:"default:false"`
+	TaxableAmount money.Money `json:"taxable_amount" gorm:"not null"`
+	Amount        money.Money `json:"amount" gorm:"not null"`
+	CreatedAt     time.Time   `json:"created_at"`
+
+	// Relationships
+	Order Order `json:"-"`
+}
+
 type OrderItem struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	OrderID   uint           `json:"order_id" gorm:"not null"`
-	ProductID uint           `json:"product_id" gorm:"not null"`
-	Quantity  int            `json:"quantity" gorm:"not null"`
-	Price     float64        `json:"price" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrderID        uint           `json:"order_id" gorm:"not null"`
+	ProductID      uint           `json:"product_id" gorm:"not null"`
+	VariantID      uint           `json:"variant_id" gorm:"not null"`
+	SKU            string         `json:"sku"`
+	VariantName    string         `json:"variant_name"`
+	Quantity       int            `json:"quantity" gorm:"not null"`
+	Price          money.Money    `json:"price" gorm:"not null"`
+	DiscountAmount money.Money    `json:"discount_amount" gorm:"not null;default:0"`
+	TaxAmount      money.Money    `json:"tax_amount" gorm:"not null"`
+	TaxInclusive   bool           `json:"tax_inclusive" gorm:"default:false"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Order   Order   `json:"-"`
-	Product Product `json:"product"`
+	Order       Order                 `json:"-"`
+	Product     Product               `json:"product"`
+	Variant     ProductVariant        `json:"-"`
+	Allocations []OrderItemAllocation `json:"allocations"`
 }
 
+// Cart is the cart of a user, or of a guest identified by its token. A guest cart is merged
+// into the user's cart when the guest signs in.
 type Cart struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"uniqueIndex;not null"`
+	UserID    *uint          `json:"user_id" gorm:"uniqueIndex"`
+	Token     *string        `json:"-" gorm:"size:36;uniqueIndex"`
+	CouponID  *uint          `json:"coupon_id"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
 	CartItems []CartItem `json:"cart_items"`
+	Coupon    *Coupon    `json:"coupon"`
 }
 
 type CartItem struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	CartID    uint           `json:"cart_id" gorm:"not null"`
 	ProductID uint           `json:"product_id" gorm:"not null"`
+	VariantID uint           `json:"variant_id" gorm:"not null"`
 	Quantity  int            `json:"quantity" gorm:"not null"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Cart    Cart    `json:"-"`
-	Product Product `json:"product"`
+	Cart    Cart           `json:"-"`
+	Product Product        `json:"product"`
+	Variant ProductVariant `json:"variant"`
 }
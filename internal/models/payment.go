@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+)
+
+// Payment is recorded as pending before the gateway is asked to authorize it and as capturing
+// while the gateway captures it, so money the gateway holds always has its payment.
+type Payment struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrderID        uint           `json:"order_id" gorm:"not null"`
+	Provider       string         `json:"provider" gorm:"not null"`
+	TransactionID  string         `json:"transaction_id"`
+	Amount         money.Money    `json:"amount" gorm:"not null"`
+	RefundedAmount money.Money    `json:"refunded_amount" gorm:"default:0"`
+	Status         PaymentStatus  `json:"status" gorm:"not null"`
+	FailureReason  string         `json:"failure_reason"`
+	IdempotencyKey string         `json:"-"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Order Order `json:"-"`
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending           PaymentStatus = "pending"
+	PaymentStatusAuthorized        PaymentStatus = "authorized"
+	PaymentStatusCapturing         PaymentStatus = "capturing"
+	PaymentStatusCaptured          PaymentStatus = "captured"
+	PaymentStatusVoided            PaymentStatus = "voided"
+	PaymentStatusFailed            PaymentStatus = "failed"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
+// Refund is recorded as pending before the gateway is asked to pay it, and completed or failed
+// with its answer. Pending refunds already claim their quantities and amount.
+type Refund struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	OrderID        uint         `json:"order_id" gorm:"not null"`
+	PaymentID      uint         `json:"payment_id" gorm:"not null"`
+	Amount         money.Money  `json:"amount" gorm:"not null"`
+	Reason         string       `json:"reason"`
+	Restocked      bool         `json:"restocked" gorm:"default:false"`
+	Status         RefundStatus `json:"status" gorm:"not null"`
+	FailureReason  string       `json:"failure_reason"`
+	IdempotencyKey string       `json:"-"`
+	CreatedBy      uint         `json:"created_by" gorm:"not null"`
+	CreatedAt      time.Time    `json:"created_at"`
+
+	// Relationships
+	Order   Order        `json:"-"`
+	Payment Payment      `json:"-"`
+	Items   []RefundItem `json:"items"`
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusCompleted RefundStatus = "completed"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+type RefundItem struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	RefundID    uint        `json:"refund_id" gorm:"not null"`
+	OrderItemID uint        `json:"order_item_id" gorm:"not null"`
+	Quantity    int         `json:"quantity" gorm:"not null"`
+	Amount      money.Money `json:"amount" gorm:"not null"`
+
+	// Relationships
+	Refund    Refund    `json:"-"`
+	OrderItem OrderItem `json:"-"`
+}
+
+// WebhookEvent records a processed webhook delivery so redeliveries are not applied twice
+type WebhookEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	EventID     string    `json:"event_id" gorm:"uniqueIndex;not null"`
+	EventType   string    `json:"event_type" gorm:"not null"`
+	ProcessedAt time.Time `json:"processed_at" gorm:"autoCreateTime"`
+}
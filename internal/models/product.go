@@ -1,14 +1,18 @@
 package models
 
 import (
+	"math"
 	"time"
 
+	"github.com/veetmoradiya3628/go-shop/internal/money"
 	"gorm.io/gorm"
 )
 
 type Category struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"`
 	Name        string         `json:"name" gorm:"not null"`
+	Slug        string         `json:"slug" gorm:"size:110;not null"`
 	Description string         `json:"description"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -16,32 +20,61 @@ type Category struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Products []Product `json:"-"`
+	Parent   *Category  `json:"-"`
+	Children []Category `json:"-" gorm:"foreignKey:ParentID"`
+	Products []Product  `json:"-"`
 }
 
+// StockAlert is the last stock alert sent for a product. Alerts are only sent when the
+// stock falls into a more severe level than the one recorded.
+type StockAlert string
+
+const (
+	StockAlertNone StockAlert = "none"
+	StockAlertLow  StockAlert = "low"
+	StockAlertOut  StockAlert = "out"
+)
+
 type Product struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	CategoryID  uint           `json:"category_id" gorm:"not null"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Price       float64        `json:"price" gorm:"not null"`
-	Stock       int            `json:"stock" gorm:"default:0"`
-	SKU         string         `json:"sku" gorm:"uniqueIndex;not null"`
-	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	CategoryID       uint           `json:"category_id" gorm:"not null"`
+	Name             string         `json:"name" gorm:"not null"`
+	Slug             string         `json:"slug" gorm:"size:110;not null"`
+	Description      string         `json:"description"`
+	Price            money.Money    `json:"price" gorm:"not null"`
+	Stock            int            `json:"stock" gorm:"default:0"`
+	SKU              string         `json:"sku" gorm:"uniqueIndex;not null"`
+	TaxClass         string         `json:"tax_class" gorm:"size:50;not null;default:standard"`
+	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	ReorderThreshold int            `json:"reorder_threshold" gorm:"not null;default:0"`
+	StockAlert       StockAlert     `json:"-" gorm:"not null;default:none"`
+	ReviewCount      int            `json:"review_count" gorm:"not null;default:0"`
+	RatingTotal      int            `json:"-" gorm:"not null;default:0"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Category   Category       `json:"category"`
-	Images     []ProductImage `json:"images"`
-	OrderItems []OrderItem    `json:"-"`
-	CartItems  []CartItem     `json:"-"`
+	Category   Category         `json:"category"`
+	Images     []ProductImage   `json:"images"`
+	Options    []ProductOption  `json:"options"`
+	Variants   []ProductVariant `json:"variants"`
+	OrderItems []OrderItem      `json:"-"`
+	CartItems  []CartItem       `json:"-"`
+}
+
+// AverageRating is the mean rating of the product's approved reviews, 0 without any
+func (p *Product) AverageRating() float64 {
+	if p.ReviewCount == 0 {
+		return 0
+	}
+	return math.Round(float64(p.RatingTotal)/float64(p.ReviewCount)*100) / 100
 }
 
 type ProductImage struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	ProductID uint           `json:"product_id" gorm:"not null"`
+	VariantID *uint          `json:"variant_id"`
 	URL       string         `json:"url" gorm:"not null"`
 	AltText   string         `json:"alt_text"`
 	IsPrimary bool           `json:"is_primary" gorm:"default:false"`
@@ -51,3 +84,16 @@ type ProductImage struct {
 	// Relationships
 	Product Product `json:"-"`
 }
+
+// BackInStockSubscription asks for an email once an out of stock product can be ordered
+// again. Subscriptions are removed when the email goes out.
+type BackInStockSubscription struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_back_in_stock_user_product"`
+	ProductID uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_back_in_stock_user_product"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User    User    `json:"-"`
+	Product Product `json:"-"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+)
+
+type PromotionType string
+
+const (
+	// PromotionTypePercentage takes PercentOff off the qualifying items
+	PromotionTypePercentage PromotionType = "percentage"
+	// PromotionTypeFixed takes AmountOff off the qualifying items
+	PromotionTypeFixed PromotionType = "fixed"
+	// PromotionTypeBuyXGetY gives GetQuantity of every BuyQuantity+GetQuantity qualifying units
+	// for free, cheapest units first
+	PromotionTypeBuyXGetY PromotionType = "buy_x_get_y"
+	// PromotionTypeTiered takes the percentage of the highest tier reached by the quantity of
+	// qualifying units
+	PromotionTypeTiered PromotionType = "tiered"
+	// PromotionTypeFreeShipping ships the order for free
+	PromotionTypeFreeShipping PromotionType = "free_shipping"
+)
+
+// Promotion is a discount applied automatically to every cart it qualifies for. Promotions
+// are evaluated by descending priority; a promotion that is not stackable only applies when
+// no other promotion did, and stops the evaluation once applied.
+type Promotion struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"not null"`
+	Description  string         `json:"description"`
+	Type         PromotionType  `json:"type" gorm:"not null"`
+	Priority     int            `json:"priority" gorm:"not null;default:0"`
+	Stackable    bool           `json:"stackable" gorm:"default:false"`
+	PercentOff   money.Rate     `json:"percent_off" gorm:"type:decimal(5,2);not null;default:0"`
+	AmountOff    money.Money    `json:"amount_off" gorm:"not null;default:0"`
+	MinCartValue money.Money    `json:"min_cart_value" gorm:"not null;default:0"`
+	BuyQuantity  int            `json:"buy_quantity" gorm:"not null;default:0"`
+	GetQuantity  int            `json:"get_quantity" gorm:"not null;default:0"`
+	StartsAt     *time.Time     `json:"starts_at"`
+	EndsAt       *time.Time     `json:"ends_at"`
+	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Tiers      []PromotionTier `json:"tiers"`
+	Categories []Category      `json:"categories" gorm:"many2many:promotion_categories"`
+	Products   []Product       `json:"products" gorm:"many2many:promotion_products"`
+}
+
+// PromotionTier is one step of a tiered promotion
+type PromotionTier struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	PromotionID uint       `json:"promotion_id" gorm:"not null"`
+	MinQuantity int        `json:"min_quantity" gorm:"not null"`
+	PercentOff  money.Rate `json:"percent_off" gorm:"type:decimal(5,2);not null"`
+}
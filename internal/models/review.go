@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewStatus is where a review stands in moderation. Only approved reviews are shown
+// and count towards the product's rating.
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusHidden   ReviewStatus = "hidden"
+)
+
+type Review struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ProductID uint   `json:"product_id" gorm:"not null"`
+	UserID    uint   `json:"user_id" gorm:"not null"`
+	Rating    int    `json:"rating" gorm:"not null"`
+	Title     string `json:"title" gorm:"not null"`
+	Body      string `json:"body"`
+	// VerifiedPurchase is set when the author has a delivered order with the product
+	VerifiedPurchase bool           `json:"verified_purchase" gorm:"not null;default:false"`
+	Status           ReviewStatus   `json:"status" gorm:"size:20;not null;default:pending"`
+	ModeratedBy      *uint          `json:"moderated_by"`
+	ModeratedAt      *time.Time     `json:"moderated_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Product Product `json:"-"`
+	User    User    `json:"-"`
+}
+
+// Counted reports whether the review counts towards its product's rating
+func (r *Review) Counted() bool {
+	return r.Status == ReviewStatusApproved
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Resources that have slugs
+const (
+	SlugResourceProduct  = "product"
+	SlugResourceCategory = "category"
+)
+
+// SlugRedirect is a slug a product or category had before, kept so old links can be
+// pointed at its current slug
+type SlugRedirect struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Resource  string    `json:"resource" gorm:"size:20;not null;uniqueIndex:idx_slug_redirects_resource_slug"`
+	Slug      string    `json:"slug" gorm:"size:110;not null;uniqueIndex:idx_slug_redirects_resource_slug"`
+	TargetID  uint      `json:"target_id" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
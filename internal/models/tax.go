@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+// Product tax classes
+const (
+	TaxClassStandard = "standard"
+	TaxClassReduced  = "reduced"
+	TaxClassZero     = "zero"
+)
+
+// TaxRate is a tax rule for a product tax class in a country, or in one region of it.
+// Inclusive rates are already part of catalogue prices, exclusive ones are added on top.
+type TaxRate struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Country   string     `json:"country" gorm:"size:2;not null"`
+	Region    string     `json:"region" gorm:"size:100;not null;default:''"`
+	TaxClass  string     `json:"tax_class" gorm:"size:50;not null"`
+	Name      string     `json:"name" gorm:"not null"`
+	Rate      money.Rate `json:"rate" gorm:"type:decimal(8,6);not null"`
+	Inclusive bool       `json:"inclusive" gorm:"default:false"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
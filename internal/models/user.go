@@ -23,6 +23,7 @@ type User struct {
 	RefreshTokens []RefreshToken `json:"-"`
 	Orders        []Order        `json:"-"`
 	Cart          Cart           `json:"-"`
+	Addresses     []Address      `json:"-"`
 }
 
 type UserRole string
@@ -43,3 +44,54 @@ type RefreshToken struct {
 	// Relationships
 	User User `json:"-"`
 }
+
+type Address struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	UserID            uint           `json:"user_id" gorm:"not null"`
+	Label             string         `json:"label"`
+	FirstName         string         `json:"first_name" gorm:"not null"`
+	LastName          string         `json:"last_name" gorm:"not null"`
+	Phone             string         `json:"phone"`
+	Line1             string         `json:"line1" gorm:"not null"`
+	Line2             string         `json:"line2"`
+	City              string         `json:"city" gorm:"not null"`
+	State             string         `json:"state"`
+	PostalCode        string         `json:"postal_code" gorm:"not null"`
+	Country           string         `json:"country" gorm:"not null"`
+	IsDefaultShipping bool           `json:"is_default_shipping" gorm:"default:false"`
+	IsDefaultBilling  bool           `json:"is_default_billing" gorm:"default:false"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-"`
+}
+
+// Snapshot copies the postal fields of the address so they can be stored on an order
+func (a *Address) Snapshot() AddressSnapshot {
+	return AddressSnapshot{
+		FirstName:  a.FirstName,
+		LastName:   a.LastName,
+		Phone:      a.Phone,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		State:      a.State,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
+
+// AddressSnapshot is an immutable copy of an address embedded into orders
+type AddressSnapshot struct {
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Phone      string `json:"phone"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+)
+
+// ProductOption is a dimension a product varies in, such as size or color
+type ProductOption struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ProductID uint   `json:"product_id" gorm:"not null;uniqueIndex:idx_product_options_product_name"`
+	Name      string `json:"name" gorm:"not null;uniqueIndex:idx_product_options_product_name"`
+	Position  int    `json:"position" gorm:"not null;default:0"`
+
+	// Relationships
+	Values []ProductOptionValue `json:"values" gorm:"foreignKey:OptionID"`
+}
+
+// ProductOptionValue is one choice of an option, such as M or red
+type ProductOptionValue struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	OptionID uint   `json:"option_id" gorm:"not null;uniqueIndex:idx_product_option_values_option_value"`
+	Value    string `json:"value" gorm:"not null;uniqueIndex:idx_product_option_values_option_value"`
+	Position int    `json:"position" gorm:"not null;default:0"`
+}
+
+// ProductVariant is the unit that is stocked, put in carts and ordered. Every product has one
+// default variant; products without options have nothing else.
+type ProductVariant struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ProductID uint           `json:"product_id" gorm:"not null"`
+	SKU       string         `json:"sku" gorm:"uniqueIndex;not null"`
+	Price     *money.Money   `json:"price"` // overrides the product price when set
+	Stock     int            `json:"stock" gorm:"not null;default:0"`
+	IsDefault bool           `json:"is_default" gorm:"not null;default:false"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Product      Product              `json:"-"`
+	OptionValues []ProductOptionValue `json:"option_values" gorm:"many2many:variant_option_values"`
+}
+
+// UnitPrice is what one unit of the variant costs
+func (v *ProductVariant) UnitPrice(product *Product) money.Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// Name describes the variant by its option values in option order, such as "M / red". The
+// option values have to be loaded.
+func (v *ProductVariant) Name() string {
+	values := make([]ProductOptionValue, len(v.OptionValues))
+	copy(values, v.OptionValues)
+	sort.Slice(values, func(i, j int) bool { return values[i].OptionID < values[j].OptionID })
+
+	names := make([]string, len(values))
+	for i := range values {
+		names[i] = values[i].Value
+	}
+	return strings.Join(names, " / ")
+}
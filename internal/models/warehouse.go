@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Warehouse is a location stock is kept and shipped from. Warehouses with a higher priority
+// are preferred when allocating order items.
+type Warehouse struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Code      string         `json:"code" gorm:"uniqueIndex;not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	Country   string         `json:"country" gorm:"size:2"`
+	Region    string         `json:"region"`
+	Priority  int            `json:"priority" gorm:"not null;default:0"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// StockLevel is the stock of a product variant in one warehouse. ProductVariant.Stock and
+// Product.Stock cache the sums of the variant's and the product's stock levels.
+type StockLevel struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_stock_levels_warehouse_variant"`
+	ProductID   uint      `json:"product_id" gorm:"not null"`
+	VariantID   uint      `json:"variant_id" gorm:"not null;uniqueIndex:idx_stock_levels_warehouse_variant"`
+	Stock       int       `json:"stock" gorm:"not null;default:0"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Warehouse Warehouse      `json:"warehouse"`
+	Product   Product        `json:"-"`
+	Variant   ProductVariant `json:"-"`
+}
+
+// OrderItemAllocation is the part of an order item shipped from one warehouse
+type OrderItemAllocation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OrderItemID uint      `json:"order_item_id" gorm:"not null"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relationships
+	Warehouse Warehouse `json:"warehouse"`
+}
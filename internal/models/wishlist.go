@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Wishlist is a named list of products a user keeps for later. It can be shown to others
+// through a public link while it has a share token.
+type Wishlist struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	ShareToken *string        `json:"-" gorm:"size:36;uniqueIndex"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User  User           `json:"-"`
+	Items []WishlistItem `json:"items"`
+}
+
+type WishlistItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WishlistID uint      `json:"wishlist_id" gorm:"not null"`
+	ProductID  uint      `json:"product_id" gorm:"not null"`
+	VariantID  *uint     `json:"variant_id"` // the product's default variant when not set
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	Wishlist Wishlist        `json:"-"`
+	Product  Product         `json:"product"`
+	Variant  *ProductVariant `json:"variant"`
+}
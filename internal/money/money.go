@@ -0,0 +1,271 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// BaseCurrency is the currency prices are kept in when a value does not carry its own
+var BaseCurrency = "USD"
+
+// currencyExponents lists currencies whose minor unit is not a hundredth
+var currencyExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// Money is an exact amount in the minor units (e.g. cents) of a currency.
+//
+// It is stored in DECIMAL columns and encoded in JSON as a plain number such as 12.50,
+// so API responses keep the shape they had when amounts were float64.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New creates a Money value from an amount in minor units
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns an empty amount in the given currency
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit
+func Exponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Parse reads a decimal string such as "12.34" exactly. Digits beyond the currency's
+// minor unit are only accepted when they are zeros.
+func Parse(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, errors.New("money: empty amount")
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("money: invalid amount %q", value)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	exp := Exponent(currency)
+	if len(fraction) > exp {
+		if strings.Trim(fraction[exp:], "0") != "" {
+			return Money{}, fmt.Errorf("money: %q has more than %d decimal places", value, exp)
+		}
+		fraction = fraction[:exp]
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("money: invalid amount %q", value)
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("money: invalid amount %q: %w", value, err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns the sum of both amounts. An empty currency adopts the other one.
+func (m Money) Add(other Money) Money {
+	currency := m.sameCurrency(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+// Sub returns m minus other
+func (m Money) Sub(other Money) Money {
+	currency := m.sameCurrency(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}
+}
+
+// Mul multiplies the amount by a quantity
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Share returns part/whole of the amount, rounded half away from zero. Summing the shares of
+// consecutive parts can drift by a minor unit, so callers allocating in steps should take
+// Share(upTo, whole) minus Share(before, whole).
+func (m Money) Share(part, whole int64) Money {
+	if whole == 0 {
+		panic("money: share of a zero whole")
+	}
+	numerator := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(part))
+	value := new(big.Rat).SetFrac(numerator, big.NewInt(whole))
+	return Money{Amount: roundHalfAwayFromZero(value), Currency: m.Currency}
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1
+func (m Money) Cmp(other Money) int {
+	m.sameCurrency(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// String formats the amount as a decimal number without the currency, e.g. "12.50"
+func (m Money) String() string {
+	exp := Exponent(m.currency())
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Format renders the amount with its currency code, e.g. "12.50 USD"
+func (m Money) Format() string {
+	return m.String() + " " + m.currency()
+}
+
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return BaseCurrency
+	}
+	return m.Currency
+}
+
+// sameCurrency returns the currency shared by both values. Mixing currencies is a
+// programming error, so it panics instead of silently producing a wrong amount.
+func (m Money) sameCurrency(other Money) string {
+	switch {
+	case m.Currency == "":
+		return other.Currency
+	case other.Currency == "" || other.Currency == m.Currency:
+		return m.Currency
+	default:
+		panic(fmt.Sprintf("money: cannot combine %s and %s amounts", m.Currency, other.Currency))
+	}
+}
+
+// MarshalJSON encodes the amount as a JSON number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string in the base currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" {
+		return nil
+	}
+	parsed, err := Parse(value, m.currency())
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalParam lets gin bind query and form parameters into Money
+func (m *Money) UnmarshalParam(param string) error {
+	parsed, err := Parse(param, m.currency())
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as an exact decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a DECIMAL column. The column does not hold a currency, so the value
+// keeps its own currency or falls back to the base currency.
+func (m *Money) Scan(src interface{}) error {
+	currency := m.currency()
+
+	var parsed Money
+	var err error
+	switch v := src.(type) {
+	case nil:
+		parsed = Zero(currency)
+	case []byte:
+		parsed, err = Parse(string(v), currency)
+	case string:
+		parsed, err = Parse(v, currency)
+	case int64:
+		parsed, err = Parse(strconv.FormatInt(v, 10), currency)
+	case float64:
+		parsed, err = Parse(strconv.FormatFloat(v, 'f', Exponent(currency), 64), currency)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,106 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in       string
+		currency string
+		want     int64
+	}{
+		{"12.34", "USD", 1234},
+		{"12.3", "USD", 1230},
+		{"12", "USD", 1200},
+		{"0.01", "USD", 1},
+		{".5", "USD", 50},
+		{"-3.10", "USD", -310},
+		{"12.300", "USD", 1230},
+		{"500", "JPY", 500},
+		{"500.00", "JPY", 500},
+		{"1.234", "KWD", 1234},
+	}
+	for _, tc := range cases {
+		m, err := Parse(tc.in, tc.currency)
+		if assert.NoError(t, err, tc.in) {
+			assert.Equal(t, tc.want, m.Amount, tc.in)
+			assert.Equal(t, tc.currency, m.Currency, tc.in)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "12.345", "1e3", "."} {
+		_, err := Parse(in, "USD")
+		assert.Error(t, err, in)
+	}
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "12.34", New(1234, "USD").String())
+	assert.Equal(t, "0.05", New(5, "USD").String())
+	assert.Equal(t, "-0.05", New(-5, "USD").String())
+	assert.Equal(t, "500", New(500, "JPY").String())
+	assert.Equal(t, "1.234", New(1234, "KWD").String())
+	assert.Equal(t, "12.34 EUR", New(1234, "EUR").Format())
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 drifts with float64, minor units do not
+	total := Zero("USD")
+	for i := 0; i < 10; i++ {
+		total = total.Add(New(10, "USD")).Add(New(20, "USD"))
+	}
+	assert.Equal(t, "3.00", total.String())
+
+	assert.Equal(t, int64(2997), New(999, "USD").Mul(3).Amount)
+	assert.Equal(t, int64(500), New(1000, "USD").Sub(New(500, "USD")).Amount)
+	assert.Equal(t, "USD", Money{}.Add(New(1, "USD")).Currency)
+	assert.Equal(t, 1, New(2, "USD").Cmp(New(1, "USD")))
+
+	assert.Panics(t, func() { New(1, "USD").Add(New(1, "EUR")) })
+
+	// Allocating 1.00 over three parts in steps adds up exactly
+	tax := New(100, "USD")
+	first := tax.Share(1, 3)
+	rest := tax.Share(3, 3).Sub(tax.Share(1, 3))
+	assert.Equal(t, int64(33), first.Amount)
+	assert.Equal(t, int64(100), first.Add(rest).Amount)
+}
+
+func TestJSON(t *testing.T) {
+	payload := struct {
+		Price Money `json:"price"`
+	}{Price: New(1999, "USD")}
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price":19.99}`, string(data))
+
+	var decoded struct {
+		Price Money `json:"price"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"price":19.9}`), &decoded))
+	assert.Equal(t, int64(1990), decoded.Price.Amount)
+	assert.Equal(t, BaseCurrency, decoded.Price.Currency)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price":19.999}`), &decoded))
+}
+
+func TestScanValue(t *testing.T) {
+	var m Money
+	assert.NoError(t, m.Scan([]byte("42.10")))
+	assert.Equal(t, int64(4210), m.Amount)
+	assert.Equal(t, BaseCurrency, m.Currency)
+
+	v, err := m.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "42.10", v)
+
+	assert.NoError(t, m.Scan(float64(1.1)))
+	assert.Equal(t, int64(110), m.Amount)
+
+	assert.Error(t, m.Scan(true))
+}
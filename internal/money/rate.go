@@ -0,0 +1,199 @@
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// rateScale is the number of decimal places exchange rates are stored with
+const rateScale = 8
+
+// roundingIncrements lists currencies that are rounded to a multiple of their minor unit
+// rather than to the minor unit itself, e.g. Swiss francs to the nearest 0.05.
+var roundingIncrements = map[string]int64{
+	"CHF": 5,
+}
+
+// Rate is an exact exchange rate: how many units of a currency one unit of the base
+// currency buys. The zero value converts like a rate of 1.
+type Rate struct {
+	value *big.Rat
+}
+
+// ParseRate reads a decimal exchange rate such as "0.85"
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "/eE") {
+		return Rate{}, fmt.Errorf("money: invalid exchange rate %q", value)
+	}
+
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Rate{}, fmt.Errorf("money: invalid exchange rate %q", value)
+	}
+	return Rate{value: r}, nil
+}
+
+// MustParseRate is like ParseRate but panics on invalid input
+func MustParseRate(value string) Rate {
+	r, err := ParseRate(value)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r Rate) rat() *big.Rat {
+	if r.value == nil {
+		return big.NewRat(1, 1)
+	}
+	return r.value
+}
+
+// IsZero reports whether no rate has been set
+func (r Rate) IsZero() bool {
+	return r.value == nil || r.value.Sign() == 0
+}
+
+// IsPositive reports whether the rate is greater than zero
+func (r Rate) IsPositive() bool {
+	return r.rat().Sign() > 0
+}
+
+// Float64 returns an approximation of the rate, for validation and display only
+func (r Rate) Float64() float64 {
+	f, _ := r.rat().Float64()
+	return f
+}
+
+// String formats the rate as a decimal without trailing zeros
+func (r Rate) String() string {
+	s := r.rat().FloatString(rateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Add returns the sum of two rates, e.g. 1 + a tax rate
+func (r Rate) Add(other Rate) Rate {
+	return Rate{value: new(big.Rat).Add(r.rat(), other.rat())}
+}
+
+// Percent reads the rate as a percentage and returns it as a fraction, e.g. 15 becomes 0.15
+func (r Rate) Percent() Rate {
+	return Rate{value: new(big.Rat).Quo(r.rat(), big.NewRat(100, 1))}
+}
+
+// Multiply returns the amount scaled by the rate, rounded half away from zero to the minor unit
+func (m Money) Multiply(rate Rate) Money {
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate.rat())
+	return Money{Amount: roundHalfAwayFromZero(value), Currency: m.Currency}
+}
+
+// Divide returns the amount divided by the rate, rounded half away from zero to the minor unit
+func (m Money) Divide(rate Rate) Money {
+	if rate.rat().Sign() == 0 {
+		panic("money: division by a zero rate")
+	}
+	value := new(big.Rat).Quo(new(big.Rat).SetInt64(m.Amount), rate.rat())
+	return Money{Amount: roundHalfAwayFromZero(value), Currency: m.Currency}
+}
+
+// Convert converts the amount into another currency at the given rate, rounding half away
+// from zero to the target currency's minor unit and rounding increment.
+func (m Money) Convert(currency string, rate Rate) Money {
+	if currency == m.currency() && rate.rat().Cmp(big.NewRat(1, 1)) == 0 {
+		return Money{Amount: m.Amount, Currency: currency}
+	}
+
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate.rat())
+
+	// Shift from the source minor unit to the target one
+	shift := Exponent(currency) - Exponent(m.currency())
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	increment := int64(1)
+	if inc, ok := roundingIncrements[strings.ToUpper(currency)]; ok {
+		increment = inc
+	}
+	value.Quo(value, new(big.Rat).SetInt64(increment))
+
+	return Money{Amount: roundHalfAwayFromZero(value) * increment, Currency: currency}
+}
+
+func roundHalfAwayFromZero(value *big.Rat) int64 {
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// MarshalJSON encodes the rate as a JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" {
+		return nil
+	}
+	parsed, err := ParseRate(value)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores the rate as an exact decimal string
+func (r Rate) Value() (driver.Value, error) {
+	return r.rat().FloatString(rateScale), nil
+}
+
+// Scan reads a DECIMAL column
+func (r *Rate) Scan(src interface{}) error {
+	var parsed Rate
+	var err error
+	switch v := src.(type) {
+	case nil:
+		parsed = Rate{}
+	case []byte:
+		parsed, err = ParseRate(string(v))
+	case string:
+		parsed, err = ParseRate(v)
+	case int64:
+		parsed = Rate{value: new(big.Rat).SetInt64(v)}
+	case float64:
+		parsed = Rate{value: new(big.Rat).SetFloat64(v)}
+	default:
+		return fmt.Errorf("money: cannot scan %T into a rate", src)
+	}
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
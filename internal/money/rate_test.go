@@ -0,0 +1,76 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRate(t *testing.T) {
+	r, err := ParseRate("0.85")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.85", r.String())
+	assert.True(t, r.IsPositive())
+
+	for _, in := range []string{"", "abc", "1/3", "1e2"} {
+		_, err := ParseRate(in)
+		assert.Error(t, err, in)
+	}
+
+	assert.Equal(t, "1", Rate{}.String(), "the zero rate is the identity")
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		name     string
+		amount   Money
+		currency string
+		rate     string
+		want     int64
+	}{
+		{"two decimals", New(1999, "USD"), "EUR", "0.92", 1839},     // 18.3908
+		{"rounds half up", New(1000, "USD"), "GBP", "0.78525", 785}, // 7.8525
+		{"zero decimal target", New(1999, "USD"), "JPY", "151.37", 3026},
+		{"three decimal target", New(1999, "USD"), "KWD", "0.3071", 6139},
+		{"rounding increment", New(1999, "USD"), "CHF", "0.9012", 1800}, // 18.0149 -> 18.00
+		{"identity", New(1999, "USD"), "USD", "1", 1999},
+		{"negative", New(-1999, "USD"), "EUR", "0.92", -1839},
+	}
+	for _, tc := range cases {
+		got := tc.amount.Convert(tc.currency, MustParseRate(tc.rate))
+		assert.Equal(t, tc.want, got.Amount, tc.name)
+		assert.Equal(t, tc.currency, got.Currency, tc.name)
+	}
+}
+
+func TestRateJSONAndScan(t *testing.T) {
+	var payload struct {
+		Rate Rate `json:"rate"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"rate":"1.2345"}`), &payload))
+	assert.Equal(t, "1.2345", payload.Rate.String())
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rate":1.2345}`, string(data))
+
+	var r Rate
+	assert.NoError(t, r.Scan([]byte("0.92000000")))
+	assert.Equal(t, "0.92", r.String())
+
+	v, err := r.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "0.92000000", v)
+}
+
+func TestMultiplyDivide(t *testing.T) {
+	vat := MustParseRate("0.2")
+	assert.Equal(t, int64(200), New(1000, "GBP").Multiply(vat).Amount)
+	assert.Equal(t, int64(1000), New(1200, "GBP").Divide(MustParseRate("1").Add(vat)).Amount)
+
+	assert.Equal(t, int64(150), New(1000, "USD").Multiply(MustParseRate("15").Percent()).Amount)
+
+	// 7.25% of 9.99 is 0.724275
+	assert.Equal(t, int64(72), New(999, "USD").Multiply(MustParseRate("0.0725")).Amount)
+}
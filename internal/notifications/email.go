@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net"
 	"net/smtp"
+	"strconv"
+
+	"github.com/veetmoradiya3628/go-shop/internal/money"
 )
 
 type SMTPConfig struct {
@@ -31,7 +34,7 @@ func NewEmailNotifier(config *SMTPConfig) *EmailNotifier {
 }
 
 func (e *EmailNotifier) SendSimpleEmail(email *SimpleEmail) error {
-	addr := fmt.Sprintf("%s:%d", e.config.Host, e.config.Port)
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
 
 	// Connect directly without TLS for development
 	conn, err := net.Dial("tcp", addr)
@@ -98,3 +101,88 @@ The Shop Team`, userName),
 
 	return e.SendSimpleEmail(email)
 }
+
+func (e *EmailNotifier) SendOrderCancelledNotification(userEmail, userName string, orderID uint, reason string) error {
+	email := &SimpleEmail{
+		To:      userEmail,
+		Subject: fmt.Sprintf("Order #%d Cancelled", orderID),
+		Body: fmt.Sprintf(`Hello %s,
+
+Your order #%d has been cancelled (%s).
+
+If you have already paid for this order, the amount will be returned to you.
+
+Best regards,
+The Shop Team`, userName, orderID, reason),
+	}
+
+	return e.SendSimpleEmail(email)
+}
+
+func (e *EmailNotifier) SendOrderRefundedNotification(userEmail, userName string, orderID uint, amount money.Money) error {
+	email := &SimpleEmail{
+		To:      userEmail,
+		Subject: fmt.Sprintf("Refund for Order #%d", orderID),
+		Body: fmt.Sprintf(`Hello %s,
+
+We have refunded %s for your order #%d.
+
+Depending on your bank it may take a few days for the amount to show up on your statement.
+
+Best regards,
+The Shop Team`, userName, amount, orderID),
+	}
+
+	return e.SendSimpleEmail(email)
+}
+
+func (e *EmailNotifier) SendLowStockNotification(managerEmail, productName, sku string, stock, reorderThreshold int) error {
+	email := &SimpleEmail{
+		To:      managerEmail,
+		Subject: fmt.Sprintf("Low Stock: %s (%s)", productName, sku),
+		Body: fmt.Sprintf(`Hello,
+
+%s (SKU %s) is running low: %d left, below the reorder threshold of %d.
+
+Please reorder soon to avoid running out.
+
+Best regards,
+The Shop Team`, productName, sku, stock, reorderThreshold),
+	}
+
+	return e.SendSimpleEmail(email)
+}
+
+func (e *EmailNotifier) SendOutOfStockNotification(managerEmail, productName, sku string) error {
+	email := &SimpleEmail{
+		To:      managerEmail,
+		Subject: fmt.Sprintf("Out of Stock: %s (%s)", productName, sku),
+		Body: fmt.Sprintf(`Hello,
+
+%s (SKU %s) is out of stock and can no longer be ordered.
+
+Please restock it as soon as possible.
+
+Best regards,
+The Shop Team`, productName, sku),
+	}
+
+	return e.SendSimpleEmail(email)
+}
+
+func (e *EmailNotifier) SendBackInStockNotification(userEmail, userName, productName string) error {
+	email := &SimpleEmail{
+		To:      userEmail,
+		Subject: fmt.Sprintf("%s Is Back in Stock", productName),
+		Body: fmt.Sprintf(`Hello %s,
+
+Good news: %s is back in stock and can be ordered again.
+
+Stock is limited, so order soon if you do not want to miss out.
+
+Best regards,
+The Shop Team`, userName, productName),
+	}
+
+	return e.SendSimpleEmail(email)
+}
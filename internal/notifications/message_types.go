@@ -1,5 +1,50 @@
 package notifications
 
+import "github.com/veetmoradiya3628/go-shop/internal/money"
+
 const (
-	UserLoggedIn = "USER_LOGGED_IN"
+	UserLoggedIn   = "USER_LOGGED_IN"
+	OrderCancelled = "ORDER_CANCELLED"
+	OrderRefunded  = "ORDER_REFUNDED"
+
+	ProductLowStock    = "PRODUCT_LOW_STOCK"
+	ProductOutOfStock  = "PRODUCT_OUT_OF_STOCK"
+	ProductBackInStock = "PRODUCT_BACK_IN_STOCK"
 )
+
+// OrderEvent is the payload published for order lifecycle events
+type OrderEvent struct {
+	OrderID      uint        `json:"order_id"`
+	Status       string      `json:"status"`
+	TotalAmount  money.Money `json:"total_amount"`
+	Reason       string      `json:"reason"`
+	RefundAmount money.Money `json:"refund_amount,omitzero"`
+	Email        string      `json:"email"`
+	FirstName    string      `json:"first_name"`
+	LastName     string      `json:"last_name"`
+}
+
+// StockAlertEvent is the payload published when an order takes a product's stock below its
+// reorder threshold or to zero
+type StockAlertEvent struct {
+	ProductID        uint   `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	SKU              string `json:"sku"`
+	Stock            int    `json:"stock"`
+	ReorderThreshold int    `json:"reorder_threshold"`
+}
+
+// BackInStockEvent is the payload published when a product customers asked about can be
+// ordered again. Each subscriber gets one email.
+type BackInStockEvent struct {
+	ProductID   uint         `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Stock       int          `json:"stock"`
+	Subscribers []Subscriber `json:"subscribers"`
+}
+
+type Subscriber struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+// Payment methods understood by the fake gateway. Any other method is approved.
+const (
+	FakePaymentMethodDecline = "fake_decline"
+	FakePaymentMethodTimeout = "fake_timeout"
+)
+
+type fakeTransaction struct {
+	authorized money.Money
+	captured   money.Money
+	refunded   money.Money
+	voided     bool
+}
+
+// FakePaymentProvider is an in-process payment gateway with deterministic outcomes,
+// used for local development and tests.
+type FakePaymentProvider struct {
+	mu             sync.Mutex
+	nextID         int
+	transactions   map[string]*fakeTransaction
+	authorizations map[string]*interfaces.PaymentResult
+	refunds        map[string]*interfaces.PaymentResult
+}
+
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{
+		transactions:   make(map[string]*fakeTransaction),
+		authorizations: make(map[string]*interfaces.PaymentResult),
+		refunds:        make(map[string]*interfaces.PaymentResult),
+	}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakePaymentProvider) Authorize(req *interfaces.PaymentRequest) (*interfaces.PaymentResult, error) {
+	switch req.PaymentMethod {
+	case FakePaymentMethodDecline:
+		return nil, interfaces.ErrPaymentDeclined
+	case FakePaymentMethodTimeout:
+		return nil, interfaces.ErrPaymentTimeout
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.authorizations[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return result, nil
+	}
+
+	p.nextID++
+	transactionID := fmt.Sprintf("fake_txn_%d", p.nextID)
+	p.transactions[transactionID] = &fakeTransaction{authorized: req.Amount}
+
+	result := &interfaces.PaymentResult{TransactionID: transactionID, Amount: req.Amount}
+	if req.IdempotencyKey != "" {
+		p.authorizations[req.IdempotencyKey] = result
+	}
+	return result, nil
+}
+
+func (p *FakePaymentProvider) Capture(transactionID string, amount money.Money) (*interfaces.PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	txn, err := p.transaction(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if txn.voided {
+		return nil, errors.New("transaction has been voided")
+	}
+	if txn.captured.IsPositive() {
+		return nil, errors.New("transaction has already been captured")
+	}
+	if amount.Cmp(txn.authorized) > 0 {
+		return nil, errors.New("capture amount exceeds authorized amount")
+	}
+
+	txn.captured = amount
+	return &interfaces.PaymentResult{TransactionID: transactionID, Amount: amount}, nil
+}
+
+func (p *FakePaymentProvider) Void(transactionID string) (*interfaces.PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	txn, err := p.transaction(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if txn.captured.IsPositive() {
+		return nil, errors.New("captured transactions must be refunded instead of voided")
+	}
+
+	txn.voided = true
+	return &interfaces.PaymentResult{TransactionID: transactionID, Amount: txn.authorized}, nil
+}
+
+func (p *FakePaymentProvider) Refund(transactionID string, amount money.Money, idempotencyKey string) (*interfaces.PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.refunds[idempotencyKey]; ok {
+		return result, nil
+	}
+
+	txn, err := p.transaction(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if txn.refunded.Add(amount).Cmp(txn.captured) > 0 {
+		return nil, errors.New("refund amount exceeds captured amount")
+	}
+
+	txn.refunded = txn.refunded.Add(amount)
+	result := &interfaces.PaymentResult{TransactionID: transactionID, Amount: amount}
+	p.refunds[idempotencyKey] = result
+	return result, nil
+}
+
+func (p *FakePaymentProvider) transaction(transactionID string) (*fakeTransaction, error) {
+	txn, ok := p.transactions[transactionID]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction: %s", transactionID)
+	}
+	return txn, nil
+}
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func TestFakePaymentProviderSuccessFlow(t *testing.T) {
+	p := NewFakePaymentProvider()
+
+	auth, err := p.Authorize(&interfaces.PaymentRequest{OrderID: 1, Amount: money.New(100_00, "USD"), PaymentMethod: "card", IdempotencyKey: "payment-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "fake_txn_1", auth.TransactionID)
+
+	retried, err := p.Authorize(&interfaces.PaymentRequest{OrderID: 1, Amount: money.New(100_00, "USD"), PaymentMethod: "card", IdempotencyKey: "payment-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, auth.TransactionID, retried.TransactionID, "a retried authorization is answered without authorizing twice")
+
+	_, err = p.Capture(auth.TransactionID, money.New(100_00, "USD"))
+	assert.NoError(t, err)
+
+	_, err = p.Refund(auth.TransactionID, money.New(60_00, "USD"), "refund-1")
+	assert.NoError(t, err)
+	_, err = p.Refund(auth.TransactionID, money.New(60_00, "USD"), "refund-1")
+	assert.NoError(t, err, "a retried refund is answered without refunding twice")
+	_, err = p.Refund(auth.TransactionID, money.New(60_00, "USD"), "refund-2")
+	assert.Error(t, err, "refunds must not exceed the captured amount")
+
+	_, err = p.Void(auth.TransactionID)
+	assert.Error(t, err, "captured transactions cannot be voided")
+}
+
+func TestFakePaymentProviderDeclineAndTimeout(t *testing.T) {
+	p := NewFakePaymentProvider()
+
+	_, err := p.Authorize(&interfaces.PaymentRequest{OrderID: 1, Amount: money.New(100_00, "USD"), PaymentMethod: FakePaymentMethodDecline})
+	assert.ErrorIs(t, err, interfaces.ErrPaymentDeclined)
+
+	_, err = p.Authorize(&interfaces.PaymentRequest{OrderID: 1, Amount: money.New(100_00, "USD"), PaymentMethod: FakePaymentMethodTimeout})
+	assert.ErrorIs(t, err, interfaces.ErrPaymentTimeout)
+}
+
+func TestFakePaymentProviderVoid(t *testing.T) {
+	p := NewFakePaymentProvider()
+
+	auth, err := p.Authorize(&interfaces.PaymentRequest{OrderID: 1, Amount: money.New(50_00, "USD"), PaymentMethod: "card"})
+	assert.NoError(t, err)
+
+	_, err = p.Void(auth.TransactionID)
+	assert.NoError(t, err)
+
+	_, err = p.Capture(auth.TransactionID, money.New(50_00, "USD"))
+	assert.Error(t, err, "voided transactions cannot be captured")
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+type taxRuleKey struct {
+	country  string
+	region   string
+	taxClass string
+}
+
+// TableTaxCalculator looks up tax rates by destination and product tax class.
+// A rule for the destination's region takes precedence over the country-wide rule;
+// items without a matching rule are not taxed.
+type TableTaxCalculator struct {
+	rules map[taxRuleKey]models.TaxRate
+}
+
+func NewTableTaxCalculator(rates []models.TaxRate) *TableTaxCalculator {
+	rules := make(map[taxRuleKey]models.TaxRate, len(rates))
+	for _, rate := range rates {
+		rules[newTaxRuleKey(rate.Country, rate.Region, rate.TaxClass)] = rate
+	}
+	return &TableTaxCalculator{rules: rules}
+}
+
+func newTaxRuleKey(country, region, taxClass string) taxRuleKey {
+	return taxRuleKey{
+		country:  strings.ToUpper(strings.TrimSpace(country)),
+		region:   strings.ToUpper(strings.TrimSpace(region)),
+		taxClass: strings.ToLower(strings.TrimSpace(taxClass)),
+	}
+}
+
+func (t *TableTaxCalculator) rule(address interfaces.TaxAddress, taxClass string) (models.TaxRate, bool) {
+	if taxClass == "" {
+		taxClass = models.TaxClassStandard
+	}
+	if address.Region != "" {
+		if rule, ok := t.rules[newTaxRuleKey(address.Country, address.Region, taxClass)]; ok {
+			return rule, true
+		}
+	}
+	rule, ok := t.rules[newTaxRuleKey(address.Country, "", taxClass)]
+	return rule, ok
+}
+
+func (t *TableTaxCalculator) Calculate(req *interfaces.TaxRequest) (*interfaces.TaxResult, error) {
+	result := &interfaces.TaxResult{
+		Items: make([]interfaces.TaxedItem, len(req.Items)),
+	}
+
+	lineIndex := make(map[taxRuleKey]int)
+	for i, item := range req.Items {
+		result.Subtotal = result.Subtotal.Add(item.Amount)
+
+		rule, ok := t.rule(req.Address, item.TaxClass)
+		if !ok || !rule.Rate.IsPositive() {
+			continue
+		}
+
+		// Inclusive prices already contain the tax: net = gross / (1 + rate)
+		var tax, taxable money.Money
+		if rule.Inclusive {
+			taxable = item.Amount.Divide(money.MustParseRate("1").Add(rule.Rate))
+			tax = item.Amount.Sub(taxable)
+		} else {
+			taxable = item.Amount
+			tax = item.Amount.Multiply(rule.Rate)
+		}
+		result.Items[i] = interfaces.TaxedItem{Tax: tax, Inclusive: rule.Inclusive}
+
+		key := newTaxRuleKey(rule.Country, rule.Region, rule.TaxClass)
+		idx, ok := lineIndex[key]
+		if !ok {
+			idx = len(result.Lines)
+			lineIndex[key] = idx
+			result.Lines = append(result.Lines, interfaces.TaxLine{
+				Name:      rule.Name,
+				Country:   rule.Country,
+				Region:    rule.Region,
+				TaxClass:  rule.TaxClass,
+				Rate:      rule.Rate,
+				Inclusive: rule.Inclusive,
+			})
+		}
+		result.Lines[idx].TaxableAmount = result.Lines[idx].TaxableAmount.Add(taxable)
+		result.Lines[idx].Amount = result.Lines[idx].Amount.Add(tax)
+	}
+
+	result.Total = result.Subtotal
+	for _, line := range result.Lines {
+		result.Tax = result.Tax.Add(line.Amount)
+		if !line.Inclusive {
+			result.Total = result.Total.Add(line.Amount)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func newTestTaxCalculator() *TableTaxCalculator {
+	return NewTableTaxCalculator([]models.TaxRate{
+		{Country: "GB", TaxClass: models.TaxClassStandard, Name: "VAT", Rate: money.MustParseRate("0.20"), Inclusive: true},
+		{Country: "GB", TaxClass: models.TaxClassReduced, Name: "VAT (reduced)", Rate: money.MustParseRate("0.05"), Inclusive: true},
+		{Country: "US", Region: "CA", TaxClass: models.TaxClassStandard, Name: "CA sales tax", Rate: money.MustParseRate("0.0725")},
+		{Country: "US", TaxClass: models.TaxClassStandard, Name: "Sales tax", Rate: money.MustParseRate("0.05")},
+	})
+}
+
+func TestTableTaxCalculatorExclusive(t *testing.T) {
+	result, err := newTestTaxCalculator().Calculate(&interfaces.TaxRequest{
+		Address: interfaces.TaxAddress{Country: "us", Region: "ca"},
+		Items: []interfaces.TaxableItem{
+			{TaxClass: models.TaxClassStandard, Amount: money.New(1000, "USD")},
+			{TaxClass: models.TaxClassStandard, Amount: money.New(2000, "USD")},
+			{TaxClass: models.TaxClassZero, Amount: money.New(500, "USD")},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(3500), result.Subtotal.Amount)
+	assert.Len(t, result.Lines, 1, "the region rule wins over the country rule")
+	assert.Equal(t, "CA sales tax", result.Lines[0].Name)
+	assert.Equal(t, int64(3000), result.Lines[0].TaxableAmount.Amount)
+	assert.Equal(t, int64(73+145), result.Lines[0].Amount.Amount)
+	assert.Equal(t, int64(218), result.Tax.Amount)
+	assert.Equal(t, int64(3718), result.Total.Amount)
+	assert.True(t, result.Items[2].Tax.IsZero(), "items without a rule are not taxed")
+}
+
+func TestTableTaxCalculatorInclusive(t *testing.T) {
+	result, err := newTestTaxCalculator().Calculate(&interfaces.TaxRequest{
+		Address: interfaces.TaxAddress{Country: "GB"},
+		Items: []interfaces.TaxableItem{
+			{TaxClass: models.TaxClassStandard, Amount: money.New(1200, "GBP")},
+			{TaxClass: models.TaxClassReduced, Amount: money.New(1050, "GBP")},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, result.Lines, 2)
+	assert.Equal(t, int64(200), result.Lines[0].Amount.Amount)
+	assert.Equal(t, int64(1000), result.Lines[0].TaxableAmount.Amount)
+	assert.Equal(t, int64(50), result.Lines[1].Amount.Amount)
+	assert.True(t, result.Items[0].Inclusive)
+
+	assert.Equal(t, int64(250), result.Tax.Amount)
+	assert.Equal(t, result.Subtotal, result.Total, "included tax does not change the total")
+}
+
+func TestTableTaxCalculatorFallsBackToCountry(t *testing.T) {
+	result, err := newTestTaxCalculator().Calculate(&interfaces.TaxRequest{
+		Address: interfaces.TaxAddress{Country: "US", Region: "NY"},
+		Items:   []interfaces.TaxableItem{{Amount: money.New(1000, "USD")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Sales tax", result.Lines[0].Name)
+	assert.Equal(t, int64(1050), result.Total.Amount)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary Get user's addresses
+// @Description Retrieve the address book of the current user
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]dto.AddressResponse} "Addresses retrieved successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /users/addresses [get]
+func (s *Server) getAddresses(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	addresses, err := s.userService.GetAddresses(userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch addresses", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Addresses retrieved successfully", addresses)
+}
+
+// @Summary Add an address
+// @Description Add an address to the current user's address book
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.AddressRequest true "Address data"
+// @Success 201 {object} utils.Response{data=dto.AddressResponse} "Address created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Router /users/addresses [post]
+func (s *Server) createAddress(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req dto.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	address, err := s.userService.CreateAddress(userID, &req)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to create address", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Address created successfully", address)
+}
+
+// @Summary Update an address
+// @Description Update an address in the current user's address book. Orders keep the address they were placed with
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Param request body dto.AddressRequest true "Address data"
+// @Success 200 {object} utils.Response{data=dto.AddressResponse} "Address updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Address not found"
+// @Router /users/addresses/{id} [put]
+func (s *Server) updateAddress(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid address ID", err)
+		return
+	}
+
+	var req dto.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	address, err := s.userService.UpdateAddress(userID, uint(id), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Address not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to update address", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Address updated successfully", address)
+}
+
+// @Summary Delete an address
+// @Description Remove an address from the current user's address book
+// @Tags User
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Success 200 {object} utils.Response "Address deleted successfully"
+// @Failure 400 {object} utils.Response "Invalid address ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Address not found"
+// @Router /users/addresses/{id} [delete]
+func (s *Server) deleteAddress(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid address ID", err)
+		return
+	}
+
+	if err := s.userService.DeleteAddress(userID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Address not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to delete address", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Address deleted successfully", nil)
+}
@@ -12,6 +12,7 @@ import (
 // @Accept json
 // @Produce json
 // @Param request body dto.RegisterRequest true "User registration data"
+// @Param X-Cart-Token header string false "Cart token of a guest cart to keep, also accepted as the cart_token cookie"
 // @Success 201 {object} utils.Response{data=dto.AuthResponse} "User registered successfully"
 // @Failure 400 {object} utils.Response "Invalid request data or user already exists"
 // @Router /auth/register [post]
@@ -22,11 +23,14 @@ func (s *Server) register(c *gin.Context) {
 		return
 	}
 
-	response, err := s.authService.Register(&req)
+	response, cartMerged, err := s.authService.Register(&req, cartToken(c))
 	if err != nil {
 		utils.BadRequestResponse(c, "Failed to register user", err)
 		return
 	}
+	if cartMerged {
+		clearCartTokenCookie(c)
+	}
 	utils.CreatedResponse(c, "User registered successfully", response)
 }
 
@@ -36,6 +40,7 @@ func (s *Server) register(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body dto.LoginRequest true "User login credentials"
+// @Param X-Cart-Token header string false "Cart token of a guest cart to merge into the user's cart, also accepted as the cart_token cookie"
 // @Success 200 {object} utils.Response{data=dto.AuthResponse} "Login successful"
 // @Failure 401 {object} utils.Response "Invalid credentials"
 // @Router /auth/login [post]
@@ -45,11 +50,14 @@ func (s *Server) login(c *gin.Context) {
 		utils.BadRequestResponse(c, "Invalid request data", err)
 		return
 	}
-	response, err := s.authService.Login(&req)
+	response, cartMerged, err := s.authService.Login(&req, cartToken(c))
 	if err != nil {
 		utils.UnauthorizedResponse(c, "Invalid email or password")
 		return
 	}
+	if cartMerged {
+		clearCartTokenCookie(c)
+	}
 	utils.SuccessResponse(c, "Login successful", response)
 }
 
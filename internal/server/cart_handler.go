@@ -1,27 +1,72 @@
 package server
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
 	"github.com/veetmoradiya3628/go-shop/internal/utils"
 )
 
+const (
+	cartTokenHeader = "X-Cart-Token"
+	cartTokenCookie = "cart_token"
+)
+
+// cartOwner identifies the cart of the request: the signed in user's, otherwise the guest
+// cart with the token in the X-Cart-Token header or the cart_token cookie
+func cartOwner(c *gin.Context) services.CartOwner {
+	return services.CartOwner{UserID: c.GetUint("user_id"), Token: cartToken(c)}
+}
+
+func cartToken(c *gin.Context) string {
+	if token := strings.TrimSpace(c.GetHeader(cartTokenHeader)); token != "" {
+		return token
+	}
+	token, _ := c.Cookie(cartTokenCookie)
+	return token
+}
+
+// setCartTokenCookie keeps the guest cart's cookie for as long as the cart is kept. Every
+// change to the cart renews both.
+func (s *Server) setCartTokenCookie(c *gin.Context, token string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cartTokenCookie, token, int(s.config.Cart.GuestCartTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+}
+
+// clearCartTokenCookie drops the cookie of a guest cart once it was merged into a user's cart.
+// A cart that failed to merge keeps its cookie, so its items can still be reached.
+func clearCartTokenCookie(c *gin.Context) {
+	if _, err := c.Cookie(cartTokenCookie); err == nil {
+		c.SetCookie(cartTokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	}
+}
+
 // @Summary Get user's cart
 // @Description Retrieve current user's shopping cart with all items
 // @Tags Cart
 // @Produce json
 // @Security BearerAuth
+// @Param X-Cart-Token header string false "Cart token of a guest cart, also accepted as the cart_token cookie"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
 // @Success 200 {object} utils.Response{data=dto.CartResponse} "Cart retrieved successfully"
-// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 400 {object} utils.Response "Unsupported currency"
+// @Failure 401 {object} utils.Response "Invalid access token"
 // @Failure 404 {object} utils.Response "Cart not found"
 // @Router /cart [get]
 func (s *Server) getCart(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	owner := cartOwner(c)
 
-	cart, err := s.cartService.GetCart(userID)
+	cart, err := s.cartService.GetCart(owner, requestCurrency(c))
 	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.BadRequestResponse(c, "Unsupported currency", err)
+			return
+		}
 		utils.NotFoundResponse(c, "Cart not found")
 		return
 	}
@@ -30,19 +75,21 @@ func (s *Server) getCart(c *gin.Context) {
 }
 
 // @Summary Add item to cart
-// @Description Add a product to the user's shopping cart
+// @Description Add a product to the cart of the user, or of a guest without an access token. A guest without a cart gets a new one, its token is returned and set as the cart_token cookie
 // @Tags Cart
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Cart-Token header string false "Cart token of a guest cart, also accepted as the cart_token cookie"
 // @Param request body dto.AddToCartRequest true "Item to add to cart"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
 // @Success 200 {object} utils.Response{data=dto.CartResponse} "Item added to cart successfully"
 // @Failure 400 {object} utils.Response "Invalid request data or insufficient stock"
-// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 401 {object} utils.Response "Invalid access token"
 // @Router /cart/items [post]
 func (s *Server) addToCart(c *gin.Context) {
 
-	userID := c.GetUint("user_id")
+	owner := cartOwner(c)
 
 	var req dto.AddToCartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -50,11 +97,14 @@ func (s *Server) addToCart(c *gin.Context) {
 		return
 	}
 
-	cart, err := s.cartService.AddToCart(userID, &req)
+	cart, err := s.cartService.AddToCart(owner, &req, requestCurrency(c))
 	if err != nil {
 		utils.BadRequestResponse(c, "Failed to add item to cart", err)
 		return
 	}
+	if cart.Token != "" {
+		s.setCartTokenCookie(c, cart.Token)
+	}
 
 	utils.SuccessResponse(c, "Item added to cart successfully", cart)
 }
@@ -65,14 +115,16 @@ func (s *Server) addToCart(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Cart-Token header string false "Cart token of a guest cart, also accepted as the cart_token cookie"
 // @Param id path int true "Cart Item ID"
 // @Param request body dto.UpdateCartItemRequest true "New quantity"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
 // @Success 200 {object} utils.Response{data=dto.CartResponse} "Cart item updated successfully"
 // @Failure 400 {object} utils.Response "Invalid request data or insufficient stock"
-// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 401 {object} utils.Response "Invalid access token"
 // @Router /cart/items/{id} [put]
 func (s *Server) updateCartItem(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	owner := cartOwner(c)
 
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -86,11 +138,14 @@ func (s *Server) updateCartItem(c *gin.Context) {
 		return
 	}
 
-	cart, err := s.cartService.UpdateCartItem(userID, uint(id), &req)
+	cart, err := s.cartService.UpdateCartItem(owner, uint(id), &req, requestCurrency(c))
 	if err != nil {
 		utils.BadRequestResponse(c, "Failed to update cart item", err)
 		return
 	}
+	if cart.Token != "" {
+		s.setCartTokenCookie(c, cart.Token)
+	}
 
 	utils.SuccessResponse(c, "Cart item updated successfully", cart)
 }
@@ -99,13 +154,14 @@ func (s *Server) updateCartItem(c *gin.Context) {
 // @Description Remove an item from the user's shopping cart
 // @Tags Cart
 // @Security BearerAuth
+// @Param X-Cart-Token header string false "Cart token of a guest cart, also accepted as the cart_token cookie"
 // @Param id path int true "Cart Item ID"
 // @Success 200 {object} utils.Response "Item removed from cart successfully"
 // @Failure 400 {object} utils.Response "Invalid cart item ID"
-// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 401 {object} utils.Response "Invalid access token"
 // @Router /cart/items/{id} [delete]
 func (s *Server) removeFromCart(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	owner := cartOwner(c)
 
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -113,10 +169,71 @@ func (s *Server) removeFromCart(c *gin.Context) {
 		return
 	}
 
-	if err := s.cartService.RemoveFromCart(userID, uint(id)); err != nil {
+	if err := s.cartService.RemoveFromCart(owner, uint(id)); err != nil {
 		utils.InternalServerErrorResponse(c, "Failed to remove item from cart", err)
 		return
 	}
+	if owner.UserID == 0 && owner.Token != "" {
+		s.setCartTokenCookie(c, owner.Token)
+	}
 
 	utils.SuccessResponse(c, "Item removed from cart successfully", nil)
 }
+
+// @Summary Apply a coupon to the cart
+// @Description Apply a discount code to the user's cart. The code is validated again at checkout
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Cart token of a guest cart, also accepted as the cart_token cookie"
+// @Param request body dto.ApplyCouponRequest true "Coupon code"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.CartResponse} "Coupon applied successfully"
+// @Failure 400 {object} utils.Response "Invalid request data or coupon cannot be applied"
+// @Failure 401 {object} utils.Response "Invalid access token"
+// @Router /cart/coupon [post]
+func (s *Server) applyCoupon(c *gin.Context) {
+	owner := cartOwner(c)
+
+	var req dto.ApplyCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	cart, err := s.cartService.ApplyCoupon(owner, &req, requestCurrency(c))
+	if err != nil {
+		utils.BadRequestResponse(c, "Failed to apply coupon", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Coupon applied successfully", cart)
+}
+
+// @Summary Remove the coupon from the cart
+// @Description Remove the discount code from the user's cart
+// @Tags Cart
+// @Produce json
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Cart token of a guest cart, also accepted as the cart_token cookie"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.CartResponse} "Coupon removed successfully"
+// @Failure 400 {object} utils.Response "Unsupported currency"
+// @Failure 401 {object} utils.Response "Invalid access token"
+// @Router /cart/coupon [delete]
+func (s *Server) removeCoupon(c *gin.Context) {
+	owner := cartOwner(c)
+
+	cart, err := s.cartService.RemoveCoupon(owner, requestCurrency(c))
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.BadRequestResponse(c, "Unsupported currency", err)
+			return
+		}
+		utils.NotFoundResponse(c, "Cart not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Coupon removed successfully", cart)
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary List coupons
+// @Description List all coupons with their usage (Admin only)
+// @Tags Coupons
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]dto.CouponResponse} "Coupons retrieved successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Router /admin/coupons [get]
+func (s *Server) getCoupons(c *gin.Context) {
+	coupons, err := s.couponService.GetCoupons()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch coupons", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Coupons retrieved successfully", coupons)
+}
+
+// @Summary Create a coupon
+// @Description Create a discount code (Admin only)
+// @Tags Coupons
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CouponRequest true "Coupon data"
+// @Success 201 {object} utils.Response{data=dto.CouponResponse} "Coupon created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 409 {object} utils.Response "Coupon code already in use"
+// @Router /admin/coupons [post]
+func (s *Server) createCoupon(c *gin.Context) {
+	var req dto.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	coupon, err := s.couponService.CreateCoupon(&req)
+	if err != nil {
+		s.couponErrorResponse(c, "Failed to create coupon", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Coupon created successfully", coupon)
+}
+
+// @Summary Update a coupon
+// @Description Replace the settings of a coupon (Admin only)
+// @Tags Coupons
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Coupon ID"
+// @Param request body dto.CouponRequest true "Coupon data"
+// @Success 200 {object} utils.Response{data=dto.CouponResponse} "Coupon updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Coupon not found"
+// @Failure 409 {object} utils.Response "Coupon code already in use"
+// @Router /admin/coupons/{id} [put]
+func (s *Server) updateCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid coupon ID", err)
+		return
+	}
+
+	var req dto.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	coupon, err := s.couponService.UpdateCoupon(uint(id), &req)
+	if err != nil {
+		s.couponErrorResponse(c, "Failed to update coupon", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Coupon updated successfully", coupon)
+}
+
+// @Summary Delete a coupon
+// @Description Delete a coupon. Carts holding the code lose it, past orders keep their discount (Admin only)
+// @Tags Coupons
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Coupon ID"
+// @Success 200 {object} utils.Response "Coupon deleted successfully"
+// @Failure 400 {object} utils.Response "Invalid coupon ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Coupon not found"
+// @Router /admin/coupons/{id} [delete]
+func (s *Server) deleteCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid coupon ID", err)
+		return
+	}
+
+	if err := s.couponService.DeleteCoupon(uint(id)); err != nil {
+		s.couponErrorResponse(c, "Failed to delete coupon", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Coupon deleted successfully", nil)
+}
+
+func (s *Server) couponErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Coupon not found")
+	case errors.Is(err, services.ErrInvalidCouponRequest):
+		utils.BadRequestResponse(c, message, err)
+	case errors.Is(err, services.ErrCouponCodeTaken):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+const acceptCurrencyHeader = "Accept-Currency"
+
+// requestCurrency returns the display currency asked for by the client, either through the
+// currency query parameter or the Accept-Currency header. An empty result means the base currency.
+func requestCurrency(c *gin.Context) string {
+	c.Header("Vary", acceptCurrencyHeader)
+
+	if currency := c.Query("currency"); currency != "" {
+		return strings.ToUpper(currency)
+	}
+	return strings.ToUpper(strings.TrimSpace(c.GetHeader(acceptCurrencyHeader)))
+}
+
+// @Summary List exchange rates
+// @Description List the exchange rates used to display prices in other currencies (Admin only)
+// @Tags Currencies
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]dto.ExchangeRateResponse} "Exchange rates retrieved successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Router /admin/exchange-rates [get]
+func (s *Server) getExchangeRates(c *gin.Context) {
+	rates, err := s.currencyService.GetExchangeRates()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch exchange rates", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Exchange rates retrieved successfully", rates)
+}
+
+// @Summary Set an exchange rate
+// @Description Create or replace the rate from the base currency to another currency (Admin only)
+// @Tags Currencies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SetExchangeRateRequest true "Currency and rate"
+// @Success 200 {object} utils.Response{data=dto.ExchangeRateResponse} "Exchange rate saved successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Router /admin/exchange-rates [put]
+func (s *Server) setExchangeRate(c *gin.Context) {
+	var req dto.SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	rate, err := s.currencyService.SetExchangeRate(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrBaseCurrencyRate) {
+			utils.BadRequestResponse(c, "Invalid request data", err)
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to save exchange rate", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Exchange rate saved successfully", rate)
+}
+
+// @Summary Delete an exchange rate
+// @Description Stop offering prices in a currency (Admin only)
+// @Tags Currencies
+// @Produce json
+// @Security BearerAuth
+// @Param currency path string true "ISO 4217 currency code"
+// @Success 200 {object} utils.Response "Exchange rate deleted successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Exchange rate not found"
+// @Router /admin/exchange-rates/{currency} [delete]
+func (s *Server) deleteExchangeRate(c *gin.Context) {
+	if err := s.currencyService.DeleteExchangeRate(c.Param("currency")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Exchange rate not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to delete exchange rate", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Exchange rate deleted successfully", nil)
+}
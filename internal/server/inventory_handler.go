@@ -0,0 +1,185 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary Get a product's inventory history
+// @Description List the stock movements of a product, most recent first (Admin only)
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.PaginatedResponse{data=[]dto.InventoryMovementResponse} "Inventory history retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid product ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Router /admin/products/{id}/inventory-history [get]
+func (s *Server) getInventoryHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	movements, meta, err := s.inventoryService.GetInventoryHistory(uint(id), page, limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Product not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to fetch inventory history", err)
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, "Inventory history retrieved successfully", movements, *meta)
+}
+
+// @Summary Reconcile inventory
+// @Description List the products whose stock differs from the sum of their inventory ledger or of their warehouse stock levels. An empty list means the ledger explains every stock level (Admin only)
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]dto.StockDiscrepancyResponse} "Inventory reconciled successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Router /admin/inventory/reconciliation [get]
+func (s *Server) reconcileInventory(c *gin.Context) {
+	discrepancies, err := s.inventoryService.Reconcile()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to reconcile inventory", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Inventory reconciled successfully", discrepancies)
+}
+
+// @Summary Get a product's stock levels
+// @Description List the stock of a product in each warehouse (Admin only)
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} utils.Response{data=[]dto.StockLevelResponse} "Stock levels retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid product ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Router /admin/products/{id}/stock-levels [get]
+func (s *Server) getStockLevels(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+
+	levels, err := s.inventoryService.GetStockLevels(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Product not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to fetch stock levels", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Stock levels retrieved successfully", levels)
+}
+
+// @Summary Set a product's stock in a warehouse
+// @Description Correct the stock of a product variant, by default the product's default variant, in one warehouse. The difference is booked as a manual adjustment (Admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param warehouse_id path int true "Warehouse ID"
+// @Param request body dto.SetStockLevelRequest true "Stock level"
+// @Success 200 {object} utils.Response{data=[]dto.StockLevelResponse} "Stock level updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product, variant or warehouse not found"
+// @Router /admin/products/{id}/stock-levels/{warehouse_id} [put]
+func (s *Server) setStockLevel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+	warehouseID, err := strconv.ParseUint(c.Param("warehouse_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid warehouse ID", err)
+		return
+	}
+
+	var req dto.SetStockLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	levels, err := s.inventoryService.SetStockLevel(uint(id), uint(warehouseID), &req, c.GetUint("user_id"))
+	if err != nil {
+		s.stockErrorResponse(c, "Failed to update stock level", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Stock level updated successfully", levels)
+}
+
+// @Summary Transfer stock between warehouses
+// @Description Move stock of a product variant, by default the product's default variant, from one warehouse to another. Both sides are recorded in the inventory ledger (Admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.StockTransferRequest true "Transfer"
+// @Success 200 {object} utils.Response{data=[]dto.StockLevelResponse} "Stock transferred successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product, variant or warehouse not found"
+// @Failure 409 {object} utils.Response "Not enough stock in the source warehouse"
+// @Router /admin/inventory/transfers [post]
+func (s *Server) transferStock(c *gin.Context) {
+	var req dto.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	levels, err := s.inventoryService.TransferStock(&req, c.GetUint("user_id"))
+	if err != nil {
+		s.stockErrorResponse(c, "Failed to transfer stock", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Stock transferred successfully", levels)
+}
+
+func (s *Server) stockErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Product, variant or warehouse not found")
+	case errors.Is(err, services.ErrInvalidStockAdjustment):
+		utils.BadRequestResponse(c, message, err)
+	case errors.Is(err, services.ErrInsufficientStock):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
@@ -35,6 +35,19 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// optionalAuthMiddleware authenticates the requests that come with an access token and lets
+// the others through as guests
+func (s *Server) optionalAuthMiddleware() gin.HandlerFunc {
+	authenticate := s.authMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
 func (s *Server) adminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("user_role")
@@ -81,3 +81,37 @@ func TestAdminMiddleware(t *testing.T) {
 	w = performRequest(routerAdmin, "GET", "/admin", nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{Secret: "testsecret", ExpiresIn: time.Hour, RefreshTokenExpires: time.Hour},
+	}
+	s := &Server{config: cfg}
+	router := gin.New()
+	router.Use(s.optionalAuthMiddleware())
+	router.GET("/cart", func(c *gin.Context) {
+		owner := cartOwner(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": owner.UserID, "token": owner.Token})
+	})
+
+	// Guests are let through with their cart token
+	w := performRequest(router, "GET", "/cart", map[string]string{cartTokenHeader: "guest-token"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id": 0, "token": "guest-token"}`, w.Body.String())
+
+	req, _ := http.NewRequest(http.MethodGet, "/cart", nil)
+	req.AddCookie(&http.Cookie{Name: cartTokenCookie, Value: "cookie-token"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"user_id": 0, "token": "cookie-token"}`, w.Body.String())
+
+	// A token that is sent has to be valid
+	w = performRequest(router, "GET", "/cart", map[string]string{"Authorization": "Bearer invalid"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token, _, err := utils.GenerateTokenPair(&cfg.JWT, 7, "user@example.com", string(models.UserRoleCustomer))
+	assert.NoError(t, err)
+	w = performRequest(router, "GET", "/cart", map[string]string{"Authorization": "Bearer " + token})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id": 7, "token": ""}`, w.Body.String())
+}
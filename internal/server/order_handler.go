@@ -1,26 +1,40 @@
 package server
 
 import (
+	"errors"
+	"io"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
 	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
 )
 
 // @Summary Create an order
-// @Description Create an order from the current user's cart
+// @Description Create an order from the current user's cart. Without address IDs the user's default shipping and billing addresses are used. The order keeps the display currency and exchange rate in effect at checkout
 // @Tags Orders
+// @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param request body dto.CreateOrderRequest false "Shipping and billing address"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
 // @Success 201 {object} utils.Response{data=dto.OrderResponse} "Order created successfully"
-// @Failure 400 {object} utils.Response "Cart is empty or insufficient stock"
+// @Failure 400 {object} utils.Response "Cart is empty, insufficient stock or unsupported currency"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Router /orders [post]
 func (s *Server) createOrder(c *gin.Context) {
 	userID := c.GetUint("user_id")
 
-	order, err := s.orderService.CreateOrder(userID)
+	var req dto.CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	order, err := s.orderService.CreateOrder(userID, &req, requestCurrency(c))
 	if err != nil {
 		utils.BadRequestResponse(c, "Failed to create order", err)
 		return
@@ -29,6 +43,32 @@ func (s *Server) createOrder(c *gin.Context) {
 	utils.CreatedResponse(c, "Order created successfully", order)
 }
 
+// @Summary Start checkout
+// @Description Reserve the stock for the items in the user's cart. The reservation expires after a while unless an order is placed
+// @Tags Orders
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=dto.CheckoutResponse} "Stock reserved successfully"
+// @Failure 400 {object} utils.Response "Cart is empty"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 409 {object} utils.Response "Insufficient stock"
+// @Router /orders/checkout [post]
+func (s *Server) startCheckout(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	checkout, err := s.reservationService.StartCheckout(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInsufficientStock) {
+			utils.ConflictResponse(c, "Failed to reserve stock", err)
+			return
+		}
+		utils.BadRequestResponse(c, "Failed to start checkout", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Stock reserved successfully", checkout)
+}
+
 // @Summary Get user's orders
 // @Description Retrieve paginated list of user's orders
 // @Tags Orders
@@ -83,3 +123,167 @@ func (s *Server) getOrder(c *gin.Context) {
 
 	utils.SuccessResponse(c, "Order retrieved successfully", order)
 }
+
+// @Summary Cancel an order
+// @Description Cancel a pending or confirmed order, return its items to stock and void its authorized payment. Orders with a captured payment have to be refunded first. Customers can cancel their own orders, admins can cancel any order
+// @Tags Orders
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Success 200 {object} utils.Response{data=dto.OrderResponse} "Order cancelled successfully"
+// @Failure 400 {object} utils.Response "Invalid order ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Order not found"
+// @Failure 409 {object} utils.Response "Order can no longer be cancelled or has to be refunded first"
+// @Router /orders/{id}/cancel [post]
+func (s *Server) cancelOrder(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	isAdmin := c.GetString("user_role") == string(models.UserRoleAdmin)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	order, err := s.orderService.CancelOrder(uint(id), userID, isAdmin)
+	if err != nil {
+		var transitionErr *services.InvalidStatusTransitionError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFoundResponse(c, "Order not found")
+		case errors.As(err, &transitionErr):
+			utils.ConflictResponse(c, "Order can no longer be cancelled", err)
+		case errors.Is(err, services.ErrOrderPaid):
+			utils.ConflictResponse(c, "Order has to be refunded before it is cancelled", err)
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to cancel order", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Order cancelled successfully", order)
+}
+
+// @Summary Update order status
+// @Description Move an order to a new status following the order lifecycle (Admin only)
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param request body dto.UpdateOrderStatusRequest true "New status"
+// @Success 200 {object} utils.Response{data=dto.OrderResponse} "Order status updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Order not found"
+// @Failure 409 {object} utils.Response "Status transition not allowed or order has to be refunded first"
+// @Router /orders/{id}/status [put]
+func (s *Server) updateOrderStatus(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	var req dto.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	order, err := s.orderService.UpdateOrderStatus(uint(id), userID, &req)
+	if err != nil {
+		var transitionErr *services.InvalidStatusTransitionError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFoundResponse(c, "Order not found")
+		case errors.As(err, &transitionErr):
+			utils.ConflictResponse(c, "Status transition not allowed", err)
+		case errors.Is(err, services.ErrOrderPaid):
+			utils.ConflictResponse(c, "Order has to be refunded before it is cancelled", err)
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to update order status", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Order status updated successfully", order)
+}
+
+// @Summary List all orders
+// @Description Retrieve a filtered, sorted and paginated list of orders across all customers (Admin only)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Order status" Enums(pending, confirmed, shipped, delivered, cancelled)
+// @Param user_id query int false "Customer ID"
+// @Param from query string false "Created on or after (YYYY-MM-DD)"
+// @Param to query string false "Created on or before (YYYY-MM-DD)"
+// @Param min_total query number false "Minimum order total"
+// @Param max_total query number false "Maximum order total"
+// @Param sort query string false "Sort order" Enums(newest, oldest, total_asc, total_desc) default(newest)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} utils.PaginatedResponse{data=[]dto.AdminOrderResponse} "Orders retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filters"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /admin/orders [get]
+func (s *Server) listAdminOrders(c *gin.Context) {
+	var query dto.AdminOrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestResponse(c, "Invalid filters", err)
+		return
+	}
+
+	if query.MinTotal != nil && query.MaxTotal != nil && query.MinTotal.Cmp(*query.MaxTotal) > 0 {
+		utils.BadRequestResponse(c, "Invalid filters", errors.New("min_total must not be greater than max_total"))
+		return
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		utils.BadRequestResponse(c, "Invalid filters", errors.New("from must not be after to"))
+		return
+	}
+
+	orders, meta, err := s.orderService.ListOrders(&query)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch orders", err)
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, "Orders retrieved successfully", orders, *meta)
+}
+
+// @Summary Get any order by ID
+// @Description Retrieve an order of any customer including the customer details (Admin only)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Success 200 {object} utils.Response{data=dto.AdminOrderResponse} "Order retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid order ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Order not found"
+// @Router /admin/orders/{id} [get]
+func (s *Server) getAdminOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	order, err := s.orderService.GetOrderForAdmin(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Order not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Order retrieved successfully", order)
+}
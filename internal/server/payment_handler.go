@@ -0,0 +1,230 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+const paymentSignatureHeader = "X-Payment-Signature"
+
+// @Summary Pay for an order
+// @Description Authorize the order total with the payment provider. With capture set the payment is captured immediately and the order is confirmed
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param request body dto.CreatePaymentRequest true "Payment method"
+// @Success 201 {object} utils.Response{data=dto.PaymentResponse} "Payment created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 402 {object} utils.Response "Payment declined"
+// @Failure 404 {object} utils.Response "Order not found"
+// @Failure 409 {object} utils.Response "Order cannot be paid"
+// @Failure 504 {object} utils.Response "Payment gateway timeout"
+// @Router /orders/{id}/payments [post]
+func (s *Server) createPayment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	payment, err := s.paymentService.CreatePayment(userID, uint(id), &req)
+	if err != nil {
+		s.paymentErrorResponse(c, "Failed to create payment", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Payment created successfully", payment)
+}
+
+// @Summary Capture an order payment
+// @Description Capture the authorized payment of an order and confirm the order (Admin only)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Success 200 {object} utils.Response{data=dto.PaymentResponse} "Payment captured successfully"
+// @Failure 400 {object} utils.Response "Invalid order ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 409 {object} utils.Response "Order has no authorized payment"
+// @Failure 504 {object} utils.Response "Payment gateway timeout, the capture is recorded once the gateway reports it"
+// @Router /admin/orders/{id}/payments/capture [post]
+func (s *Server) capturePayment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	payment, err := s.paymentService.CapturePayment(uint(id), userID)
+	if err != nil {
+		s.paymentErrorResponse(c, "Failed to capture payment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Payment captured successfully", payment)
+}
+
+// @Summary Void an order payment
+// @Description Release the authorized payment of an order without charging it (Admin only)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Success 200 {object} utils.Response{data=dto.PaymentResponse} "Payment voided successfully"
+// @Failure 400 {object} utils.Response "Invalid order ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 409 {object} utils.Response "Order has no authorized payment"
+// @Router /admin/orders/{id}/payments/void [post]
+func (s *Server) voidPayment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	payment, err := s.paymentService.VoidPayment(uint(id), userID)
+	if err != nil {
+		s.paymentErrorResponse(c, "Failed to void payment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Payment voided successfully", payment)
+}
+
+// @Summary Payment gateway webhook
+// @Description Receive payment status updates from the payment gateway. The X-Payment-Signature header must carry "t=<unix timestamp>,v1=<hex HMAC-SHA256 of timestamp.body>"
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param X-Payment-Signature header string true "Webhook signature"
+// @Param request body dto.PaymentWebhookEvent true "Webhook event"
+// @Success 200 {object} utils.Response "Webhook processed successfully"
+// @Failure 400 {object} utils.Response "Invalid payload"
+// @Failure 401 {object} utils.Response "Invalid signature"
+// @Failure 404 {object} utils.Response "Payment not found"
+// @Router /webhooks/payments [post]
+func (s *Server) paymentWebhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid payload", err)
+		return
+	}
+
+	signature := c.GetHeader(paymentSignatureHeader)
+	if err := utils.VerifyWebhookSignature(signature, s.config.Payment.WebhookSecret, body, s.config.Payment.WebhookTolerance, time.Now()); err != nil {
+		utils.UnauthorizedResponse(c, err.Error())
+		return
+	}
+
+	var event dto.PaymentWebhookEvent
+	if err := binding.JSON.BindBody(body, &event); err != nil {
+		utils.BadRequestResponse(c, "Invalid payload", err)
+		return
+	}
+
+	processed, err := s.paymentService.HandleWebhook(&event)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Payment not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to process webhook", err)
+		return
+	}
+
+	if !processed {
+		utils.SuccessResponse(c, "Webhook already processed", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, "Webhook processed successfully", nil)
+}
+
+// @Summary Refund an order
+// @Description Refund the whole order or selected order items and quantities, optionally putting them back in stock. Refunds a failed earlier request left pending are finished first, without paying them twice (Admin only)
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param request body dto.CreateRefundRequest true "Items to refund, empty for the whole order"
+// @Success 201 {object} utils.Response{data=dto.RefundResponse} "Refund created successfully"
+// @Failure 400 {object} utils.Response "Invalid refund"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Order not found"
+// @Failure 409 {object} utils.Response "Refund exceeds the captured amount"
+// @Failure 504 {object} utils.Response "Gateway did not answer, the refund stays pending"
+// @Router /admin/orders/{id}/refunds [post]
+func (s *Server) refundOrder(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID", err)
+		return
+	}
+
+	var req dto.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	refund, err := s.paymentService.RefundOrder(uint(id), userID, &req)
+	if err != nil {
+		s.paymentErrorResponse(c, "Failed to refund order", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Refund created successfully", refund)
+}
+
+func (s *Server) paymentErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Order not found")
+	case errors.Is(err, interfaces.ErrPaymentDeclined):
+		utils.ErrorResponse(c, http.StatusPaymentRequired, message, err)
+	case errors.Is(err, interfaces.ErrPaymentTimeout):
+		utils.ErrorResponse(c, http.StatusGatewayTimeout, message, err)
+	case errors.Is(err, services.ErrInvalidRefund):
+		utils.BadRequestResponse(c, message, err)
+	case errors.Is(err, services.ErrOrderNotPayable),
+		errors.Is(err, services.ErrOrderAlreadyPaid),
+		errors.Is(err, services.ErrNoAuthorizedPayment),
+		errors.Is(err, services.ErrNoCapturedPayment),
+		errors.Is(err, services.ErrRefundExceedsCapture):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
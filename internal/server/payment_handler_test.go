@@ -0,0 +1,43 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/veetmoradiya3628/go-shop/internal/config"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+)
+
+func TestPaymentWebhookRejectsBadSignatures(t *testing.T) {
+	cfg := &config.Config{
+		Payment: config.PaymentConfig{WebhookSecret: "whsec_test", WebhookTolerance: 5 * time.Minute},
+	}
+	s := &Server{config: cfg}
+	router := gin.New()
+	router.POST("/webhooks/payments", s.paymentWebhook)
+
+	body := []byte(`{"id":"evt_1","type":"payment.captured","data":{"transaction_id":"fake_txn_1"}}`)
+	send := func(signature string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/webhooks/payments", bytes.NewReader(body))
+		if signature != "" {
+			req.Header.Set(paymentSignatureHeader, signature)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// missing signature
+	assert.Equal(t, http.StatusUnauthorized, send("").Code)
+
+	// signed with another secret
+	assert.Equal(t, http.StatusUnauthorized, send(utils.SignWebhookPayload("other", time.Now(), body)).Code)
+
+	// stale timestamp
+	assert.Equal(t, http.StatusUnauthorized, send(utils.SignWebhookPayload("whsec_test", time.Now().Add(-time.Hour), body)).Code)
+}
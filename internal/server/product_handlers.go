@@ -1,11 +1,14 @@
 package server
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
 	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
 )
 
 // @Summary Create a new category
@@ -19,6 +22,8 @@ import (
 // @Failure 400 {object} utils.Response "Invalid request data"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Parent category not found"
+// @Failure 409 {object} utils.Response "Slug already in use"
 // @Router /categories [post]
 func (s *Server) createCategory(c *gin.Context) {
 	var req dto.CreateCategoryRequest
@@ -29,7 +34,7 @@ func (s *Server) createCategory(c *gin.Context) {
 
 	category, err := s.productService.CreateCategory(&req)
 	if err != nil {
-		utils.InternalServerErrorResponse(c, "Failed to create category", err)
+		s.categoryErrorResponse(c, "Failed to create category", err)
 		return
 	}
 
@@ -53,8 +58,48 @@ func (s *Server) getCategories(c *gin.Context) {
 	utils.SuccessResponse(c, "Categories retrieved successfully", categories)
 }
 
+// @Summary Get the category tree
+// @Description Retrieve the active categories nested under their parents
+// @Tags Categories
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]dto.CategoryTreeResponse} "Category tree retrieved successfully"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /categories/tree [get]
+func (s *Server) getCategoryTree(c *gin.Context) {
+	tree, err := s.productService.GetCategoryTree()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch category tree", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Category tree retrieved successfully", tree)
+}
+
+// @Summary Get a category by slug
+// @Description Retrieve a category by its slug. A slug the category had before answers with a redirect to its current one
+// @Tags Categories
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Success 200 {object} utils.Response{data=dto.CategoryResponse} "Category retrieved successfully"
+// @Success 301 {object} utils.Response{data=dto.SlugRedirectResponse} "Category moved"
+// @Failure 404 {object} utils.Response "Category not found"
+// @Router /categories/slug/{slug} [get]
+func (s *Server) getCategoryBySlug(c *gin.Context) {
+	category, redirect, err := s.productService.GetCategoryBySlug(c.Param("slug"))
+	if err != nil {
+		s.categoryErrorResponse(c, "Failed to fetch category", err)
+		return
+	}
+	if redirect != nil {
+		utils.MovedPermanentlyResponse(c, "/api/v1/categories/slug/"+redirect.Slug, "Category moved", redirect)
+		return
+	}
+
+	utils.SuccessResponse(c, "Category retrieved successfully", category)
+}
+
 // @Summary Update a category
-// @Description Update an existing category (Admin only)
+// @Description Update an existing category. A category cannot be moved under itself or one of its subcategories (Admin only)
 // @Tags Categories
 // @Accept json
 // @Produce json
@@ -62,9 +107,11 @@ func (s *Server) getCategories(c *gin.Context) {
 // @Param id path int true "Category ID"
 // @Param request body dto.UpdateCategoryRequest true "Category update data"
 // @Success 200 {object} utils.Response{data=dto.CategoryResponse} "Category updated successfully"
-// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 400 {object} utils.Response "Invalid request data or parent would create a cycle"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Category or parent category not found"
+// @Failure 409 {object} utils.Response "Slug already in use"
 // @Router /categories/{id} [put]
 func (s *Server) updateCategory(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -79,21 +126,24 @@ func (s *Server) updateCategory(c *gin.Context) {
 	}
 	category, err := s.productService.UpdateCategory(uint(id), &req)
 	if err != nil {
-		utils.InternalServerErrorResponse(c, "Failed to update category", err)
+		s.categoryErrorResponse(c, "Failed to update category", err)
 		return
 	}
 	utils.SuccessResponse(c, "Category updated successfully", category)
 }
 
 // @Summary Delete a category
-// @Description Delete a category (Admin only)
+// @Description Delete a category. A category with subcategories is only deleted when they are moved to another parent (Admin only)
 // @Tags Categories
 // @Security BearerAuth
 // @Param id path int true "Category ID"
+// @Param move_children_to query int false "Category the subcategories move under, 0 for the top level"
 // @Success 200 {object} utils.Response "Category deleted successfully"
-// @Failure 400 {object} utils.Response "Invalid category ID"
+// @Failure 400 {object} utils.Response "Invalid category ID or new parent would create a cycle"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Category or new parent not found"
+// @Failure 409 {object} utils.Response "Category has subcategories"
 // @Router /categories/{id} [delete]
 func (s *Server) deleteCategory(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -101,8 +151,18 @@ func (s *Server) deleteCategory(c *gin.Context) {
 		utils.BadRequestResponse(c, "Invalid category ID", err)
 		return
 	}
-	if err := s.productService.DeleteCategory(uint(id)); err != nil {
-		utils.InternalServerErrorResponse(c, "Failed to delete category", err)
+	var moveChildrenTo *uint
+	if value, ok := c.GetQuery("move_children_to"); ok {
+		parentID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid move_children_to category ID", err)
+			return
+		}
+		target := uint(parentID)
+		moveChildrenTo = &target
+	}
+	if err := s.productService.DeleteCategory(uint(id), moveChildrenTo); err != nil {
+		s.categoryErrorResponse(c, "Failed to delete category", err)
 		return
 	}
 
@@ -117,9 +177,10 @@ func (s *Server) deleteCategory(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CreateProductRequest true "Product data"
 // @Success 201 {object} utils.Response{data=dto.ProductResponse} "Product created successfully"
-// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 400 {object} utils.Response "Invalid request data or variant options"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 409 {object} utils.Response "Slug already in use"
 // @Router /products [post]
 func (s *Server) createProduct(c *gin.Context) {
 	var req dto.CreateProductRequest
@@ -127,9 +188,16 @@ func (s *Server) createProduct(c *gin.Context) {
 		utils.BadRequestResponse(c, "Invalid request data", err)
 		return
 	}
-	product, err := s.productService.CreateProduct(&req)
+	product, err := s.productService.CreateProduct(&req, c.GetUint("user_id"))
 	if err != nil {
-		utils.InternalServerErrorResponse(c, "Failed to create product", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidVariantOptions):
+			utils.BadRequestResponse(c, "Failed to create product", err)
+		case errors.Is(err, services.ErrSlugTaken):
+			utils.ConflictResponse(c, "Failed to create product", err)
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to create product", err)
+		}
 		return
 	}
 
@@ -137,20 +205,40 @@ func (s *Server) createProduct(c *gin.Context) {
 }
 
 // @Summary Get all products
-// @Description Retrieve paginated list of active products
+// @Description Retrieve a filtered, sorted and paginated list of active products
 // @Tags Products
 // @Produce json
+// @Param category_id query int false "Category ID, products of its subcategories included"
+// @Param min_price query number false "Minimum price of the cheapest active variant in the base currency"
+// @Param max_price query number false "Maximum price of the cheapest active variant in the base currency"
+// @Param in_stock query bool false "Only products in stock"
+// @Param q query string false "Text to look for in the name, description or SKU"
+// @Param sort query string false "Sort order" Enums(price_asc, price_desc, newest, name) default(newest)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
 // @Success 200 {object} utils.PaginatedResponse{data=[]dto.ProductResponse} "Products retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filters or unsupported currency"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /products [get]
 func (s *Server) getProducts(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	var query dto.ProductListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestResponse(c, "Invalid filters", err)
+		return
+	}
+
+	if query.MinPrice != nil && query.MaxPrice != nil && query.MinPrice.Cmp(*query.MaxPrice) > 0 {
+		utils.BadRequestResponse(c, "Invalid filters", errors.New("min_price must not be greater than max_price"))
+		return
+	}
 
-	products, meta, err := s.productService.GetProducts(page, limit)
+	products, meta, err := s.productService.GetProducts(&query, requestCurrency(c))
 	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.BadRequestResponse(c, "Unsupported currency", err)
+			return
+		}
 		utils.InternalServerErrorResponse(c, "Failed to fetch products", err)
 		return
 	}
@@ -163,8 +251,9 @@ func (s *Server) getProducts(c *gin.Context) {
 // @Tags Products
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
 // @Success 200 {object} utils.Response{data=dto.ProductResponse} "Product retrieved successfully"
-// @Failure 400 {object} utils.Response "Invalid product ID"
+// @Failure 400 {object} utils.Response "Invalid product ID or unsupported currency"
 // @Failure 404 {object} utils.Response "Product not found"
 // @Router /products/{id} [get]
 func (s *Server) getProduct(c *gin.Context) {
@@ -174,17 +263,50 @@ func (s *Server) getProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := s.productService.GetProduct(uint(id))
+	product, err := s.productService.GetProduct(uint(id), requestCurrency(c))
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.BadRequestResponse(c, "Unsupported currency", err)
+			return
+		}
+		utils.NotFoundResponse(c, "Product not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Product retrieved successfully", product)
+}
+
+// @Summary Get a product by slug
+// @Description Retrieve a product by its slug. A slug the product had before answers with a redirect to its current one
+// @Tags Products
+// @Produce json
+// @Param slug path string true "Product slug"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.ProductResponse} "Product retrieved successfully"
+// @Success 301 {object} utils.Response{data=dto.SlugRedirectResponse} "Product moved"
+// @Failure 400 {object} utils.Response "Unsupported currency"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Router /products/slug/{slug} [get]
+func (s *Server) getProductBySlug(c *gin.Context) {
+	product, redirect, err := s.productService.GetProductBySlug(c.Param("slug"), requestCurrency(c))
 	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.BadRequestResponse(c, "Unsupported currency", err)
+			return
+		}
 		utils.NotFoundResponse(c, "Product not found")
 		return
 	}
+	if redirect != nil {
+		utils.MovedPermanentlyResponse(c, "/api/v1/products/slug/"+redirect.Slug, "Product moved", redirect)
+		return
+	}
 
 	utils.SuccessResponse(c, "Product retrieved successfully", product)
 }
 
 // @Summary Update a product
-// @Description Update an existing product (Admin only)
+// @Description Update an existing product. A stock change is applied to the default variant in the primary warehouse, products with several variants have their stock changed per variant (Admin only)
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -195,6 +317,7 @@ func (s *Server) getProduct(c *gin.Context) {
 // @Failure 400 {object} utils.Response "Invalid request data"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 409 {object} utils.Response "Slug already in use"
 // @Router /products/{id} [put]
 func (s *Server) updateProduct(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -209,15 +332,57 @@ func (s *Server) updateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := s.productService.UpdateProduct(uint(id), &req)
+	product, err := s.productService.UpdateProduct(uint(id), &req, c.GetUint("user_id"))
 	if err != nil {
-		utils.InternalServerErrorResponse(c, "Failed to update product", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidStockAdjustment):
+			utils.BadRequestResponse(c, "Failed to update product", err)
+		case errors.Is(err, services.ErrSlugTaken):
+			utils.ConflictResponse(c, "Failed to update product", err)
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to update product", err)
+		}
 		return
 	}
 
 	utils.SuccessResponse(c, "Product updated successfully", product)
 }
 
+// @Summary Get notified when a product is back in stock
+// @Description Subscribe to one email once an out of stock product can be ordered again
+// @Tags Products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} utils.Response{data=dto.BackInStockSubscriptionResponse} "Subscribed to back in stock notification"
+// @Failure 400 {object} utils.Response "Invalid product ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Failure 409 {object} utils.Response "Product is in stock"
+// @Router /products/{id}/notify-me [post]
+func (s *Server) notifyWhenInStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+
+	subscription, err := s.productService.NotifyWhenInStock(c.GetUint("user_id"), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFoundResponse(c, "Product not found")
+		case errors.Is(err, services.ErrProductInStock):
+			utils.ConflictResponse(c, "Product is in stock", err)
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to subscribe to back in stock notification", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Subscribed to back in stock notification", subscription)
+}
+
 // @Summary Delete a product
 // @Description Delete a product (Admin only)
 // @Tags Products
@@ -243,15 +408,17 @@ func (s *Server) deleteProduct(c *gin.Context) {
 }
 
 // @Summary Upload product image
-// @Description Upload an image for a product (Admin only)
+// @Description Upload an image for a product, optionally shown for one of its variants (Admin only)
 // @Tags Products
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
 // @Param image formData file true "Image file"
+// @Param variant_id formData int false "Variant the image shows"
 // @Success 200 {object} utils.Response{data=map[string]string} "Image uploaded successfully"
 // @Failure 400 {object} utils.Response "Invalid request or file"
+// @Failure 404 {object} utils.Response "Variant not found"
 // @Failure 401 {object} utils.Response "Unauthorized"
 // @Failure 403 {object} utils.Response "Admin access required"
 // @Router /products/{id}/images [post]
@@ -266,6 +433,16 @@ func (s *Server) uploadProductImage(c *gin.Context) {
 		utils.BadRequestResponse(c, "Image file is required", err)
 		return
 	}
+	var variantID *uint
+	if value := c.PostForm("variant_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid variant ID", err)
+			return
+		}
+		id := uint(parsed)
+		variantID = &id
+	}
 
 	imageURL, err := s.uploadService.UploadProductImage(uint(id), file)
 
@@ -273,9 +450,150 @@ func (s *Server) uploadProductImage(c *gin.Context) {
 		utils.InternalServerErrorResponse(c, "Failed to upload product image", err)
 		return
 	}
-	if err := s.productService.AddProductImage(uint(id), imageURL, file.Filename); err != nil {
+	if err := s.productService.AddProductImage(uint(id), variantID, imageURL, file.Filename); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Variant not found")
+			return
+		}
 		utils.InternalServerErrorResponse(c, "Failed to associate image with product", err)
 		return
 	}
 	utils.SuccessResponse(c, "Product image uploaded successfully", gin.H{"image_url": imageURL})
 }
+
+// @Summary Add a product variant
+// @Description Add a variant to a product. Options and option values the product does not have yet are added (Admin only)
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body dto.ProductVariantRequest true "Variant data"
+// @Success 201 {object} utils.Response{data=dto.ProductResponse} "Variant created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data or variant options"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Router /products/{id}/variants [post]
+func (s *Server) createVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+
+	var req dto.ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	product, err := s.productService.CreateVariant(uint(id), &req, c.GetUint("user_id"))
+	if err != nil {
+		s.variantErrorResponse(c, "Failed to create variant", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Variant created successfully", product)
+}
+
+// @Summary Update a product variant
+// @Description Replace a variant's SKU, price, options and stock. A stock change is applied to the primary warehouse (Admin only)
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variant_id path int true "Variant ID"
+// @Param request body dto.ProductVariantRequest true "Variant data"
+// @Success 200 {object} utils.Response{data=dto.ProductResponse} "Variant updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data or variant options"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product or variant not found"
+// @Router /products/{id}/variants/{variant_id} [put]
+func (s *Server) updateVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid variant ID", err)
+		return
+	}
+
+	var req dto.ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	product, err := s.productService.UpdateVariant(uint(id), uint(variantID), &req, c.GetUint("user_id"))
+	if err != nil {
+		s.variantErrorResponse(c, "Failed to update variant", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Variant updated successfully", product)
+}
+
+// @Summary Delete a product variant
+// @Description Delete a variant without stock and remove it from carts. The default variant cannot be deleted (Admin only)
+// @Tags Products
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variant_id path int true "Variant ID"
+// @Success 200 {object} utils.Response "Variant deleted successfully"
+// @Failure 400 {object} utils.Response "Invalid product or variant ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Product or variant not found"
+// @Failure 409 {object} utils.Response "Default variant or variant still holds stock"
+// @Router /products/{id}/variants/{variant_id} [delete]
+func (s *Server) deleteVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid variant ID", err)
+		return
+	}
+
+	if err := s.productService.DeleteVariant(uint(id), uint(variantID)); err != nil {
+		s.variantErrorResponse(c, "Failed to delete variant", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Variant deleted successfully", nil)
+}
+
+func (s *Server) categoryErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Category not found")
+	case errors.Is(err, services.ErrCategoryCycle):
+		utils.BadRequestResponse(c, message, err)
+	case errors.Is(err, services.ErrCategoryHasChildren), errors.Is(err, services.ErrSlugTaken):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func (s *Server) variantErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Product or variant not found")
+	case errors.Is(err, services.ErrInvalidVariantOptions), errors.Is(err, services.ErrInvalidStockAdjustment):
+		utils.BadRequestResponse(c, message, err)
+	case errors.Is(err, services.ErrDefaultVariant), errors.Is(err, services.ErrVariantHasStock):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
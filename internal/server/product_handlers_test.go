@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProductsRejectsInvalidFilters(t *testing.T) {
+	s := &Server{}
+	router := gin.New()
+	router.GET("/products", s.getProducts)
+
+	for _, query := range []string{
+		"sort=cheapest",
+		"min_price=-1",
+		"min_price=abc",
+		"min_price=20&max_price=10",
+		"in_stock=maybe",
+		"category_id=-3",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/products?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestSlugValidation(t *testing.T) {
+	s := &Server{}
+	router := gin.New()
+	router.POST("/products", s.createProduct)
+
+	body := `{"category_id": 1, "name": "Beret", "price": "25.00", "sku": "BERET", "slug": "Not A Slug"}`
+	req, _ := http.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary List the reviews of a product
+// @Description Retrieve the approved reviews of a product
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param rating query int false "Only reviews with this rating"
+// @Param sort query string false "Sort order" Enums(newest, oldest, rating_desc, rating_asc) default(newest)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} utils.PaginatedResponse{data=[]dto.ReviewResponse} "Reviews retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid product ID or filters"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Router /products/{id}/reviews [get]
+func (s *Server) getProductReviews(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+
+	var query dto.ReviewListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestResponse(c, "Invalid filters", err)
+		return
+	}
+
+	reviews, meta, err := s.reviewService.GetProductReviews(uint(id), &query)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Product not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to fetch reviews", err)
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, "Reviews retrieved successfully", reviews, *meta)
+}
+
+// @Summary Review a product
+// @Description Write a review of a product, shown once an admin approves it. Reviews of delivered purchases are marked as verified
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body dto.ReviewRequest true "Review data"
+// @Success 201 {object} utils.Response{data=dto.ReviewResponse} "Review created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Product not found"
+// @Failure 409 {object} utils.Response "Product already reviewed"
+// @Router /products/{id}/reviews [post]
+func (s *Server) createReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID", err)
+		return
+	}
+
+	var req dto.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	review, err := s.reviewService.CreateReview(c.GetUint("user_id"), uint(id), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Product not found")
+			return
+		}
+		s.reviewErrorResponse(c, "Failed to create review", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Review created successfully", review)
+}
+
+// @Summary List my reviews
+// @Description Retrieve the reviews of the current user, whatever their moderation status
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} utils.PaginatedResponse{data=[]dto.ReviewResponse} "Reviews retrieved successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Router /users/reviews [get]
+func (s *Server) getMyReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	reviews, meta, err := s.reviewService.GetUserReviews(c.GetUint("user_id"), page, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch reviews", err)
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, "Reviews retrieved successfully", reviews, *meta)
+}
+
+// @Summary Update my review
+// @Description Edit a review of the current user. The edited review is shown again once an admin approves it
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body dto.ReviewRequest true "Review data"
+// @Success 200 {object} utils.Response{data=dto.ReviewResponse} "Review updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Review not found"
+// @Router /users/reviews/{id} [put]
+func (s *Server) updateReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid review ID", err)
+		return
+	}
+
+	var req dto.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	review, err := s.reviewService.UpdateReview(c.GetUint("user_id"), uint(id), &req)
+	if err != nil {
+		s.reviewErrorResponse(c, "Failed to update review", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Review updated successfully", review)
+}
+
+// @Summary Delete my review
+// @Description Delete a review of the current user
+// @Tags Reviews
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 200 {object} utils.Response "Review deleted successfully"
+// @Failure 400 {object} utils.Response "Invalid review ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Review not found"
+// @Router /users/reviews/{id} [delete]
+func (s *Server) deleteReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid review ID", err)
+		return
+	}
+
+	if err := s.reviewService.DeleteReview(c.GetUint("user_id"), uint(id)); err != nil {
+		s.reviewErrorResponse(c, "Failed to delete review", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Review deleted successfully", nil)
+}
+
+// @Summary List reviews for moderation
+// @Description List reviews by moderation status, oldest first (Admin only)
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Moderation status" Enums(pending, approved, hidden)
+// @Param product_id query int false "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} utils.PaginatedResponse{data=[]dto.ReviewResponse} "Reviews retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid filters"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Router /admin/reviews [get]
+func (s *Server) getReviews(c *gin.Context) {
+	var query dto.AdminReviewListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestResponse(c, "Invalid filters", err)
+		return
+	}
+
+	reviews, meta, err := s.reviewService.GetReviews(&query)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch reviews", err)
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, "Reviews retrieved successfully", reviews, *meta)
+}
+
+// @Summary Approve a review
+// @Description Show a review on its product and count it towards the product's rating (Admin only)
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 200 {object} utils.Response{data=dto.ReviewResponse} "Review approved successfully"
+// @Failure 400 {object} utils.Response "Invalid review ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Review not found"
+// @Router /admin/reviews/{id}/approve [post]
+func (s *Server) approveReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid review ID", err)
+		return
+	}
+
+	review, err := s.reviewService.ApproveReview(uint(id), c.GetUint("user_id"))
+	if err != nil {
+		s.reviewErrorResponse(c, "Failed to approve review", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Review approved successfully", review)
+}
+
+// @Summary Hide a review
+// @Description Take a review off its product and out of the product's rating (Admin only)
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 200 {object} utils.Response{data=dto.ReviewResponse} "Review hidden successfully"
+// @Failure 400 {object} utils.Response "Invalid review ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Review not found"
+// @Router /admin/reviews/{id}/hide [post]
+func (s *Server) hideReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid review ID", err)
+		return
+	}
+
+	review, err := s.reviewService.HideReview(uint(id), c.GetUint("user_id"))
+	if err != nil {
+		s.reviewErrorResponse(c, "Failed to hide review", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Review hidden successfully", review)
+}
+
+func (s *Server) reviewErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Review not found")
+	case errors.Is(err, services.ErrAlreadyReviewed):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateReviewRejectsInvalidRatings(t *testing.T) {
+	s := &Server{}
+	router := gin.New()
+	router.POST("/products/:id/reviews", s.createReview)
+
+	for _, body := range []string{
+		`{"rating": 0, "title": "Nothing"}`,
+		`{"rating": 6, "title": "Too much"}`,
+		`{"rating": 4}`,
+	} {
+		req, _ := http.NewRequest(http.MethodPost, "/products/1/reviews", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+)
+
+// @Summary Search products
+// @Description Full-text search over product names, descriptions, SKUs and category names, most relevant first. Matches are highlighted with <mark> tags and the facets count all matches by category and price bucket
+// @Tags Products
+// @Produce json
+// @Param q query string true "Search text, supports quoted phrases, OR and -excluded words"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.PaginatedResponse{data=dto.SearchResponse} "Search results retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid search or unsupported currency"
+// @Failure 500 {object} utils.Response "Internal server error"
+// @Router /search [get]
+func (s *Server) searchProducts(c *gin.Context) {
+	var query dto.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestResponse(c, "Invalid search", err)
+		return
+	}
+
+	results, meta, err := s.searchService.Search(&query, requestCurrency(c))
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.BadRequestResponse(c, "Unsupported currency", err)
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to search products", err)
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, "Search results retrieved successfully", results, *meta)
+}
@@ -16,15 +16,25 @@ import (
 )
 
 type Server struct {
-	config         *config.Config
-	db             *gorm.DB
-	logger         *zerolog.Logger
-	authService    *services.AuthService
-	productService *services.ProductService
-	userService    *services.UserService
-	uploadService  *services.UploadService
-	cartService    *services.CartService
-	orderService   *services.OrderService
+	config             *config.Config
+	db                 *gorm.DB
+	logger             *zerolog.Logger
+	authService        *services.AuthService
+	productService     *services.ProductService
+	userService        *services.UserService
+	uploadService      *services.UploadService
+	cartService        *services.CartService
+	orderService       *services.OrderService
+	paymentService     *services.PaymentService
+	currencyService    *services.CurrencyService
+	couponService      *services.CouponService
+	promotionService   *services.PromotionService
+	reservationService *services.ReservationService
+	inventoryService   *services.InventoryService
+	warehouseService   *services.WarehouseService
+	searchService      *services.SearchService
+	reviewService      *services.ReviewService
+	wishlistService    *services.WishlistService
 }
 
 func New(cfg *config.Config,
@@ -36,17 +46,37 @@ func New(cfg *config.Config,
 	uploadService *services.UploadService,
 	cartService *services.CartService,
 	orderService *services.OrderService,
+	paymentService *services.PaymentService,
+	currencyService *services.CurrencyService,
+	couponService *services.CouponService,
+	promotionService *services.PromotionService,
+	reservationService *services.ReservationService,
+	inventoryService *services.InventoryService,
+	warehouseService *services.WarehouseService,
+	searchService *services.SearchService,
+	reviewService *services.ReviewService,
+	wishlistService *services.WishlistService,
 ) *Server {
 	return &Server{
-		config:         cfg,
-		db:             db,
-		logger:         logger,
-		authService:    authService,
-		productService: productService,
-		userService:    userService,
-		uploadService:  uploadService,
-		cartService:    cartService,
-		orderService:   orderService,
+		config:             cfg,
+		db:                 db,
+		logger:             logger,
+		authService:        authService,
+		productService:     productService,
+		userService:        userService,
+		uploadService:      uploadService,
+		cartService:        cartService,
+		orderService:       orderService,
+		paymentService:     paymentService,
+		currencyService:    currencyService,
+		couponService:      couponService,
+		promotionService:   promotionService,
+		reservationService: reservationService,
+		inventoryService:   inventoryService,
+		warehouseService:   warehouseService,
+		searchService:      searchService,
+		reviewService:      reviewService,
+		wishlistService:    wishlistService,
 	}
 }
 
@@ -77,6 +107,20 @@ func (s *Server) SetupRoutes() *gin.Engine {
 			auth.POST("/refresh", s.refreshToken)
 			auth.POST("/logout", s.logout)
 		}
+
+		// cart routes, for users and for guests with a cart token
+		cart := api.Group("/cart")
+		cart.Use(s.optionalAuthMiddleware())
+		{
+			cartRoutes := cart
+			cartRoutes.GET("/", s.getCart)
+			cartRoutes.POST("/items", s.addToCart)
+			cartRoutes.PUT("/items/:id", s.updateCartItem)
+			cartRoutes.DELETE("/items/:id", s.removeFromCart)
+			cartRoutes.POST("/coupon", s.applyCoupon)
+			cartRoutes.DELETE("/coupon", s.removeCoupon)
+		}
+
 		protected := api.Group("/")
 		protected.Use(s.authMiddleware())
 		{
@@ -85,6 +129,23 @@ func (s *Server) SetupRoutes() *gin.Engine {
 				userRoutes := users
 				userRoutes.GET("/profile", s.getProfile)
 				userRoutes.PUT("/profile", s.updateProfile)
+				userRoutes.GET("/addresses", s.getAddresses)
+				userRoutes.POST("/addresses", s.createAddress)
+				userRoutes.PUT("/addresses/:id", s.updateAddress)
+				userRoutes.DELETE("/addresses/:id", s.deleteAddress)
+				userRoutes.GET("/reviews", s.getMyReviews)
+				userRoutes.PUT("/reviews/:id", s.updateReview)
+				userRoutes.DELETE("/reviews/:id", s.deleteReview)
+				userRoutes.GET("/wishlists", s.getWishlists)
+				userRoutes.POST("/wishlists", s.createWishlist)
+				userRoutes.GET("/wishlists/:id", s.getWishlist)
+				userRoutes.PUT("/wishlists/:id", s.updateWishlist)
+				userRoutes.DELETE("/wishlists/:id", s.deleteWishlist)
+				userRoutes.POST("/wishlists/:id/items", s.addWishlistItem)
+				userRoutes.DELETE("/wishlists/:id/items/:item_id", s.removeWishlistItem)
+				userRoutes.POST("/wishlists/:id/items/:item_id/move-to-cart", s.moveWishlistItemToCart)
+				userRoutes.POST("/wishlists/:id/share", s.shareWishlist)
+				userRoutes.DELETE("/wishlists/:id/share", s.unshareWishlist)
 			}
 
 			// category routes
@@ -104,16 +165,11 @@ func (s *Server) SetupRoutes() *gin.Engine {
 				productRoutes.PUT("/:id", s.adminMiddleware(), s.updateProduct)
 				productRoutes.DELETE("/:id", s.adminMiddleware(), s.deleteProduct)
 				productRoutes.POST("/:id/images", s.adminMiddleware(), s.uploadProductImage)
-			}
-
-			// cart routes
-			cart := protected.Group("/cart")
-			{
-				cartRoutes := cart
-				cartRoutes.GET("/", s.getCart)
-				cartRoutes.POST("/items", s.addToCart)
-				cartRoutes.PUT("/items/:id", s.updateCartItem)
-				cartRoutes.DELETE("/items/:id", s.removeFromCart)
+				productRoutes.POST("/:id/variants", s.adminMiddleware(), s.createVariant)
+				productRoutes.PUT("/:id/variants/:variant_id", s.adminMiddleware(), s.updateVariant)
+				productRoutes.DELETE("/:id/variants/:variant_id", s.adminMiddleware(), s.deleteVariant)
+				productRoutes.POST("/:id/notify-me", s.notifyWhenInStock)
+				productRoutes.POST("/:id/reviews", s.createReview)
 			}
 
 			// Order routes
@@ -121,15 +177,82 @@ func (s *Server) SetupRoutes() *gin.Engine {
 			{
 				orderRoutes := orders
 				orderRoutes.POST("/", s.createOrder)
+				orderRoutes.POST("/checkout", s.startCheckout)
 				orderRoutes.GET("/", s.getOrders)
 				orderRoutes.GET("/:id", s.getOrder)
+				orderRoutes.POST("/:id/cancel", s.cancelOrder)
+				orderRoutes.POST("/:id/payments", s.createPayment)
+				orderRoutes.PUT("/:id/status", s.adminMiddleware(), s.updateOrderStatus)
 			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(s.adminMiddleware())
+			{
+				adminOrders := admin.Group("/orders")
+				adminOrders.GET("/", s.listAdminOrders)
+				adminOrders.GET("/:id", s.getAdminOrder)
+				adminOrders.POST("/:id/payments/capture", s.capturePayment)
+				adminOrders.POST("/:id/payments/void", s.voidPayment)
+				adminOrders.POST("/:id/refunds", s.refundOrder)
+
+				adminExchangeRates := admin.Group("/exchange-rates")
+				adminExchangeRates.GET("/", s.getExchangeRates)
+				adminExchangeRates.PUT("/", s.setExchangeRate)
+				adminExchangeRates.DELETE("/:currency", s.deleteExchangeRate)
+
+				adminCoupons := admin.Group("/coupons")
+				adminCoupons.GET("/", s.getCoupons)
+				adminCoupons.POST("/", s.createCoupon)
+				adminCoupons.PUT("/:id", s.updateCoupon)
+				adminCoupons.DELETE("/:id", s.deleteCoupon)
+
+				adminPromotions := admin.Group("/promotions")
+				adminPromotions.GET("/", s.getPromotions)
+				adminPromotions.POST("/", s.createPromotion)
+				adminPromotions.PUT("/:id", s.updatePromotion)
+				adminPromotions.DELETE("/:id", s.deletePromotion)
+
+				adminProducts := admin.Group("/products")
+				adminProducts.GET("/:id/inventory-history", s.getInventoryHistory)
+				adminProducts.GET("/:id/stock-levels", s.getStockLevels)
+				adminProducts.PUT("/:id/stock-levels/:warehouse_id", s.setStockLevel)
+
+				adminReviews := admin.Group("/reviews")
+				adminReviews.GET("/", s.getReviews)
+				adminReviews.POST("/:id/approve", s.approveReview)
+				adminReviews.POST("/:id/hide", s.hideReview)
+
+				adminInventory := admin.Group("/inventory")
+				adminInventory.GET("/reconciliation", s.reconcileInventory)
+				adminInventory.POST("/transfers", s.transferStock)
+
+				adminWarehouses := admin.Group("/warehouses")
+				adminWarehouses.GET("/", s.getWarehouses)
+				adminWarehouses.POST("/", s.createWarehouse)
+				adminWarehouses.PUT("/:id", s.updateWarehouse)
+				adminWarehouses.DELETE("/:id", s.deleteWarehouse)
+			}
+		}
+
+		// webhook routes, authenticated by their signature. Without a secret anyone could sign
+		// them, so the route is left out.
+		if s.config.Payment.WebhookSecret != "" {
+			api.POST("/webhooks/payments", s.paymentWebhook)
+		} else {
+			s.logger.Warn().Msg("PAYMENT_WEBHOOK_SECRET is not set, payment webhooks are disabled")
 		}
 
 		// public routes
 		api.GET("/categories", s.getCategories)
+		api.GET("/categories/tree", s.getCategoryTree)
+		api.GET("/categories/slug/:slug", s.getCategoryBySlug)
 		api.GET("/products", s.getProducts)
 		api.GET("/products/:id", s.getProduct)
+		api.GET("/products/slug/:slug", s.getProductBySlug)
+		api.GET("/products/:id/reviews", s.getProductReviews)
+		api.GET("/search", s.searchProducts)
+		api.GET("/wishlists/shared/:token", s.getSharedWishlist)
 	}
 
 	return router
@@ -144,7 +267,7 @@ func (s *Server) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Cart-Token")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
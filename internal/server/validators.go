@@ -0,0 +1,34 @@
+package server
+
+import (
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		// Validate money fields by their minor units and rates by their value so tags like
+		// gt=0 and min=0 keep working
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			if m, ok := field.Interface().(money.Money); ok {
+				return m.Amount
+			}
+			return nil
+		}, money.Money{})
+
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			if r, ok := field.Interface().(money.Rate); ok && !r.IsZero() {
+				return r.Float64()
+			}
+			return nil
+		}, money.Rate{})
+
+		_ = v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+			return utils.IsSlug(fl.Field().String())
+		})
+	}
+}
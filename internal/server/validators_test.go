@@ -0,0 +1,35 @@
+package server
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+)
+
+func TestMoneyFieldValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bind := func(body string) (*dto.CreateProductRequest, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		var req dto.CreateProductRequest
+		err := c.ShouldBindJSON(&req)
+		return &req, err
+	}
+
+	req, err := bind(`{"category_id":1,"name":"Mug","price":12.5,"stock":3,"sku":"MUG-1"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1250), req.Price.Amount)
+
+	_, err = bind(`{"category_id":1,"name":"Mug","price":0,"stock":3,"sku":"MUG-1"}`)
+	assert.Error(t, err, "zero prices are rejected by gt=0")
+
+	_, err = bind(`{"category_id":1,"name":"Mug","price":1.005,"stock":3,"sku":"MUG-1"}`)
+	assert.Error(t, err, "sub-cent prices are rejected")
+}
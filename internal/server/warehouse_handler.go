@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary List warehouses
+// @Description List all warehouses with the stock they hold (Admin only)
+// @Tags Warehouses
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]dto.WarehouseResponse} "Warehouses retrieved successfully"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Router /admin/warehouses [get]
+func (s *Server) getWarehouses(c *gin.Context) {
+	warehouses, err := s.warehouseService.GetWarehouses()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to fetch warehouses", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Warehouses retrieved successfully", warehouses)
+}
+
+// @Summary Create a warehouse
+// @Description Create a warehouse stock can be kept and shipped from (Admin only)
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.WarehouseRequest true "Warehouse data"
+// @Success 201 {object} utils.Response{data=dto.WarehouseResponse} "Warehouse created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 409 {object} utils.Response "Warehouse code already in use"
+// @Router /admin/warehouses [post]
+func (s *Server) createWarehouse(c *gin.Context) {
+	var req dto.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	warehouse, err := s.warehouseService.CreateWarehouse(&req)
+	if err != nil {
+		s.warehouseErrorResponse(c, "Failed to create warehouse", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Warehouse created successfully", warehouse)
+}
+
+// @Summary Update a warehouse
+// @Description Replace the settings of a warehouse. A warehouse holding stock cannot be deactivated (Admin only)
+// @Tags Warehouses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Warehouse ID"
+// @Param request body dto.WarehouseRequest true "Warehouse data"
+// @Success 200 {object} utils.Response{data=dto.WarehouseResponse} "Warehouse updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Warehouse not found"
+// @Failure 409 {object} utils.Response "Warehouse code already in use or warehouse still holds stock"
+// @Router /admin/warehouses/{id} [put]
+func (s *Server) updateWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid warehouse ID", err)
+		return
+	}
+
+	var req dto.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	warehouse, err := s.warehouseService.UpdateWarehouse(uint(id), &req)
+	if err != nil {
+		s.warehouseErrorResponse(c, "Failed to update warehouse", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Warehouse updated successfully", warehouse)
+}
+
+// @Summary Delete a warehouse
+// @Description Delete an empty warehouse. Its stock has to be transferred elsewhere first (Admin only)
+// @Tags Warehouses
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Warehouse ID"
+// @Success 200 {object} utils.Response "Warehouse deleted successfully"
+// @Failure 400 {object} utils.Response "Invalid warehouse ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Admin access required"
+// @Failure 404 {object} utils.Response "Warehouse not found"
+// @Failure 409 {object} utils.Response "Warehouse still holds stock"
+// @Router /admin/warehouses/{id} [delete]
+func (s *Server) deleteWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid warehouse ID", err)
+		return
+	}
+
+	if err := s.warehouseService.DeleteWarehouse(uint(id)); err != nil {
+		s.warehouseErrorResponse(c, "Failed to delete warehouse", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Warehouse deleted successfully", nil)
+}
+
+func (s *Server) warehouseErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Warehouse not found")
+	case errors.Is(err, services.ErrWarehouseCodeTaken), errors.Is(err, services.ErrWarehouseNotEmpty):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
@@ -0,0 +1,347 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/services"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary List my wishlists
+// @Description Retrieve the wishlists of the current user with the current price and stock of their items
+// @Tags Wishlists
+// @Produce json
+// @Security BearerAuth
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=[]dto.WishlistResponse} "Wishlists retrieved successfully"
+// @Failure 400 {object} utils.Response "Unsupported currency"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Router /users/wishlists [get]
+func (s *Server) getWishlists(c *gin.Context) {
+	wishlists, err := s.wishlistService.GetWishlists(c.GetUint("user_id"), requestCurrency(c))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to fetch wishlists", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlists retrieved successfully", wishlists)
+}
+
+// @Summary Create a wishlist
+// @Description Create a named wishlist for the current user
+// @Tags Wishlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.WishlistRequest true "Wishlist data"
+// @Success 201 {object} utils.Response{data=dto.WishlistResponse} "Wishlist created successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 409 {object} utils.Response "Wishlist name already in use"
+// @Router /users/wishlists [post]
+func (s *Server) createWishlist(c *gin.Context) {
+	var req dto.WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	wishlist, err := s.wishlistService.CreateWishlist(c.GetUint("user_id"), &req)
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to create wishlist", err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Wishlist created successfully", wishlist)
+}
+
+// @Summary Get a wishlist
+// @Description Retrieve a wishlist of the current user with the current price and stock of its items
+// @Tags Wishlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.WishlistResponse} "Wishlist retrieved successfully"
+// @Failure 400 {object} utils.Response "Invalid wishlist ID or unsupported currency"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist not found"
+// @Router /users/wishlists/{id} [get]
+func (s *Server) getWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+
+	wishlist, err := s.wishlistService.GetWishlist(c.GetUint("user_id"), uint(id), requestCurrency(c))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to fetch wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlist retrieved successfully", wishlist)
+}
+
+// @Summary Rename a wishlist
+// @Description Rename a wishlist of the current user
+// @Tags Wishlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Param request body dto.WishlistRequest true "Wishlist data"
+// @Success 200 {object} utils.Response{data=dto.WishlistResponse} "Wishlist updated successfully"
+// @Failure 400 {object} utils.Response "Invalid request data"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist not found"
+// @Failure 409 {object} utils.Response "Wishlist name already in use"
+// @Router /users/wishlists/{id} [put]
+func (s *Server) updateWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+
+	var req dto.WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	wishlist, err := s.wishlistService.UpdateWishlist(c.GetUint("user_id"), uint(id), &req)
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to update wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlist updated successfully", wishlist)
+}
+
+// @Summary Delete a wishlist
+// @Description Delete a wishlist of the current user, its share link stops working
+// @Tags Wishlists
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Success 200 {object} utils.Response "Wishlist deleted successfully"
+// @Failure 400 {object} utils.Response "Invalid wishlist ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist not found"
+// @Router /users/wishlists/{id} [delete]
+func (s *Server) deleteWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+
+	if err := s.wishlistService.DeleteWishlist(c.GetUint("user_id"), uint(id)); err != nil {
+		s.wishlistErrorResponse(c, "Failed to delete wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlist deleted successfully", nil)
+}
+
+// @Summary Add a product to a wishlist
+// @Description Add a product, or one of its variants, to a wishlist of the current user. A product already on the wishlist is left as it is
+// @Tags Wishlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Param request body dto.AddWishlistItemRequest true "Product to add"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.WishlistResponse} "Item added to wishlist successfully"
+// @Failure 400 {object} utils.Response "Invalid request data or unsupported currency"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist, product or variant not found"
+// @Router /users/wishlists/{id}/items [post]
+func (s *Server) addWishlistItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+
+	var req dto.AddWishlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request data", err)
+		return
+	}
+
+	wishlist, err := s.wishlistService.AddItem(c.GetUint("user_id"), uint(id), &req, requestCurrency(c))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to add item to wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Item added to wishlist successfully", wishlist)
+}
+
+// @Summary Remove a product from a wishlist
+// @Description Remove an item from a wishlist of the current user
+// @Tags Wishlists
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Param item_id path int true "Wishlist item ID"
+// @Success 200 {object} utils.Response "Item removed from wishlist successfully"
+// @Failure 400 {object} utils.Response "Invalid wishlist or item ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Router /users/wishlists/{id}/items/{item_id} [delete]
+func (s *Server) removeWishlistItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist item ID", err)
+		return
+	}
+
+	if err := s.wishlistService.RemoveItem(c.GetUint("user_id"), uint(id), uint(itemID)); err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to remove item from wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Item removed from wishlist successfully", nil)
+}
+
+// @Summary Move a wishlist item to the cart
+// @Description Add a wishlist item to the cart of the current user with the same stock checks as adding it directly, and take it off the wishlist
+// @Tags Wishlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Param item_id path int true "Wishlist item ID"
+// @Param request body dto.MoveToCartRequest false "Quantity to add"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.CartResponse} "Item moved to cart successfully"
+// @Failure 400 {object} utils.Response "Invalid request data, insufficient stock or unsupported currency"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist item not found"
+// @Router /users/wishlists/{id}/items/{item_id}/move-to-cart [post]
+func (s *Server) moveWishlistItemToCart(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist item ID", err)
+		return
+	}
+
+	// The body is optional, one unit is moved without it
+	var req dto.MoveToCartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, "Invalid request data", err)
+			return
+		}
+	}
+
+	cart, err := s.wishlistService.MoveToCart(c.GetUint("user_id"), uint(id), uint(itemID), &req, requestCurrency(c))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to move item to cart", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Item moved to cart successfully", cart)
+}
+
+// @Summary Share a wishlist
+// @Description Turn on the read-only public link of a wishlist of the current user. The link is /api/v1/wishlists/shared/{share_token}
+// @Tags Wishlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Success 200 {object} utils.Response{data=dto.WishlistResponse} "Wishlist shared successfully"
+// @Failure 400 {object} utils.Response "Invalid wishlist ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist not found"
+// @Router /users/wishlists/{id}/share [post]
+func (s *Server) shareWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+
+	wishlist, err := s.wishlistService.ShareWishlist(c.GetUint("user_id"), uint(id))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to share wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlist shared successfully", wishlist)
+}
+
+// @Summary Stop sharing a wishlist
+// @Description Turn off the public link of a wishlist of the current user
+// @Tags Wishlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Wishlist ID"
+// @Success 200 {object} utils.Response{data=dto.WishlistResponse} "Wishlist unshared successfully"
+// @Failure 400 {object} utils.Response "Invalid wishlist ID"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 404 {object} utils.Response "Wishlist not found"
+// @Router /users/wishlists/{id}/share [delete]
+func (s *Server) unshareWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid wishlist ID", err)
+		return
+	}
+
+	wishlist, err := s.wishlistService.UnshareWishlist(c.GetUint("user_id"), uint(id))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to unshare wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlist unshared successfully", wishlist)
+}
+
+// @Summary Get a shared wishlist
+// @Description Retrieve a wishlist through its public link
+// @Tags Wishlists
+// @Produce json
+// @Param token path string true "Share token"
+// @Param currency query string false "ISO 4217 display currency, also accepted as the Accept-Currency header"
+// @Success 200 {object} utils.Response{data=dto.WishlistResponse} "Wishlist retrieved successfully"
+// @Failure 400 {object} utils.Response "Unsupported currency"
+// @Failure 404 {object} utils.Response "Wishlist not found"
+// @Router /wishlists/shared/{token} [get]
+func (s *Server) getSharedWishlist(c *gin.Context) {
+	wishlist, err := s.wishlistService.GetSharedWishlist(c.Param("token"), requestCurrency(c))
+	if err != nil {
+		s.wishlistErrorResponse(c, "Failed to fetch wishlist", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Wishlist retrieved successfully", wishlist)
+}
+
+func (s *Server) wishlistErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Wishlist, item or product not found")
+	case errors.Is(err, services.ErrUnsupportedCurrency), errors.Is(err, services.ErrCannotMoveToCart):
+		utils.BadRequestResponse(c, message, err)
+	case errors.Is(err, services.ErrWishlistNameTaken):
+		utils.ConflictResponse(c, message, err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
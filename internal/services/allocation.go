@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+)
+
+// AllocationStrategy decides which warehouses order items are shipped from
+type AllocationStrategy string
+
+const (
+	// AllocationByPriority prefers the warehouses with the highest priority
+	AllocationByPriority AllocationStrategy = "priority"
+	// AllocationByDistance prefers warehouses in the shipping address's region, then its
+	// country, then falls back to priority
+	AllocationByDistance AllocationStrategy = "closest"
+)
+
+func ParseAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch strategy := AllocationStrategy(strings.ToLower(name)); strategy {
+	case AllocationByPriority, AllocationByDistance:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown warehouse allocation strategy %q", name)
+	}
+}
+
+// rank orders the warehouses by preference for shipping to the destination
+func (strategy AllocationStrategy) rank(warehouses []models.Warehouse, destination models.AddressSnapshot) []models.Warehouse {
+	ranked := append([]models.Warehouse(nil), warehouses...)
+
+	distance := func(w *models.Warehouse) int {
+		if strategy != AllocationByDistance || destination.Country == "" {
+			return 0
+		}
+		switch {
+		case strings.EqualFold(w.Country, destination.Country) && w.Region != "" && strings.EqualFold(w.Region, destination.State):
+			return 0
+		case strings.EqualFold(w.Country, destination.Country):
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if di, dj := distance(&ranked[i]), distance(&ranked[j]); di != dj {
+			return di < dj
+		}
+		if ranked[i].Priority != ranked[j].Priority {
+			return ranked[i].Priority > ranked[j].Priority
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	return ranked
+}
+
+// stockAllocation is a quantity of a product taken from one warehouse
+type stockAllocation struct {
+	WarehouseID uint
+	Quantity    int
+}
+
+// fillFrom takes the quantity from the levels in order, as much as each one has. Levels must
+// be in the order the warehouses are preferred. The result is short when stock runs out.
+func fillFrom(levels []models.StockLevel, quantity int) ([]stockAllocation, int) {
+	var allocations []stockAllocation
+	for i := range levels {
+		if quantity == 0 {
+			break
+		}
+		take := min(levels[i].Stock, quantity)
+		if take <= 0 {
+			continue
+		}
+		allocations = append(allocations, stockAllocation{WarehouseID: levels[i].WarehouseID, Quantity: take})
+		quantity -= take
+	}
+	return allocations, quantity
+}
+
+// returnTo splits units coming back from an order item over the warehouses it was shipped
+// from. Returns fill the allocations in order, so after already units came back earlier the
+// next ones go where the earlier ones stopped. Units no allocation has room for are left over.
+func returnTo(allocations []models.OrderItemAllocation, already, quantity int) ([]stockAllocation, int) {
+	var returned []stockAllocation
+	for i := range allocations {
+		if quantity == 0 {
+			break
+		}
+		room := allocations[i].Quantity - already
+		if room <= 0 {
+			already -= allocations[i].Quantity
+			continue
+		}
+		already = 0
+
+		back := min(room, quantity)
+		returned = append(returned, stockAllocation{WarehouseID: allocations[i].WarehouseID, Quantity: back})
+		quantity -= back
+	}
+	return returned, quantity
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+)
+
+func warehouseIDs(warehouses []models.Warehouse) []uint {
+	ids := make([]uint, len(warehouses))
+	for i := range warehouses {
+		ids[i] = warehouses[i].ID
+	}
+	return ids
+}
+
+func TestAllocationStrategyRank(t *testing.T) {
+	warehouses := []models.Warehouse{
+		{ID: 1, Code: "MAIN", Country: "US", Region: "NY", Priority: 100},
+		{ID: 2, Code: "WEST", Country: "US", Region: "CA", Priority: 10},
+		{ID: 3, Code: "EU", Country: "DE", Priority: 50},
+		{ID: 4, Code: "EAST", Country: "US", Region: "NJ", Priority: 10},
+	}
+	california := models.AddressSnapshot{Country: "US", State: "CA"}
+	germany := models.AddressSnapshot{Country: "DE"}
+
+	tests := []struct {
+		name        string
+		strategy    AllocationStrategy
+		destination models.AddressSnapshot
+		want        []uint
+	}{
+		{"priority ignores the destination", AllocationByPriority, california, []uint{1, 3, 2, 4}},
+		{"closest prefers the region", AllocationByDistance, california, []uint{2, 1, 4, 3}},
+		{"closest prefers the country", AllocationByDistance, germany, []uint{3, 1, 2, 4}},
+		{"closest without a destination falls back to priority", AllocationByDistance, models.AddressSnapshot{}, []uint{1, 3, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, warehouseIDs(tt.strategy.rank(warehouses, tt.destination)))
+		})
+	}
+
+	// The input is left in its original order
+	assert.Equal(t, []uint{1, 2, 3, 4}, warehouseIDs(warehouses))
+}
+
+func TestParseAllocationStrategy(t *testing.T) {
+	strategy, err := ParseAllocationStrategy("Closest")
+	require.NoError(t, err)
+	assert.Equal(t, AllocationByDistance, strategy)
+
+	_, err = ParseAllocationStrategy("random")
+	assert.Error(t, err)
+}
+
+func TestFillFrom(t *testing.T) {
+	levels := []models.StockLevel{
+		{WarehouseID: 1, Stock: 3},
+		{WarehouseID: 2, Stock: 0},
+		{WarehouseID: 3, Stock: 5},
+	}
+
+	allocations, short := fillFrom(levels, 6)
+	assert.Equal(t, []stockAllocation{{WarehouseID: 1, Quantity: 3}, {WarehouseID: 3, Quantity: 3}}, allocations)
+	assert.Zero(t, short)
+
+	allocations, short = fillFrom(levels, 2)
+	assert.Equal(t, []stockAllocation{{WarehouseID: 1, Quantity: 2}}, allocations)
+	assert.Zero(t, short)
+
+	_, short = fillFrom(levels, 10)
+	assert.Equal(t, 2, short)
+}
+
+func TestReturnTo(t *testing.T) {
+	allocations := []models.OrderItemAllocation{
+		{WarehouseID: 1, Quantity: 3},
+		{WarehouseID: 2, Quantity: 2},
+	}
+
+	returned, rest := returnTo(allocations, 0, 4)
+	assert.Equal(t, []stockAllocation{{WarehouseID: 1, Quantity: 3}, {WarehouseID: 2, Quantity: 1}}, returned)
+	assert.Zero(t, rest)
+
+	// A second return continues where the first one stopped
+	returned, rest = returnTo(allocations, 4, 1)
+	assert.Equal(t, []stockAllocation{{WarehouseID: 2, Quantity: 1}}, returned)
+	assert.Zero(t, rest)
+
+	// Items placed before warehouses existed have no allocations
+	returned, rest = returnTo(nil, 0, 2)
+	assert.Empty(t, returned)
+	assert.Equal(t, 2, rest)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/veetmoradiya3628/go-shop/internal/config"
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
 	"github.com/veetmoradiya3628/go-shop/internal/events"
@@ -28,17 +29,20 @@ func NewAuthService(db *gorm.DB, config *config.Config, eventPublisher events.Pu
 	}
 }
 
-func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.AuthResponse, error) {
+// Register creates a customer account. The guest cart with the cart token, if any, becomes
+// the new user's cart. It also reports whether the guest cart was merged, a guest cart that
+// failed to merge is kept for its token.
+func (s *AuthService) Register(req *dto.RegisterRequest, cartToken string) (*dto.AuthResponse, bool, error) {
 	// check if user exists
 	var existingUser models.User
 	if err := s.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("User already exists")
+		return nil, false, errors.New("User already exists")
 	}
 
 	// hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// create user
@@ -51,33 +55,39 @@ func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.AuthResponse, err
 		Role:      models.UserRoleCustomer,
 	}
 	if err := s.db.Create(&user).Error; err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	// create a cart
 	cart := models.Cart{
-		UserID: user.ID,
+		UserID: &user.ID,
 	}
 	if err := s.db.Create(&cart).Error; err != nil {
 		fmt.Println("Unable to create cart")
-		return nil, err
+		return nil, false, err
 	}
+	merged := s.claimGuestCart(user.ID, cartToken)
 
 	// generate token
-	return s.generateAuthResponse(&user)
+	response, err := s.generateAuthResponse(&user)
+	return response, merged, err
 }
 
-func (s *AuthService) Login(req *dto.LoginRequest) (*dto.AuthResponse, error) {
+// Login signs a user in. The guest cart with the cart token, if any, is merged into the
+// user's cart. It also reports whether the guest cart was merged, as Register does.
+func (s *AuthService) Login(req *dto.LoginRequest, cartToken string) (*dto.AuthResponse, bool, error) {
 	// find user by email
 	var user models.User
 	if err := s.db.Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
-		return nil, errors.New("Invalid email or password")
+		return nil, false, errors.New("Invalid email or password")
 	}
 	// check password
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.New("Invalid email or password")
+		return nil, false, errors.New("Invalid email or password")
 	}
+	merged := s.claimGuestCart(user.ID, cartToken)
 	// generate token
-	return s.generateAuthResponse(&user)
+	response, err := s.generateAuthResponse(&user)
+	return response, merged, err
 }
 
 func (s *AuthService) RefreshToken(req *dto.RefreshTokenRequest) (*dto.AuthResponse, error) {
@@ -101,6 +111,23 @@ func (s *AuthService) RefreshToken(req *dto.RefreshTokenRequest) (*dto.AuthRespo
 func (s *AuthService) Logout(refreshToken string) error {
 	return s.db.Where("token = ?", refreshToken).Delete(&models.RefreshToken{}).Error
 }
+
+// claimGuestCart merges the guest cart with the token into the user's cart and reports
+// whether it did. The credentials are checked by then, so a failed merge is logged instead of
+// failing the sign in.
+func (s *AuthService) claimGuestCart(userID uint, cartToken string) bool {
+	if cartToken == "" {
+		return false
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return mergeGuestCart(tx, userID, cartToken)
+	}); err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("failed to merge guest cart")
+		return false
+	}
+	return true
+}
+
 func (s *AuthService) generateAuthResponse(user *models.User) (*dto.AuthResponse, error) {
 	accessToken, refreshToken, err := utils.GenerateTokenPair(&s.config.JWT, user.ID, user.Email, string(user.Role))
 	if err != nil {
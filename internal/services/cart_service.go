@@ -1,143 +1,499 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
 	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CartService struct {
-	db *gorm.DB
+	db               *gorm.DB
+	currencyService  *CurrencyService
+	couponService    *CouponService
+	promotionService *PromotionService
+	taxCalculator    interfaces.TaxCalculator
 }
 
-func NewCartService(db *gorm.DB) *CartService {
-	return &CartService{db: db}
+func NewCartService(db *gorm.DB, currencyService *CurrencyService, couponService *CouponService, promotionService *PromotionService, taxCalculator interfaces.TaxCalculator) *CartService {
+	return &CartService{
+		db:               db,
+		currencyService:  currencyService,
+		couponService:    couponService,
+		promotionService: promotionService,
+		taxCalculator:    taxCalculator,
+	}
+}
+
+// CartOwner identifies a cart: the user's once signed in, otherwise the guest cart with the
+// token
+type CartOwner struct {
+	UserID uint
+	Token  string
+}
+
+// scope limits a query on carts to the owner's cart
+func (o CartOwner) scope(db *gorm.DB) *gorm.DB {
+	if o.UserID != 0 {
+		return db.Where("carts.user_id = ?", o.UserID)
+	}
+	return db.Where("carts.token = ? AND carts.user_id IS NULL", o.Token)
+}
+
+func (s *CartService) GetCart(owner CartOwner, currency string) (*dto.CartResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getCart(owner, display)
+}
+
+func (s *CartService) getCart(owner CartOwner, display displayCurrency) (*dto.CartResponse, error) {
+	var cart models.Cart
+	err := owner.scope(s.db.Preload("CartItems.Product.Category").Preload("CartItems.Variant.OptionValues").
+		Preload("Coupon.Categories").Preload("Coupon.Products")).
+		First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Estimate taxes for the user's default shipping address, checkout uses the chosen one
+	var address models.Address
+	var taxAddress interfaces.TaxAddress
+	if owner.UserID != 0 {
+		if err := s.db.Where("user_id = ? AND is_default_shipping = ?", owner.UserID, true).First(&address).Error; err == nil {
+			taxAddress = taxAddressFor(address.Snapshot())
+		}
+	}
+
+	// Automatic promotions come first, a coupon discounts what is left
+	now := time.Now()
+	promotions, err := s.promotionService.activePromotions(s.db, now)
+	if err != nil {
+		return nil, err
+	}
+	breakdown := newPriceBreakdown(cartPricedLines(&cart))
+	breakdown.applyPromotions(promotions, now)
+
+	// A coupon that stopped applying stays on the cart so the customer sees why
+	var couponErr error
+	if cart.Coupon != nil {
+		couponErr = s.couponService.checkUsage(s.db, cart.Coupon, owner.UserID)
+		if couponErr == nil {
+			couponErr = breakdown.applyCoupon(cart.Coupon, now)
+		}
+		if couponErr != nil && !errors.Is(couponErr, ErrInvalidCoupon) {
+			return nil, couponErr
+		}
+	}
+
+	if err := breakdown.calculateTaxes(s.taxCalculator, taxAddress); err != nil {
+		return nil, err
+	}
+
+	return s.convertToCartResponse(&cart, breakdown, couponErr, display), nil
 }
 
-func (s *CartService) GetCart(userID uint) (*dto.CartResponse, error) {
+// ApplyCoupon puts a discount code on the cart after checking that it currently applies
+func (s *CartService) ApplyCoupon(owner CartOwner, req *dto.ApplyCouponRequest, currency string) (*dto.CartResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
 	var cart models.Cart
-	err := s.db.Preload("CartItems.Product.Category").
-		Where("user_id = ?", userID).First(&cart).Error
+	if err := owner.scope(s.db.Preload("CartItems.Product").Preload("CartItems.Variant")).First(&cart).Error; err != nil {
+		return nil, errors.New("cart not found")
+	}
+
+	coupon, err := s.couponService.findByCode(s.db, req.Code)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.couponService.checkUsage(s.db, coupon, owner.UserID); err != nil {
+		return nil, err
+	}
+	if err := newPriceBreakdown(cartPricedLines(&cart)).applyCoupon(coupon, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&cart).Update("coupon_id", coupon.ID).Error; err != nil {
+		return nil, err
+	}
 
-	return s.convertToCartResponse(&cart), nil
+	return s.getCart(owner, display)
 }
 
-func (s *CartService) AddToCart(userID uint, req *dto.AddToCartRequest) (*dto.CartResponse, error) {
+func (s *CartService) RemoveCoupon(owner CartOwner, currency string) (*dto.CartResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
 
+	if err := owner.scope(s.db.Model(&models.Cart{})).Update("coupon_id", nil).Error; err != nil {
+		return nil, err
+	}
+
+	return s.getCart(owner, display)
+}
+
+// AddToCart adds a product to the owner's cart. A guest without a cart gets a new one, its
+// token is in the response.
+func (s *CartService) AddToCart(owner CartOwner, req *dto.AddToCartRequest, currency string) (*dto.CartResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		owner, err = s.addToCart(tx, owner, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getCart(owner, display)
+}
+
+// addToCart adds a product to the owner's cart in the transaction and returns the owner of
+// the cart it went to, which carries the token of a new guest cart
+func (s *CartService) addToCart(tx *gorm.DB, owner CartOwner, req *dto.AddToCartRequest) (CartOwner, error) {
 	// Check if product exists
 	var product models.Product
-	if err := s.db.First(&product, req.ProductID).Error; err != nil {
-		return nil, errors.New("product not found")
+	if err := tx.First(&product, req.ProductID).Error; err != nil {
+		return owner, errors.New("product not found")
 	}
 
-	if product.Stock < req.Quantity {
-		return nil, errors.New("insufficient stock")
+	variant, err := findVariant(tx, product.ID, req.VariantID)
+	if err != nil || !variant.IsActive {
+		return owner, errors.New("variant not found")
+	}
+
+	if variant.Stock < req.Quantity {
+		return owner, errors.New("insufficient stock")
 	}
 
 	// Get or create cart
 	var cart models.Cart
-	if err := s.db.Where("user_id = ?", userID).First(&cart).Error; err != nil {
-		cart = models.Cart{UserID: userID}
-		if err := s.db.Create(&cart).Error; err != nil {
-			return nil, err
+	if err := owner.scope(tx).First(&cart).Error; err != nil {
+		if owner.UserID != 0 {
+			cart = models.Cart{UserID: &owner.UserID}
+		} else {
+			token := uuid.New().String()
+			cart = models.Cart{Token: &token}
+			owner.Token = token
+		}
+		if err := tx.Create(&cart).Error; err != nil {
+			return owner, err
 		}
+	} else if err := touchCart(tx, cart.ID); err != nil {
+		return owner, err
 	}
 
 	// Check if item already exists in cart
 	var cartItem models.CartItem
-	if err := s.db.Where("cart_id = ? AND product_id = ?", cart.ID, req.ProductID).First(&cartItem).Error; err != nil {
+	if err := tx.Where("cart_id = ? AND variant_id = ?", cart.ID, variant.ID).First(&cartItem).Error; err != nil {
 		// Create new cart item
 		cartItem = models.CartItem{
 			CartID:    cart.ID,
-			ProductID: req.ProductID,
+			ProductID: product.ID,
+			VariantID: variant.ID,
 			Quantity:  req.Quantity,
 		}
-		s.db.Create(&cartItem)
-	} else {
-		// Update existing cart item
-		cartItem.Quantity += req.Quantity
-		if cartItem.Quantity > product.Stock {
-			return nil, errors.New("insufficient stock")
-		}
-		s.db.Save(&cartItem)
+		return owner, tx.Create(&cartItem).Error
 	}
 
-	return s.GetCart(userID)
+	// Update existing cart item
+	cartItem.Quantity += req.Quantity
+	if cartItem.Quantity > variant.Stock {
+		return owner, errors.New("insufficient stock")
+	}
+	return owner, tx.Save(&cartItem).Error
 }
 
-func (s *CartService) UpdateCartItem(userID, itemID uint, req *dto.UpdateCartItemRequest) (*dto.CartResponse, error) {
+func (s *CartService) UpdateCartItem(owner CartOwner, itemID uint, req *dto.UpdateCartItemRequest, currency string) (*dto.CartResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
 	var cartItem models.CartItem
-	if err := s.db.Joins("JOIN carts ON cart_items.cart_id = carts.id").
-		Where("cart_items.id = ? AND carts.user_id = ?", itemID, userID).
+	if err := owner.scope(s.db.Joins("JOIN carts ON cart_items.cart_id = carts.id")).
+		Where("cart_items.id = ?", itemID).
 		First(&cartItem).Error; err != nil {
 		return nil, errors.New("cart item not found")
 	}
 
-	var product models.Product
-	if err := s.db.First(&product, cartItem.ProductID).Error; err != nil {
-		return nil, errors.New("product not found")
+	var variant models.ProductVariant
+	if err := s.db.First(&variant, cartItem.VariantID).Error; err != nil {
+		return nil, errors.New("variant not found")
 	}
 
-	if product.Stock < req.Quantity {
+	if variant.Stock < req.Quantity {
 		return nil, errors.New("insufficient stock")
 	}
 
 	cartItem.Quantity = req.Quantity
-	if err := s.db.Save(&cartItem).Error; err != nil {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&cartItem).Error; err != nil {
+			return err
+		}
+		return touchCart(tx, cartItem.CartID)
+	}); err != nil {
 		return nil, err
 	}
 
-	return s.GetCart(userID)
+	return s.getCart(owner, display)
+}
+
+func (s *CartService) RemoveFromCart(owner CartOwner, itemID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var cart models.Cart
+		if err := owner.scope(tx.Select("carts.id")).First(&cart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Where("id = ? AND cart_id = ?", itemID, cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return touchCart(tx, cart.ID)
+	})
 }
 
-func (s *CartService) RemoveFromCart(userID, itemID uint) error {
-	return s.db.Where("id = ? AND cart_id IN (?)", itemID,
-		s.db.Select("id").Table("carts").
-			Where("user_id = ?", userID)).
-		Delete(&models.CartItem{}).Error
+// touchCart marks a cart as changed now. Every change keeps a guest cart from expiring.
+func touchCart(tx *gorm.DB, cartID uint) error {
+	return tx.Model(&models.Cart{}).Where("id = ?", cartID).Update("updated_at", time.Now()).Error
 }
 
-func (s *CartService) convertToCartResponse(cart *models.Cart) *dto.CartResponse {
+// mergeGuestCart moves the items of the guest cart with the token into the user's cart and
+// deletes the guest cart. Quantities of a variant in both carts are summed, and what is moved
+// is capped by the stock of the variant. Items no longer on sale are not moved. The guest's
+// coupon is kept when the user's cart has none.
+func mergeGuestCart(tx *gorm.DB, userID uint, token string) error {
+	var guest models.Cart
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("CartItems").
+		Where("token = ? AND user_id IS NULL", token).
+		First(&guest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var cart models.Cart
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&cart).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		cart = models.Cart{UserID: &userID}
+		if err := tx.Create(&cart).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, item := range guest.CartItems {
+		// Items of variants or products taken off sale stay behind
+		var variant models.ProductVariant
+		if err := tx.Joins("JOIN products ON products.id = product_variants.product_id AND products.is_active = ? AND products.deleted_at IS NULL", true).
+			Where("product_variants.id = ? AND product_variants.is_active = ?", item.VariantID, true).
+			First(&variant).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+
+		var existing models.CartItem
+		err := tx.Where("cart_id = ? AND variant_id = ?", cart.ID, item.VariantID).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// What the user already had is kept even when it is more than the stock
+		quantity := min(existing.Quantity+item.Quantity, variant.Stock)
+		if quantity <= existing.Quantity {
+			continue
+		}
+		if existing.ID != 0 {
+			if err := tx.Model(&existing).Update("quantity", quantity).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		merged := models.CartItem{CartID: cart.ID, ProductID: item.ProductID, VariantID: item.VariantID, Quantity: quantity}
+		if err := tx.Create(&merged).Error; err != nil {
+			return err
+		}
+	}
+
+	if cart.CouponID == nil && guest.CouponID != nil {
+		if err := tx.Model(&cart).Update("coupon_id", guest.CouponID).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("cart_id = ?", guest.ID).Delete(&models.CartItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&guest).Error
+}
+
+// DeleteExpiredGuestCarts deletes the guest carts that were not changed since before and
+// returns how many were deleted. Their cookie has expired by then, so no guest can reach them.
+func (s *CartService) DeleteExpiredGuestCarts(before time.Time) (int, error) {
+	deleted := 0
+	for {
+		// Items go with their cart through the foreign key
+		result := s.db.Exec(`DELETE FROM carts WHERE id IN (
+			SELECT id FROM carts WHERE user_id IS NULL AND updated_at < ? ORDER BY id LIMIT ?)`,
+			before, sweepBatchSize)
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+
+		deleted += int(result.RowsAffected)
+		if result.RowsAffected < sweepBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// RunGuestCartSweeper deletes guest carts older than the TTL every interval until the
+// context is done
+func (s *CartService) RunGuestCartSweeper(ctx context.Context, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := s.DeleteExpiredGuestCarts(now.Add(-ttl))
+			if err != nil {
+				log.Error().Err(err).Msg("failed to delete expired guest carts")
+				continue
+			}
+			if deleted > 0 {
+				log.Info().Int("deleted", deleted).Msg("deleted expired guest carts")
+			}
+		}
+	}
+}
+
+// cartProductResponse is the product shown with a cart item, with its current price and stock
+func cartProductResponse(product *models.Product, display displayCurrency) dto.ProductResponse {
+	return dto.ProductResponse{
+		ID:            product.ID,
+		CategoryID:    product.CategoryID,
+		Name:          product.Name,
+		Slug:          product.Slug,
+		Description:   product.Description,
+		Price:         display.convert(product.Price),
+		Currency:      display.code,
+		Stock:         product.Stock,
+		SKU:           product.SKU,
+		TaxClass:      product.TaxClass,
+		IsActive:      product.IsActive,
+		AverageRating: product.AverageRating(),
+		ReviewCount:   product.ReviewCount,
+		Category: dto.CategoryResponse{
+			ID:          product.Category.ID,
+			Name:        product.Category.Name,
+			Slug:        product.Category.Slug,
+			Description: product.Category.Description,
+			IsActive:    product.Category.IsActive,
+		},
+	}
+}
+
+func (s *CartService) convertToCartResponse(cart *models.Cart, breakdown *priceBreakdown, couponErr error, display displayCurrency) *dto.CartResponse {
 
 	cartItems := make([]dto.CartItemResponse, len(cart.CartItems)) // memory allocation
-	var total float64
+	subtotal := money.Zero(display.code)
 
 	for i := range cart.CartItems {
-		subtotal := float64(cart.CartItems[i].Quantity) * cart.CartItems[i].Product.Price
-		total += subtotal
+		price := display.convert(cart.CartItems[i].Variant.UnitPrice(&cart.CartItems[i].Product))
+		lineTotal := price.Mul(cart.CartItems[i].Quantity)
+		subtotal = subtotal.Add(lineTotal)
 
 		cartItems[i] = dto.CartItemResponse{
-			ID: cart.CartItems[i].ID,
-			Product: dto.ProductResponse{
-				ID:          cart.CartItems[i].Product.ID,
-				CategoryID:  cart.CartItems[i].Product.CategoryID,
-				Name:        cart.CartItems[i].Product.Name,
-				Description: cart.CartItems[i].Product.Description,
-				Price:       cart.CartItems[i].Product.Price,
-				Stock:       cart.CartItems[i].Product.Stock,
-				SKU:         cart.CartItems[i].Product.SKU,
-				IsActive:    cart.CartItems[i].Product.IsActive,
-				Category: dto.CategoryResponse{
-					ID:          cart.CartItems[i].Product.Category.ID,
-					Name:        cart.CartItems[i].Product.Category.Name,
-					Description: cart.CartItems[i].Product.Category.Description,
-					IsActive:    cart.CartItems[i].Product.Category.IsActive,
-				},
-			},
-			Quantity: cart.CartItems[i].Quantity,
-			Subtotal: subtotal,
-		}
-	}
-
-	return &dto.CartResponse{
-		ID:        cart.ID,
-		UserID:    cart.UserID,
-		CartItems: cartItems,
-		Total:     total,
+			ID:          cart.CartItems[i].ID,
+			Product:     cartProductResponse(&cart.CartItems[i].Product, display),
+			VariantID:   cart.CartItems[i].VariantID,
+			SKU:         cart.CartItems[i].Variant.SKU,
+			VariantName: cart.CartItems[i].Variant.Name(),
+			Price:       price,
+			Quantity:    cart.CartItems[i].Quantity,
+			Subtotal:    lineTotal,
+		}
+	}
+
+	discounts := make([]dto.DiscountLineResponse, len(breakdown.Discounts))
+	discountTotal := money.Zero(display.code)
+	for i, discount := range breakdown.Discounts {
+		discounts[i] = dto.DiscountLineResponse{
+			Code:          discount.Code,
+			PromotionID:   discount.PromotionID,
+			PromotionName: discount.PromotionName,
+			Description:   discount.Description,
+			Amount:        display.convert(discount.Amount),
+		}
+		discountTotal = discountTotal.Add(discounts[i].Amount)
+	}
+
+	taxLines := make([]dto.TaxLineResponse, len(breakdown.Taxes.Lines))
+	taxTotal := money.Zero(display.code)
+	total := subtotal.Sub(discountTotal)
+	for i, line := range breakdown.Taxes.Lines {
+		taxLines[i] = dto.TaxLineResponse{
+			Name:          line.Name,
+			Rate:          line.Rate,
+			Inclusive:     line.Inclusive,
+			TaxableAmount: display.convert(line.TaxableAmount),
+			Amount:        display.convert(line.Amount),
+		}
+		taxTotal = taxTotal.Add(taxLines[i].Amount)
+		if !line.Inclusive {
+			total = total.Add(taxLines[i].Amount)
+		}
+	}
+
+	response := &dto.CartResponse{
+		ID:            cart.ID,
+		CartItems:     cartItems,
+		Subtotal:      subtotal,
+		Discounts:     discounts,
+		DiscountTotal: discountTotal,
+		FreeShipping:  breakdown.FreeShipping,
+		TaxLines:      taxLines,
+		TaxTotal:      taxTotal,
+		Total:         total,
+		Currency:      display.code,
+	}
+	if cart.UserID != nil {
+		response.UserID = *cart.UserID
+	} else if cart.Token != nil {
+		response.Token = *cart.Token
+	}
+	if cart.Coupon != nil {
+		response.CouponCode = cart.Coupon.Code
+	}
+	if couponErr != nil {
+		response.CouponError = couponErr.Error()
 	}
+	return response
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/providers"
+)
+
+func TestMergeGuestCartSumsQuantitiesCappedByStock(t *testing.T) {
+	reservations := newTestReservationService(t)
+	db := reservations.db
+	service := NewCartService(db, NewCurrencyService(db), NewCouponService(db), NewPromotionService(db), providers.NewTableTaxCalculator(nil))
+
+	user := models.User{Email: "guest@example.com", Password: "secret", FirstName: "Test", LastName: "Shopper"}
+	require.NoError(t, db.Create(&user).Error)
+	shared := createTestProduct(t, reservations, 5)
+	guestOnly := createTestProduct(t, reservations, 1)
+
+	owner := CartOwner{UserID: user.ID}
+	_, err := service.AddToCart(owner, &dto.AddToCartRequest{ProductID: shared.ID, Quantity: 3}, "")
+	require.NoError(t, err)
+
+	// A guest gets a cart and its token on the first item
+	guestCart, err := service.AddToCart(CartOwner{}, &dto.AddToCartRequest{ProductID: shared.ID, Quantity: 4}, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, guestCart.Token)
+	guest := CartOwner{Token: guestCart.Token}
+	_, err = service.AddToCart(guest, &dto.AddToCartRequest{ProductID: guestOnly.ID, Quantity: 1}, "")
+	require.NoError(t, err)
+
+	withdrawn := createTestProduct(t, reservations, 5)
+	_, err = service.AddToCart(guest, &dto.AddToCartRequest{ProductID: withdrawn.ID, Quantity: 1}, "")
+	require.NoError(t, err)
+
+	// The stock of the guest-only product runs out and the other one is taken off sale before
+	// the guest signs in
+	require.NoError(t, db.Model(&models.ProductVariant{}).Where("id = ?", guestOnly.Variants[0].ID).Update("stock", 0).Error)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", withdrawn.ID).Update("is_active", false).Error)
+
+	require.NoError(t, mergeGuestCart(db, user.ID, guest.Token))
+
+	cart, err := service.GetCart(owner, "")
+	require.NoError(t, err)
+	require.Len(t, cart.CartItems, 1)
+	assert.Equal(t, shared.ID, cart.CartItems[0].Product.ID)
+	assert.Equal(t, 5, cart.CartItems[0].Quantity)
+
+	// The guest cart is gone
+	_, err = service.GetCart(guest, "")
+	assert.Error(t, err)
+}
+
+func TestDeleteExpiredGuestCartsKeepsRecentAndUserCarts(t *testing.T) {
+	reservations := newTestReservationService(t)
+	db := reservations.db
+	service := NewCartService(db, NewCurrencyService(db), NewCouponService(db), NewPromotionService(db), providers.NewTableTaxCalculator(nil))
+
+	user := models.User{Email: "sweeper@example.com", Password: "secret", FirstName: "Test", LastName: "Shopper"}
+	require.NoError(t, db.Create(&user).Error)
+	product := createTestProduct(t, reservations, 5)
+
+	_, err := service.AddToCart(CartOwner{UserID: user.ID}, &dto.AddToCartRequest{ProductID: product.ID, Quantity: 1}, "")
+	require.NoError(t, err)
+	stale, err := service.AddToCart(CartOwner{}, &dto.AddToCartRequest{ProductID: product.ID, Quantity: 1}, "")
+	require.NoError(t, err)
+	recent, err := service.AddToCart(CartOwner{}, &dto.AddToCartRequest{ProductID: product.ID, Quantity: 1}, "")
+	require.NoError(t, err)
+	edited, err := service.AddToCart(CartOwner{}, &dto.AddToCartRequest{ProductID: product.ID, Quantity: 1}, "")
+	require.NoError(t, err)
+
+	// The stale and the edited guest cart and the user's cart were last changed a month ago
+	monthAgo := time.Now().AddDate(0, -1, 0)
+	require.NoError(t, db.Model(&models.Cart{}).Where("token IN ? OR user_id = ?", []string{stale.Token, edited.Token}, user.ID).
+		UpdateColumn("updated_at", monthAgo).Error)
+
+	// Changing a quantity counts as a change like adding does
+	_, err = service.UpdateCartItem(CartOwner{Token: edited.Token}, edited.CartItems[0].ID, &dto.UpdateCartItemRequest{Quantity: 2}, "")
+	require.NoError(t, err)
+
+	deleted, err := service.DeleteExpiredGuestCarts(time.Now().Add(-7 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = service.GetCart(CartOwner{Token: stale.Token}, "")
+	assert.Error(t, err)
+	_, err = service.GetCart(CartOwner{Token: recent.Token}, "")
+	assert.NoError(t, err)
+	_, err = service.GetCart(CartOwner{Token: edited.Token}, "")
+	assert.NoError(t, err)
+	_, err = service.GetCart(CartOwner{UserID: user.ID}, "")
+	assert.NoError(t, err)
+}
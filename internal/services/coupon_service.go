@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCouponRequest = errors.New("invalid coupon")
+	ErrCouponCodeTaken      = errors.New("coupon code is already in use")
+)
+
+type CouponService struct {
+	db *gorm.DB
+}
+
+func NewCouponService(db *gorm.DB) *CouponService {
+	return &CouponService{db: db}
+}
+
+func (s *CouponService) GetCoupons() ([]dto.CouponResponse, error) {
+	var coupons []models.Coupon
+	if err := s.db.Preload("Categories").Preload("Products").
+		Order("created_at DESC").Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.CouponResponse, len(coupons))
+	for i := range coupons {
+		response[i] = s.convertToCouponResponse(&coupons[i])
+	}
+	return response, nil
+}
+
+func (s *CouponService) CreateCoupon(req *dto.CouponRequest) (*dto.CouponResponse, error) {
+	coupon := models.Coupon{IsActive: true}
+	if err := s.applyCouponRequest(s.db, &coupon, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&coupon).Error; err != nil {
+		return nil, err
+	}
+	// gorm skips false on create because of the column default
+	if !coupon.IsActive {
+		if err := s.db.Model(&coupon).Update("is_active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.getCoupon(coupon.ID)
+}
+
+func (s *CouponService) UpdateCoupon(id uint, req *dto.CouponRequest) (*dto.CouponResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		if err := tx.First(&coupon, id).Error; err != nil {
+			return err
+		}
+
+		if err := s.applyCouponRequest(tx, &coupon, req); err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Categories", "Products").Save(&coupon).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&coupon).Association("Categories").Replace(coupon.Categories); err != nil {
+			return err
+		}
+		return tx.Model(&coupon).Association("Products").Replace(coupon.Products)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getCoupon(id)
+}
+
+func (s *CouponService) DeleteCoupon(id uint) error {
+	result := s.db.Delete(&models.Coupon{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	// Carts holding the code simply lose it
+	return s.db.Model(&models.Cart{}).Where("coupon_id = ?", id).Update("coupon_id", nil).Error
+}
+
+func (s *CouponService) getCoupon(id uint) (*dto.CouponResponse, error) {
+	var coupon models.Coupon
+	if err := s.db.Preload("Categories").Preload("Products").First(&coupon, id).Error; err != nil {
+		return nil, err
+	}
+
+	response := s.convertToCouponResponse(&coupon)
+	return &response, nil
+}
+
+func (s *CouponService) applyCouponRequest(tx *gorm.DB, coupon *models.Coupon, req *dto.CouponRequest) error {
+	couponType := models.CouponType(req.Type)
+	switch {
+	case couponType == models.CouponTypePercentage && req.PercentOff.IsZero():
+		return fmt.Errorf("%w: percentage coupons need percent_off", ErrInvalidCouponRequest)
+	case couponType == models.CouponTypeFixed && !req.AmountOff.IsPositive():
+		return fmt.Errorf("%w: fixed coupons need amount_off", ErrInvalidCouponRequest)
+	case req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCouponRequest)
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	var taken int64
+	if err := tx.Unscoped().Model(&models.Coupon{}).
+		Where("code = ? AND id <> ?", code, coupon.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrCouponCodeTaken
+	}
+
+	coupon.Code = code
+	coupon.Description = req.Description
+	coupon.Type = couponType
+	coupon.PercentOff = money.MustParseRate("0")
+	coupon.AmountOff = money.Zero(money.BaseCurrency)
+	switch couponType {
+	case models.CouponTypePercentage:
+		coupon.PercentOff = req.PercentOff
+	case models.CouponTypeFixed:
+		coupon.AmountOff = req.AmountOff
+	}
+	coupon.MinCartValue = req.MinCartValue
+	coupon.UsageLimit = req.UsageLimit
+	coupon.PerUserLimit = req.PerUserLimit
+	coupon.StartsAt = req.StartsAt
+	coupon.EndsAt = req.EndsAt
+	if req.IsActive != nil {
+		coupon.IsActive = *req.IsActive
+	}
+
+	coupon.Categories = nil
+	if len(req.CategoryIDs) > 0 {
+		if err := tx.Find(&coupon.Categories, req.CategoryIDs).Error; err != nil {
+			return err
+		}
+		if len(coupon.Categories) != len(req.CategoryIDs) {
+			return fmt.Errorf("%w: unknown category in category_ids", ErrInvalidCouponRequest)
+		}
+	}
+
+	coupon.Products = nil
+	if len(req.ProductIDs) > 0 {
+		if err := tx.Find(&coupon.Products, req.ProductIDs).Error; err != nil {
+			return err
+		}
+		if len(coupon.Products) != len(req.ProductIDs) {
+			return fmt.Errorf("%w: unknown product in product_ids", ErrInvalidCouponRequest)
+		}
+	}
+
+	return nil
+}
+
+// findByCode loads a coupon with its restrictions by its case-insensitive code
+func (s *CouponService) findByCode(tx *gorm.DB, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := tx.Preload("Categories").Preload("Products").
+		Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).
+		First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: unknown code", ErrInvalidCoupon)
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// checkUsage verifies the global and per-user usage limits of a coupon
+func (s *CouponService) checkUsage(tx *gorm.DB, coupon *models.Coupon, userID uint) error {
+	if coupon.UsageLimit != nil && coupon.UsedCount >= *coupon.UsageLimit {
+		return fmt.Errorf("%w: coupon has been fully redeemed", ErrInvalidCoupon)
+	}
+
+	if coupon.PerUserLimit != nil {
+		var used int64
+		if err := tx.Model(&models.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, userID).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(*coupon.PerUserLimit) {
+			return fmt.Errorf("%w: you have already used this coupon", ErrInvalidCoupon)
+		}
+	}
+
+	return nil
+}
+
+// lockForRedemption reloads the coupon with a row lock, so concurrent checkouts using the
+// same code are serialized and the usage limits hold.
+func (s *CouponService) lockForRedemption(tx *gorm.DB, couponID uint) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, couponID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: coupon no longer exists", ErrInvalidCoupon)
+		}
+		return nil, err
+	}
+	if err := tx.Model(&coupon).Association("Categories").Find(&coupon.Categories); err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&coupon).Association("Products").Find(&coupon.Products); err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// redeem records the coupon's use by an order. It must run in the order transaction after
+// lockForRedemption and checkUsage.
+func (s *CouponService) redeem(tx *gorm.DB, coupon *models.Coupon, userID, orderID uint, amount money.Money) error {
+	if err := tx.Model(coupon).Update("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.CouponRedemption{
+		CouponID: coupon.ID,
+		UserID:   userID,
+		OrderID:  orderID,
+		Amount:   amount,
+	}).Error
+}
+
+// release gives back the coupon redeemed by a cancelled order
+func (s *CouponService) release(tx *gorm.DB, orderID uint) error {
+	var redemption models.CouponRedemption
+	if err := tx.Where("order_id = ?", orderID).First(&redemption).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.Model(&models.Coupon{}).Where("id = ? AND used_count > 0", redemption.CouponID).
+		Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&redemption).Error
+}
+
+func (s *CouponService) convertToCouponResponse(coupon *models.Coupon) dto.CouponResponse {
+	categoryIDs := make([]uint, len(coupon.Categories))
+	for i := range coupon.Categories {
+		categoryIDs[i] = coupon.Categories[i].ID
+	}
+	productIDs := make([]uint, len(coupon.Products))
+	for i := range coupon.Products {
+		productIDs[i] = coupon.Products[i].ID
+	}
+
+	return dto.CouponResponse{
+		ID:           coupon.ID,
+		Code:         coupon.Code,
+		Description:  coupon.Description,
+		Type:         string(coupon.Type),
+		PercentOff:   coupon.PercentOff,
+		AmountOff:    coupon.AmountOff,
+		MinCartValue: coupon.MinCartValue,
+		UsageLimit:   coupon.UsageLimit,
+		PerUserLimit: coupon.PerUserLimit,
+		UsedCount:    coupon.UsedCount,
+		StartsAt:     coupon.StartsAt,
+		EndsAt:       coupon.EndsAt,
+		IsActive:     coupon.IsActive,
+		CategoryIDs:  categoryIDs,
+		ProductIDs:   productIDs,
+		CreatedAt:    coupon.CreatedAt.Format(defaultDateFormat),
+	}
+}
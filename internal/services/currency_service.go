@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrBaseCurrencyRate    = errors.New("the base currency has a fixed rate of 1")
+)
+
+// displayCurrency converts base currency amounts for a response
+type displayCurrency struct {
+	code string
+	rate money.Rate
+}
+
+func (d displayCurrency) convert(amount money.Money) money.Money {
+	return amount.Convert(d.code, d.rate)
+}
+
+// orderCurrency returns the currency and rate recorded on an order at checkout
+func orderCurrency(order *models.Order) displayCurrency {
+	if order.Currency == "" {
+		return displayCurrency{code: money.BaseCurrency}
+	}
+	return displayCurrency{code: order.Currency, rate: order.ExchangeRate}
+}
+
+type CurrencyService struct {
+	db *gorm.DB
+}
+
+func NewCurrencyService(db *gorm.DB) *CurrencyService {
+	return &CurrencyService{db: db}
+}
+
+func (s *CurrencyService) GetExchangeRates() ([]dto.ExchangeRateResponse, error) {
+	var rates []models.ExchangeRate
+	if err := s.db.Order("currency").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.ExchangeRateResponse, len(rates))
+	for i := range rates {
+		response[i] = s.convertToExchangeRateResponse(&rates[i])
+	}
+	return response, nil
+}
+
+// SetExchangeRate creates or replaces the rate for a currency
+func (s *CurrencyService) SetExchangeRate(req *dto.SetExchangeRateRequest) (*dto.ExchangeRateResponse, error) {
+	currency := strings.ToUpper(req.Currency)
+	if currency == money.BaseCurrency {
+		return nil, ErrBaseCurrencyRate
+	}
+
+	rate := models.ExchangeRate{Currency: currency, Rate: req.Rate}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&rate).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Where("currency = ?", currency).First(&rate).Error; err != nil {
+		return nil, err
+	}
+
+	response := s.convertToExchangeRateResponse(&rate)
+	return &response, nil
+}
+
+func (s *CurrencyService) DeleteExchangeRate(currency string) error {
+	result := s.db.Where("currency = ?", strings.ToUpper(currency)).Delete(&models.ExchangeRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// displayCurrency resolves the currency a client asked for. An empty code means the base currency.
+func (s *CurrencyService) displayCurrency(currency string) (displayCurrency, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == money.BaseCurrency {
+		return displayCurrency{code: money.BaseCurrency}, nil
+	}
+
+	var rate models.ExchangeRate
+	if err := s.db.Where("currency = ?", currency).First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return displayCurrency{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+		return displayCurrency{}, err
+	}
+	return displayCurrency{code: currency, rate: rate.Rate}, nil
+}
+
+func (s *CurrencyService) convertToExchangeRateResponse(rate *models.ExchangeRate) dto.ExchangeRateResponse {
+	return dto.ExchangeRateResponse{
+		Currency:  rate.Currency,
+		Rate:      rate.Rate,
+		UpdatedAt: rate.UpdatedAt.Format(defaultDateFormat),
+	}
+}
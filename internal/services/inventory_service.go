@@ -0,0 +1,703 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/events"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/notifications"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock      = errors.New("insufficient stock")
+	ErrNoWarehouse            = errors.New("no active warehouse")
+	ErrInvalidStockAdjustment = errors.New("invalid stock adjustment")
+)
+
+// movement says why stock changes, for the ledger. Restocked, when set, collects the back in
+// stock notifications the stock put back makes due.
+type movement struct {
+	Reason      models.MovementReason
+	ReferenceID *uint
+	ActorID     *uint
+	Note        string
+	Restocked   *backInStockEvents
+}
+
+// backInStockEvents are back in stock notifications to publish once the transaction that
+// made them due commits
+type backInStockEvents []*notifications.BackInStockEvent
+
+func (e *backInStockEvents) add(event *notifications.BackInStockEvent) {
+	if event != nil {
+		*e = append(*e, event)
+	}
+}
+
+// movementFor builds a movement, zero IDs meaning no reference or no actor
+func movementFor(reason models.MovementReason, referenceID, actorID uint) movement {
+	m := movement{Reason: reason}
+	if referenceID != 0 {
+		m.ReferenceID = &referenceID
+	}
+	if actorID != 0 {
+		m.ActorID = &actorID
+	}
+	return m
+}
+
+// InventoryService is the only writer of stock. Stock is kept per warehouse in stock_levels,
+// Product.Stock caches the sum, and both change in the same statement sequence as the
+// inventory_movements ledger entry that explains the change.
+type InventoryService struct {
+	db             *gorm.DB
+	eventPublisher events.Publisher
+	strategy       AllocationStrategy
+}
+
+func NewInventoryService(db *gorm.DB, eventPublisher events.Publisher, strategy AllocationStrategy) *InventoryService {
+	return &InventoryService{db: db, eventPublisher: eventPublisher, strategy: strategy}
+}
+
+// GetInventoryHistory lists the stock movements of a product, most recent first
+func (s *InventoryService) GetInventoryHistory(productID uint, page, limit int) ([]dto.InventoryMovementResponse, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	// History outlives the product, so deleted products can still be inspected
+	var product models.Product
+	if err := s.db.Unscoped().Select("id").First(&product, productID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var total int64
+	if err := s.db.Model(&models.InventoryMovement{}).Where("product_id = ?", productID).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var movements []models.InventoryMovement
+	if err := s.db.Where("product_id = ?", productID).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&movements).Error; err != nil {
+		return nil, nil, err
+	}
+
+	response := make([]dto.InventoryMovementResponse, len(movements))
+	for i := range movements {
+		response[i] = dto.InventoryMovementResponse{
+			ID:          movements[i].ID,
+			ProductID:   movements[i].ProductID,
+			WarehouseID: movements[i].WarehouseID,
+			Delta:       movements[i].Delta,
+			Reason:      string(movements[i].Reason),
+			ReferenceID: movements[i].ReferenceID,
+			ActorID:     movements[i].ActorID,
+			Note:        movements[i].Note,
+			CreatedAt:   movements[i].CreatedAt.Format(defaultDateFormat),
+		}
+	}
+	meta := &utils.PaginationMeta{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+	return response, meta, nil
+}
+
+// Reconcile returns the products whose stock differs from the sum of their ledger, of their
+// warehouse stock levels or of their variants' stock. The list is empty as long as nothing writes stock behind
+// the InventoryService.
+func (s *InventoryService) Reconcile() ([]dto.StockDiscrepancyResponse, error) {
+	var rows []struct {
+		ProductID      uint
+		Name           string
+		SKU            string
+		Stock          int
+		LedgerStock    int
+		WarehouseStock int
+		VariantStock   int
+	}
+
+	totals := s.db.Table("products").Select(`products.id AS product_id, products.name, products.sku, products.stock,
+		COALESCE((SELECT SUM(delta) FROM inventory_movements WHERE inventory_movements.product_id = products.id), 0) AS ledger_stock,
+		COALESCE((SELECT SUM(stock) FROM stock_levels WHERE stock_levels.product_id = products.id), 0) AS warehouse_stock,
+		COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_variants.product_id = products.id), 0) AS variant_stock`)
+
+	if err := s.db.Table("(?) AS totals", totals).
+		Where("stock <> ledger_stock OR stock <> warehouse_stock OR stock <> variant_stock").
+		Order("product_id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.StockDiscrepancyResponse, len(rows))
+	for i, row := range rows {
+		response[i] = dto.StockDiscrepancyResponse{
+			ProductID:      row.ProductID,
+			ProductName:    row.Name,
+			SKU:            row.SKU,
+			Stock:          row.Stock,
+			LedgerStock:    row.LedgerStock,
+			WarehouseStock: row.WarehouseStock,
+			VariantStock:   row.VariantStock,
+			Difference:     row.Stock - row.LedgerStock,
+		}
+	}
+	return response, nil
+}
+
+// GetStockLevels lists a product's stock per variant and warehouse
+func (s *InventoryService) GetStockLevels(productID uint) ([]dto.StockLevelResponse, error) {
+	var product models.Product
+	if err := s.db.Select("id").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	return s.stockLevels(s.db, productID)
+}
+
+// SetStockLevel corrects a variant's stock in one warehouse, booked as a manual adjustment.
+// Without a variant the product's default variant is corrected.
+func (s *InventoryService) SetStockLevel(productID, warehouseID uint, req *dto.SetStockLevelRequest, actorID uint) ([]dto.StockLevelResponse, error) {
+	var response []dto.StockLevelResponse
+	var restocked *notifications.BackInStockEvent
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&product, productID).Error; err != nil {
+			return err
+		}
+		variant, err := findVariant(tx, productID, req.VariantID)
+		if err != nil {
+			return err
+		}
+		var warehouse models.Warehouse
+		if err := tx.First(&warehouse, warehouseID).Error; err != nil {
+			return err
+		}
+		// Inactive warehouses hold no stock, so Product.Stock stays what can be sold
+		if !warehouse.IsActive && req.Stock > 0 {
+			return fmt.Errorf("%w: warehouse %s is not active", ErrInvalidStockAdjustment, warehouse.Code)
+		}
+
+		m := movementFor(models.MovementReasonAdjustment, 0, actorID)
+		m.Note = req.Note
+		if err := s.set(tx, variant, warehouseID, req.Stock, m); err != nil {
+			return err
+		}
+
+		if restocked, err = s.backInStock(tx, productID, product.Stock); err != nil {
+			return err
+		}
+		response, err = s.stockLevels(tx, productID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishBackInStock(restocked)
+
+	return response, nil
+}
+
+// TransferStock moves stock of a product variant from one warehouse to another. Without a
+// variant the product's default variant is moved.
+func (s *InventoryService) TransferStock(req *dto.StockTransferRequest, actorID uint) ([]dto.StockLevelResponse, error) {
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return nil, fmt.Errorf("%w: source and destination warehouse are the same", ErrInvalidStockAdjustment)
+	}
+
+	var response []dto.StockLevelResponse
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.lockProducts(tx, []uint{req.ProductID}); err != nil {
+			return err
+		}
+		variant, err := findVariant(tx, req.ProductID, req.VariantID)
+		if err != nil {
+			return err
+		}
+
+		var warehouses []models.Warehouse
+		if err := tx.Find(&warehouses, []uint{req.FromWarehouseID, req.ToWarehouseID}).Error; err != nil {
+			return err
+		}
+		if len(warehouses) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+		codes := make(map[uint]string, len(warehouses))
+		for i := range warehouses {
+			codes[warehouses[i].ID] = warehouses[i].Code
+			if warehouses[i].ID == req.ToWarehouseID && !warehouses[i].IsActive {
+				return fmt.Errorf("%w: warehouse %s is not active", ErrInvalidStockAdjustment, warehouses[i].Code)
+			}
+		}
+
+		out := movementFor(models.MovementReasonTransfer, 0, actorID)
+		out.Note = "to " + codes[req.ToWarehouseID]
+		if err := s.take(tx, variant, req.FromWarehouseID, req.Quantity, out); err != nil {
+			return err
+		}
+
+		in := movementFor(models.MovementReasonTransfer, 0, actorID)
+		in.Note = "from " + codes[req.FromWarehouseID]
+		if err := s.put(tx, variant, req.ToWarehouseID, req.Quantity, in); err != nil {
+			return err
+		}
+
+		response, err = s.stockLevels(tx, variant.ProductID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// rankWarehouses returns the active warehouses in the order items shipped to the
+// destination should be taken from
+func (s *InventoryService) rankWarehouses(tx *gorm.DB, destination models.AddressSnapshot) ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	if err := tx.Where("is_active = ?", true).Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouse
+	}
+	return s.strategy.rank(warehouses, destination), nil
+}
+
+// primaryWarehouse is the warehouse stock goes to when no other one is implied
+func (s *InventoryService) primaryWarehouse(tx *gorm.DB) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := tx.Where("is_active = ?", true).Order("priority DESC, id ASC").First(&warehouse).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoWarehouse
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// plan works out which warehouses the quantity of a variant comes from, following the
+// ranked warehouses. The stock level rows stay locked until the transaction ends, so the
+// planned quantities can then be taken.
+func (s *InventoryService) plan(tx *gorm.DB, variant *models.ProductVariant, quantity int, ranked []models.Warehouse) ([]stockAllocation, error) {
+	warehouseIDs := make([]uint, len(ranked))
+	position := make(map[uint]int, len(ranked))
+	for i := range ranked {
+		warehouseIDs[i] = ranked[i].ID
+		position[ranked[i].ID] = i
+	}
+
+	// The variant's rows are locked in id order whatever the ranking. Across variants and
+	// products the caller keeps the order, see lockProducts.
+	var levels []models.StockLevel
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("variant_id = ? AND warehouse_id IN ?", variant.ID, warehouseIDs).
+		Order("id ASC").
+		Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		return position[levels[i].WarehouseID] < position[levels[j].WarehouseID]
+	})
+
+	allocations, short := fillFrom(levels, quantity)
+	if short > 0 {
+		return nil, insufficientStock(variant)
+	}
+	return allocations, nil
+}
+
+// lockProducts locks the rows of the products stock is about to move for, in id order, and
+// returns their stock before it moves. take and put lock a product's row only after the stock
+// level and variant rows they change, so two transactions moving stock of the same products
+// could each end up holding a row the other waits for. Transactions moving stock of several
+// variants therefore lock the products first and then move the variants in variant id order.
+func (s *InventoryService) lockProducts(tx *gorm.DB, productIDs []uint) (map[uint]int, error) {
+	var products []models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "stock").
+		Where("id IN ?", productIDs).
+		Order("id ASC").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	stock := make(map[uint]int, len(products))
+	for i := range products {
+		stock[products[i].ID] = products[i].Stock
+	}
+	return stock, nil
+}
+
+// takeAllocated takes the quantity of a variant from the ranked warehouses
+func (s *InventoryService) takeAllocated(tx *gorm.DB, variant *models.ProductVariant, quantity int, ranked []models.Warehouse, m movement) ([]stockAllocation, error) {
+	allocations, err := s.plan(tx, variant, quantity, ranked)
+	if err != nil {
+		return nil, err
+	}
+	for _, allocation := range allocations {
+		if err := s.take(tx, variant, allocation.WarehouseID, allocation.Quantity, m); err != nil {
+			return nil, err
+		}
+	}
+	return allocations, nil
+}
+
+// returnItem puts units of an order item back in the warehouses they were shipped from.
+// Items placed before warehouses existed go back to the primary warehouse.
+func (s *InventoryService) returnItem(tx *gorm.DB, item *models.OrderItem, already, quantity int, m movement) error {
+	returned, rest := returnTo(item.Allocations, already, quantity)
+	if rest > 0 {
+		warehouse, err := s.primaryWarehouse(tx)
+		if err != nil {
+			return err
+		}
+		returned = append(returned, stockAllocation{WarehouseID: warehouse.ID, Quantity: rest})
+	}
+
+	variant := &models.ProductVariant{ID: item.VariantID, ProductID: item.ProductID}
+	for _, allocation := range returned {
+		if err := s.put(tx, variant, allocation.WarehouseID, allocation.Quantity, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// take removes stock of a variant from a warehouse only if enough is left there. The check
+// and the write are one statement, so there is no window for a concurrent checkout to
+// oversell.
+func (s *InventoryService) take(tx *gorm.DB, variant *models.ProductVariant, warehouseID uint, quantity int, m movement) error {
+	result := tx.Model(&models.StockLevel{}).
+		Where("warehouse_id = ? AND variant_id = ? AND stock >= ?", warehouseID, variant.ID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return insufficientStock(variant)
+	}
+
+	if err := s.adjustTotals(tx, variant, -quantity); err != nil {
+		return err
+	}
+	return s.record(tx, variant, warehouseID, -quantity, m)
+}
+
+// put adds stock of a variant to a warehouse
+func (s *InventoryService) put(tx *gorm.DB, variant *models.ProductVariant, warehouseID uint, quantity int, m movement) error {
+	level := models.StockLevel{WarehouseID: warehouseID, ProductID: variant.ProductID, VariantID: variant.ID, Stock: quantity}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "warehouse_id"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"stock":      gorm.Expr("stock_levels.stock + ?", quantity),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&level).Error; err != nil {
+		return err
+	}
+
+	if err := s.adjustTotals(tx, variant, quantity); err != nil {
+		return err
+	}
+
+	// Stock held by a checkout coming back is not a restock, the alert stays as it was and
+	// nobody is told the product is back
+	if m.Reason != models.MovementReasonReservationRelease {
+		if err := s.settleStockAlert(tx, variant.ProductID); err != nil {
+			return err
+		}
+		if m.Restocked != nil {
+			var product models.Product
+			if err := tx.Select("id", "stock").First(&product, variant.ProductID).Error; err != nil {
+				return err
+			}
+			event, err := s.backInStock(tx, variant.ProductID, product.Stock-quantity)
+			if err != nil {
+				return err
+			}
+			m.Restocked.add(event)
+		}
+	}
+	return s.record(tx, variant, warehouseID, quantity, m)
+}
+
+// set moves a variant's stock in a warehouse to an absolute count, recording the difference
+func (s *InventoryService) set(tx *gorm.DB, variant *models.ProductVariant, warehouseID uint, stock int, m movement) error {
+	var level models.StockLevel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND variant_id = ?", warehouseID, variant.ID).
+		First(&level).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	delta := stock - level.Stock
+	if delta > 0 {
+		return s.put(tx, variant, warehouseID, delta, m)
+	}
+	if delta < 0 {
+		return s.take(tx, variant, warehouseID, -delta, m)
+	}
+	return nil
+}
+
+// setTotal moves a variant's total stock to an absolute count by adjusting its stock in the
+// primary warehouse. Stock held elsewhere has to be corrected per warehouse.
+func (s *InventoryService) setTotal(tx *gorm.DB, variant *models.ProductVariant, total int, m movement) error {
+	var current models.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "product_id", "sku", "stock").First(&current, variant.ID).Error; err != nil {
+		return err
+	}
+
+	delta := total - current.Stock
+	if delta == 0 {
+		return nil
+	}
+
+	warehouse, err := s.primaryWarehouse(tx)
+	if err != nil {
+		return err
+	}
+	if delta > 0 {
+		return s.put(tx, &current, warehouse.ID, delta, m)
+	}
+
+	if err := s.take(tx, &current, warehouse.ID, -delta, m); err != nil {
+		if errors.Is(err, ErrInsufficientStock) {
+			return fmt.Errorf("%w: warehouse %s does not hold enough stock, adjust the other warehouses' stock levels", ErrInvalidStockAdjustment, warehouse.Code)
+		}
+		return err
+	}
+	return nil
+}
+
+// adjustTotals keeps the variant's and the product's cached stock in step with a change to
+// one of the variant's stock levels
+func (s *InventoryService) adjustTotals(tx *gorm.DB, variant *models.ProductVariant, delta int) error {
+	if err := tx.Model(&models.ProductVariant{}).
+		Where("id = ?", variant.ID).
+		Update("stock", gorm.Expr("stock + ?", delta)).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).
+		Where("id = ?", variant.ProductID).
+		Update("stock", gorm.Expr("stock + ?", delta)).Error
+}
+
+func insufficientStock(variant *models.ProductVariant) error {
+	if variant.SKU == "" {
+		return ErrInsufficientStock
+	}
+	return fmt.Errorf("%w for SKU: %s", ErrInsufficientStock, variant.SKU)
+}
+
+// stockAlertEvent is a stock alert to publish once the transaction that raised it commits
+type stockAlertEvent struct {
+	EventType string
+	Event     notifications.StockAlertEvent
+}
+
+// raiseStockAlerts records the alert level of products whose stock fell further than their
+// last alert and returns the alerts to send. A product stays at its level until stock
+// recovers, so one crossing of the threshold produces one alert however many orders follow.
+func (s *InventoryService) raiseStockAlerts(tx *gorm.DB, productIDs []uint) ([]stockAlertEvent, error) {
+	var products []models.Product
+	if err := tx.Select("id", "name", "sku", "stock", "reorder_threshold", "stock_alert").
+		Where("id IN ?", productIDs).
+		Order("id ASC").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var alerts []stockAlertEvent
+	for i := range products {
+		level := stockAlertFor(products[i].Stock, products[i].ReorderThreshold)
+		if alertSeverity(level) <= alertSeverity(products[i].StockAlert) {
+			continue
+		}
+		if err := tx.Model(&products[i]).Update("stock_alert", level).Error; err != nil {
+			return nil, err
+		}
+
+		eventType := notifications.ProductLowStock
+		if level == models.StockAlertOut {
+			eventType = notifications.ProductOutOfStock
+		}
+		alerts = append(alerts, stockAlertEvent{
+			EventType: eventType,
+			Event: notifications.StockAlertEvent{
+				ProductID:        products[i].ID,
+				ProductName:      products[i].Name,
+				SKU:              products[i].SKU,
+				Stock:            products[i].Stock,
+				ReorderThreshold: products[i].ReorderThreshold,
+			},
+		})
+	}
+	return alerts, nil
+}
+
+// settleStockAlert lowers a product's recorded alert level once its stock has recovered, so
+// the next time it falls an alert is sent again
+func (s *InventoryService) settleStockAlert(tx *gorm.DB, productID uint) error {
+	var product models.Product
+	if err := tx.Select("id", "stock", "reorder_threshold", "stock_alert").First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	level := stockAlertFor(product.Stock, product.ReorderThreshold)
+	if alertSeverity(level) >= alertSeverity(product.StockAlert) {
+		return nil
+	}
+	return tx.Model(&product).Update("stock_alert", level).Error
+}
+
+// backInStock clears the back in stock subscriptions of a product whose stock went from zero
+// to positive in this transaction and returns the notification for its subscribers, nil
+// when none is due. Clearing them in the same transaction makes sure every subscriber is
+// notified once.
+func (s *InventoryService) backInStock(tx *gorm.DB, productID uint, stockBefore int) (*notifications.BackInStockEvent, error) {
+	if stockBefore > 0 {
+		return nil, nil
+	}
+
+	var product models.Product
+	if err := tx.Select("id", "name", "stock", "is_active").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	// Subscriptions wait for a product that can actually be ordered
+	if product.Stock <= 0 || !product.IsActive {
+		return nil, nil
+	}
+
+	var subscriptions []models.BackInStockSubscription
+	if err := tx.Clauses(clause.Returning{}).
+		Where("product_id = ?", productID).
+		Delete(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]uint, len(subscriptions))
+	for i := range subscriptions {
+		userIDs[i] = subscriptions[i].UserID
+	}
+	var users []models.User
+	if err := tx.Select("id", "email", "first_name", "last_name").Where("id IN ?", userIDs).Order("id ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	event := &notifications.BackInStockEvent{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Stock:       product.Stock,
+		Subscribers: make([]notifications.Subscriber, len(users)),
+	}
+	for i := range users {
+		event.Subscribers[i] = notifications.Subscriber{
+			Email:     users[i].Email,
+			FirstName: users[i].FirstName,
+			LastName:  users[i].LastName,
+		}
+	}
+	return event, nil
+}
+
+// publishBackInStock sends the back in stock notifications once the restock is committed. The
+// subscriptions are gone by then, so a failed publish is logged instead of returned.
+func (s *InventoryService) publishBackInStock(events ...*notifications.BackInStockEvent) {
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		if err := s.eventPublisher.Publish(notifications.ProductBackInStock, event, map[string]string{}); err != nil {
+			log.Error().Err(err).Uint("product_id", event.ProductID).Msg("failed to publish back in stock event")
+		}
+	}
+}
+
+// stockAlertFor is the alert level of a stock count. A threshold of 0 only alerts when the
+// product runs out.
+func stockAlertFor(stock, reorderThreshold int) models.StockAlert {
+	switch {
+	case stock <= 0:
+		return models.StockAlertOut
+	case stock < reorderThreshold:
+		return models.StockAlertLow
+	default:
+		return models.StockAlertNone
+	}
+}
+
+func alertSeverity(alert models.StockAlert) int {
+	switch alert {
+	case models.StockAlertLow:
+		return 1
+	case models.StockAlertOut:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func (s *InventoryService) record(tx *gorm.DB, variant *models.ProductVariant, warehouseID uint, delta int, m movement) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Create(&models.InventoryMovement{
+		ProductID:   variant.ProductID,
+		VariantID:   variant.ID,
+		WarehouseID: warehouseID,
+		Delta:       delta,
+		Reason:      m.Reason,
+		ReferenceID: m.ReferenceID,
+		ActorID:     m.ActorID,
+		Note:        m.Note,
+	}).Error
+}
+
+func (s *InventoryService) stockLevels(tx *gorm.DB, productID uint) ([]dto.StockLevelResponse, error) {
+	var levels []models.StockLevel
+	if err := tx.Preload("Warehouse", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Variant", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("product_id = ?", productID).Order("variant_id ASC, warehouse_id ASC").Find(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.StockLevelResponse, len(levels))
+	for i := range levels {
+		response[i] = dto.StockLevelResponse{
+			VariantID:     levels[i].VariantID,
+			SKU:           levels[i].Variant.SKU,
+			WarehouseID:   levels[i].WarehouseID,
+			WarehouseCode: levels[i].Warehouse.Code,
+			WarehouseName: levels[i].Warehouse.Name,
+			Stock:         levels[i].Stock,
+		}
+	}
+	return response, nil
+}
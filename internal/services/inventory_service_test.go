@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/notifications"
+)
+
+func TestStockAlertFor(t *testing.T) {
+	tests := []struct {
+		stock, threshold int
+		want             models.StockAlert
+	}{
+		{10, 5, models.StockAlertNone},
+		{5, 5, models.StockAlertNone},
+		{4, 5, models.StockAlertLow},
+		{0, 5, models.StockAlertOut},
+		{1, 0, models.StockAlertNone},
+		{0, 0, models.StockAlertOut},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, stockAlertFor(tt.stock, tt.threshold), "stock %d, threshold %d", tt.stock, tt.threshold)
+	}
+}
+
+func TestStockAlertsOncePerThresholdCrossing(t *testing.T) {
+	service := newTestReservationService(t)
+	inventory := service.inventoryService
+	product := createTestProduct(t, service, 10)
+	require.NoError(t, service.db.Model(&product).Update("reorder_threshold", 5).Error)
+	warehouseID := primaryWarehouseID(t, service)
+	sale := movementFor(models.MovementReasonSale, 0, 0)
+
+	sell := func(quantity int) []stockAlertEvent {
+		t.Helper()
+		require.NoError(t, inventory.take(service.db, &product.Variants[0], warehouseID, quantity, sale))
+		alerts, err := inventory.raiseStockAlerts(service.db, []uint{product.ID})
+		require.NoError(t, err)
+		return alerts
+	}
+
+	assert.Empty(t, sell(5))
+
+	alerts := sell(1)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, notifications.ProductLowStock, alerts[0].EventType)
+	assert.Equal(t, 4, alerts[0].Event.Stock)
+
+	// Further orders below the threshold stay quiet
+	assert.Empty(t, sell(1))
+
+	alerts = sell(3)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, notifications.ProductOutOfStock, alerts[0].EventType)
+
+	// A restock above the threshold re-arms both alerts
+	require.NoError(t, inventory.put(service.db, &product.Variants[0], warehouseID, 6, movementFor(models.MovementReasonImport, 0, 0)))
+	alerts = sell(2)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, notifications.ProductLowStock, alerts[0].EventType)
+}
+
+func TestBackInStockClearsSubscriptionsOnce(t *testing.T) {
+	service := newTestReservationService(t)
+	inventory := service.inventoryService
+	product := createTestProduct(t, service, 0)
+	warehouseID := primaryWarehouseID(t, service)
+
+	user := models.User{Email: "waiting@example.com", Password: "secret", FirstName: "Test", LastName: "Shopper"}
+	require.NoError(t, service.db.Create(&user).Error)
+	require.NoError(t, service.db.Create(&models.BackInStockSubscription{UserID: user.ID, ProductID: product.ID}).Error)
+
+	require.NoError(t, inventory.put(service.db, &product.Variants[0], warehouseID, 3, movementFor(models.MovementReasonAdjustment, 0, 0)))
+	event, err := inventory.backInStock(service.db, product.ID, 0)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, 3, event.Stock)
+	assert.Equal(t, []notifications.Subscriber{{Email: user.Email, FirstName: "Test", LastName: "Shopper"}}, event.Subscribers)
+
+	// The subscribers were notified, nobody is left to tell
+	event, err = inventory.backInStock(service.db, product.ID, 0)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	// Stock that was already there is no restock
+	require.NoError(t, service.db.Create(&models.BackInStockSubscription{UserID: user.ID, ProductID: product.ID}).Error)
+	event, err = inventory.backInStock(service.db, product.ID, 3)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestPutCollectsBackInStockExceptReservationRelease(t *testing.T) {
+	service := newTestReservationService(t)
+	inventory := service.inventoryService
+	product := createTestProduct(t, service, 0)
+	warehouseID := primaryWarehouseID(t, service)
+
+	user := models.User{Email: "returning@example.com", Password: "secret", FirstName: "Test", LastName: "Shopper"}
+	require.NoError(t, service.db.Create(&user).Error)
+	require.NoError(t, service.db.Create(&models.BackInStockSubscription{UserID: user.ID, ProductID: product.ID}).Error)
+
+	// Stock held by a checkout coming back leaves the subscriptions alone
+	var restocked backInStockEvents
+	release := movementFor(models.MovementReasonReservationRelease, 0, 0)
+	release.Restocked = &restocked
+	require.NoError(t, inventory.put(service.db, &product.Variants[0], warehouseID, 1, release))
+	assert.Empty(t, restocked)
+	require.NoError(t, inventory.take(service.db, &product.Variants[0], warehouseID, 1, movementFor(models.MovementReasonReservation, 0, 0)))
+
+	cancellation := movementFor(models.MovementReasonCancellation, 0, 0)
+	cancellation.Restocked = &restocked
+	require.NoError(t, inventory.put(service.db, &product.Variants[0], warehouseID, 2, cancellation))
+	require.NoError(t, inventory.put(service.db, &product.Variants[0], warehouseID, 1, cancellation))
+	require.Len(t, restocked, 1)
+	assert.Equal(t, product.ID, restocked[0].ProductID)
+	assert.Equal(t, 2, restocked[0].Stock)
+}
@@ -3,33 +3,124 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/events"
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
 	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"github.com/veetmoradiya3628/go-shop/internal/notifications"
 	"github.com/veetmoradiya3628/go-shop/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
 	defaultDateFormat = "2006-01-02T15:04:05Z"
 )
 
+var ErrOrderPaid = errors.New("order has a captured payment, refund it before cancelling")
+
+// orderStatusTransitions lists the statuses an order may move to from each status.
+// Delivered and cancelled orders are final.
+var orderStatusTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending:   {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed: {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:   {models.OrderStatusDelivered},
+}
+
+// InvalidStatusTransitionError is returned when an order is asked to move to a status
+// that is not reachable from its current one.
+type InvalidStatusTransitionError struct {
+	From models.OrderStatus
+	To   models.OrderStatus
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+// validateStatusTransition checks the transition table for a move between two statuses
+func validateStatusTransition(from, to models.OrderStatus) error {
+	for _, allowed := range orderStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &InvalidStatusTransitionError{From: from, To: to}
+}
+
+// withOrderDetails preloads everything convertToOrderResponse needs
+func withOrderDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("OrderItems.Product.Category").
+		Preload("OrderItems.Allocations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("OrderItems.Allocations.Warehouse", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("Discounts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("TaxLines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Preload("Refunds.Items")
+}
+
 type OrderService struct {
-	db *gorm.DB
+	db                 *gorm.DB
+	eventPublisher     events.Publisher
+	currencyService    *CurrencyService
+	couponService      *CouponService
+	promotionService   *PromotionService
+	reservationService *ReservationService
+	inventoryService   *InventoryService
+	taxCalculator      interfaces.TaxCalculator
+	paymentProvider    interfaces.PaymentProvider
 }
 
 // NewOrderService creates the order service type
-func NewOrderService(db *gorm.DB) *OrderService {
-	return &OrderService{db: db}
+func NewOrderService(db *gorm.DB, eventPublisher events.Publisher, currencyService *CurrencyService, couponService *CouponService, promotionService *PromotionService, reservationService *ReservationService, inventoryService *InventoryService, taxCalculator interfaces.TaxCalculator, paymentProvider interfaces.PaymentProvider) *OrderService {
+	return &OrderService{
+		db:                 db,
+		eventPublisher:     eventPublisher,
+		currencyService:    currencyService,
+		couponService:      couponService,
+		promotionService:   promotionService,
+		reservationService: reservationService,
+		inventoryService:   inventoryService,
+		taxCalculator:      taxCalculator,
+		paymentProvider:    paymentProvider,
+	}
 }
 
-func (s *OrderService) CreateOrder(userID uint) (*dto.OrderResponse, error) {
+// CreateOrder places an order for the user's cart. The order records the display currency and
+// exchange rate in effect at checkout, so its converted totals never change afterwards.
+func (s *OrderService) CreateOrder(userID uint, req *dto.CreateOrderRequest, currency string) (*dto.OrderResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
 	var orderResponse *dto.OrderResponse
+	var stockAlerts []stockAlertEvent
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
 
 		var cart models.Cart
-		if err := tx.Preload("CartItems.Product").Where("user_id = ?", userID).First(&cart).Error; err != nil {
+		if err := tx.Preload("CartItems.Product").Preload("CartItems.Variant.OptionValues").Where("user_id = ?", userID).First(&cart).Error; err != nil {
 			return errors.New("cart not found")
 		}
 
@@ -37,57 +128,147 @@ func (s *OrderService) CreateOrder(userID uint) (*dto.OrderResponse, error) {
 			return errors.New("cart is empty")
 		}
 
-		// Calculate total and validate stock
-		var totalAmount float64
-		var orderItems []models.OrderItem
+		shippingAddress, err := s.resolveAddress(tx, userID, req.ShippingAddressID, "is_default_shipping")
+		if err != nil {
+			return fmt.Errorf("shipping address: %w", err)
+		}
 
-		for i := range cart.CartItems {
-			cartItem := &cart.CartItems[i]
+		billingAddress := shippingAddress
+		if req.BillingAddressID != 0 {
+			billingAddress, err = s.resolveAddress(tx, userID, req.BillingAddressID, "is_default_billing")
+			if err != nil {
+				return fmt.Errorf("billing address: %w", err)
+			}
+		} else if address, err := s.resolveAddress(tx, userID, 0, "is_default_billing"); err == nil {
+			billingAddress = address
+		}
 
-			if cartItem.Product.Stock < cartItem.Quantity {
-				return fmt.Errorf("insufficient stock for product: %s", cartItem.Product.Name)
+		// Automatic promotions come first, a coupon discounts what is left
+		now := time.Now()
+		promotions, err := s.promotionService.activePromotions(tx, now)
+		if err != nil {
+			return err
+		}
+		breakdown := newPriceBreakdown(cartPricedLines(&cart))
+		breakdown.applyPromotions(promotions, now)
+
+		// The coupon row stays locked until the transaction ends, so concurrent checkouts
+		// with the same code cannot exceed its usage limits
+		var coupon *models.Coupon
+		if cart.CouponID != nil {
+			coupon, err = s.couponService.lockForRedemption(tx, *cart.CouponID)
+			if err != nil {
+				return err
+			}
+			if err := s.couponService.checkUsage(tx, coupon, userID); err != nil {
+				return err
+			}
+			if err := breakdown.applyCoupon(coupon, now); err != nil {
+				return err
 			}
+		}
+
+		// Taxes are charged for the shipping destination
+		if err := breakdown.calculateTaxes(s.taxCalculator, taxAddressFor(shippingAddress.Snapshot())); err != nil {
+			return err
+		}
+		taxes := breakdown.Taxes
+
+		var orderItems []models.OrderItem
 
-			itemTotal := float64(cartItem.Quantity) * cartItem.Product.Price
-			totalAmount += itemTotal
+		for i := range cart.CartItems {
+			cartItem := &cart.CartItems[i]
 
 			orderItems = append(orderItems, models.OrderItem{
-				ProductID: cartItem.ProductID,
-				Quantity:  cartItem.Quantity,
-				Price:     cartItem.Product.Price,
+				ProductID:      cartItem.ProductID,
+				VariantID:      cartItem.VariantID,
+				SKU:            cartItem.Variant.SKU,
+				VariantName:    cartItem.Variant.Name(),
+				Quantity:       cartItem.Quantity,
+				Price:          cartItem.Variant.UnitPrice(&cartItem.Product),
+				DiscountAmount: breakdown.Lines[i].Discount,
+				TaxAmount:      taxes.Items[i].Tax,
+				TaxInclusive:   taxes.Items[i].Inclusive,
 			})
+		}
 
-			// Update product stock
-			cartItem.Product.Stock -= cartItem.Quantity
-			if err := tx.Save(&cartItem.Product).Error; err != nil {
-				return err
-			}
+		// Create order
+		order := models.Order{
+			UserID:          userID,
+			Status:          models.OrderStatusPending,
+			SubtotalAmount:  breakdown.Subtotal,
+			DiscountAmount:  breakdown.Discount,
+			FreeShipping:    breakdown.FreeShipping,
+			TaxAmount:       taxes.Tax,
+			TotalAmount:     breakdown.Total(),
+			Currency:        display.code,
+			ExchangeRate:    display.rate,
+			ShippingAddress: shippingAddress.Snapshot(),
+			BillingAddress:  billingAddress.Snapshot(),
+			OrderItems:      orderItems,
+			Discounts:       convertToOrderDiscounts(breakdown.Discounts),
+			TaxLines:        convertToOrderTaxLines(taxes.Lines),
+		}
 
-			// Create order
-			order := models.Order{
-				UserID:      userID,
-				Status:      models.OrderStatusPending,
-				TotalAmount: totalAmount,
-				OrderItems:  orderItems,
-			}
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
 
-			if err := tx.Create(&order).Error; err != nil {
-				return err
+		// Stock reserved at checkout start is used first, the rest is taken now from the
+		// warehouses chosen for the shipping address
+		allocations, err := s.reservationService.commit(tx, userID, order.ID, cart.CartItems, order.ShippingAddress)
+		if err != nil {
+			return err
+		}
+		var itemAllocations []models.OrderItemAllocation
+		for i := range order.OrderItems {
+			for _, allocation := range allocations[i] {
+				itemAllocations = append(itemAllocations, models.OrderItemAllocation{
+					OrderItemID: order.OrderItems[i].ID,
+					WarehouseID: allocation.WarehouseID,
+					Quantity:    allocation.Quantity,
+				})
 			}
+		}
+		if err := tx.Create(&itemAllocations).Error; err != nil {
+			return err
+		}
 
-			// Clear cart
-			if err := tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
-				return err
-			}
+		productIDs := make([]uint, len(cart.CartItems))
+		for i := range cart.CartItems {
+			productIDs[i] = cart.CartItems[i].ProductID
+		}
+		stockAlerts, err = s.inventoryService.raiseStockAlerts(tx, productIDs)
+		if err != nil {
+			return err
+		}
 
-			response, err := s.getOrderResponse(tx, order.ID)
-			if err != nil {
+		if coupon != nil {
+			if err := s.couponService.redeem(tx, coupon, userID, order.ID, couponDiscount(breakdown, coupon.ID)); err != nil {
 				return err
 			}
+		}
 
-			orderResponse = response
+		// Record the initial status so the history starts with the customer placing the order
+		if err := s.recordStatusChange(tx, order.ID, nil, models.OrderStatusPending, userID, "order placed"); err != nil {
+			return err
 		}
 
+		// Clear cart
+		if err := tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&cart).Update("coupon_id", nil).Error; err != nil {
+			return err
+		}
+
+		response, err := s.getOrderResponse(tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		orderResponse = response
+
 		return nil // Transaction successful
 	})
 
@@ -95,6 +276,8 @@ func (s *OrderService) CreateOrder(userID uint) (*dto.OrderResponse, error) {
 		return nil, err
 	}
 
+	s.publishStockAlerts(stockAlerts)
+
 	return orderResponse, nil
 
 }
@@ -118,7 +301,7 @@ func (s *OrderService) GetOrders(userID uint, page, limit int) ([]dto.OrderRespo
 
 	s.db.Model(&models.Order{}).Where("user_id = ?", userID).Count(&total)
 
-	if err := s.db.Preload("OrderItems.Product.Category").
+	if err := s.db.Scopes(withOrderDetails).
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Offset(offset).Limit(limit).
@@ -143,9 +326,165 @@ func (s *OrderService) GetOrders(userID uint, page, limit int) ([]dto.OrderRespo
 	return response, meta, nil
 }
 
+// resolveAddress loads the chosen address of the user, or the one flagged by defaultColumn when none was chosen
+func (s *OrderService) resolveAddress(tx *gorm.DB, userID, addressID uint, defaultColumn string) (*models.Address, error) {
+	var address models.Address
+	query := tx.Where("user_id = ?", userID)
+	if addressID != 0 {
+		query = query.Where("id = ?", addressID)
+	} else {
+		query = query.Where(defaultColumn+" = ?", true)
+	}
+
+	if err := query.First(&address).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if addressID != 0 {
+				return nil, errors.New("address not found")
+			}
+			return nil, errors.New("no address selected and no default address set")
+		}
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// taxAddressFor returns the tax destination of an address
+func taxAddressFor(address models.AddressSnapshot) interfaces.TaxAddress {
+	return interfaces.TaxAddress{Country: address.Country, Region: address.State}
+}
+
+func convertToOrderDiscounts(discounts []discountLine) []models.OrderDiscount {
+	orderDiscounts := make([]models.OrderDiscount, len(discounts))
+	for i, discount := range discounts {
+		orderDiscounts[i] = models.OrderDiscount{
+			CouponID:      discount.CouponID,
+			PromotionID:   discount.PromotionID,
+			PromotionName: discount.PromotionName,
+			Code:          discount.Code,
+			Description:   discount.Description,
+			Amount:        discount.Amount,
+		}
+	}
+	return orderDiscounts
+}
+
+func convertToOrderTaxLines(lines []interfaces.TaxLine) []models.OrderTaxLine {
+	taxLines := make([]models.OrderTaxLine, len(lines))
+	for i, line := range lines {
+		taxLines[i] = models.OrderTaxLine{
+			Name:          line.Name,
+			Country:       line.Country,
+			Region:        line.Region,
+			TaxClass:      line.TaxClass,
+			Rate:          line.Rate,
+			Inclusive:     line.Inclusive,
+			TaxableAmount: line.TaxableAmount,
+			Amount:        line.Amount,
+		}
+	}
+	return taxLines
+}
+
+// adminOrderSorts maps the sort options of the admin order list to their ORDER BY clause
+var adminOrderSorts = map[string]string{
+	"newest":     "created_at DESC",
+	"oldest":     "created_at ASC",
+	"total_asc":  "total_amount ASC, created_at DESC",
+	"total_desc": "total_amount DESC, created_at DESC",
+}
+
+// ListOrders returns orders across all customers for the admin order list
+func (s *OrderService) ListOrders(query *dto.AdminOrderListQuery) ([]dto.AdminOrderResponse, *utils.PaginationMeta, error) {
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = 10
+	}
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	filtered := s.db.Model(&models.Order{})
+	if query.Status != "" {
+		filtered = filtered.Where("status = ?", query.Status)
+	}
+	if query.UserID != 0 {
+		filtered = filtered.Where("user_id = ?", query.UserID)
+	}
+	if !query.From.IsZero() {
+		filtered = filtered.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		// the end date is inclusive, so match everything before the following day
+		filtered = filtered.Where("created_at < ?", query.To.AddDate(0, 0, 1))
+	}
+	if query.MinTotal != nil {
+		filtered = filtered.Where("total_amount >= ?", *query.MinTotal)
+	}
+	if query.MaxTotal != nil {
+		filtered = filtered.Where("total_amount <= ?", *query.MaxTotal)
+	}
+
+	sort, ok := adminOrderSorts[query.Sort]
+	if !ok {
+		sort = adminOrderSorts["newest"]
+	}
+
+	offset := (page - 1) * limit
+	var orders []models.Order
+	var total int64
+
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if err := filtered.Session(&gorm.Session{}).
+		Preload("User").
+		Scopes(withOrderDetails).
+		Order(sort).
+		Offset(offset).Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, nil, err
+	}
+
+	response := make([]dto.AdminOrderResponse, len(orders))
+	for i := range orders {
+		response[i] = s.convertToAdminOrderResponse(&orders[i])
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := &utils.PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	return response, meta, nil
+}
+
+// GetOrderForAdmin returns any order together with the customer who placed it
+func (s *OrderService) GetOrderForAdmin(orderID uint) (*dto.AdminOrderResponse, error) {
+	var order models.Order
+	if err := s.db.Preload("User").
+		Scopes(withOrderDetails).
+		First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+
+	response := s.convertToAdminOrderResponse(&order)
+
+	return &response, nil
+}
+
 func (s *OrderService) GetOrder(userID, orderID uint) (*dto.OrderResponse, error) {
 	var order models.Order
-	if err := s.db.Preload("OrderItems.Product.Category").
+	if err := s.db.Scopes(withOrderDetails).
 		Where("id = ? AND user_id = ?", orderID, userID).
 		First(&order).Error; err != nil {
 		return nil, err
@@ -156,9 +495,329 @@ func (s *OrderService) GetOrder(userID, orderID uint) (*dto.OrderResponse, error
 	return &response, nil
 }
 
+// UpdateOrderStatus moves an order to a new status and records who made the change
+func (s *OrderService) UpdateOrderStatus(orderID, actorID uint, req *dto.UpdateOrderStatusRequest) (*dto.OrderResponse, error) {
+	var orderResponse *dto.OrderResponse
+	var restocked backInStockEvents
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return err
+		}
+
+		newStatus := models.OrderStatus(req.Status)
+		if err := validateStatusTransition(order.Status, newStatus); err != nil {
+			return err
+		}
+
+		// Cancelling through the status endpoint must give the stock, coupon and payment back as well
+		if newStatus == models.OrderStatusCancelled {
+			if err := s.cancel(tx, &order, actorID, req.Note, &restocked); err != nil {
+				return err
+			}
+		} else if err := s.changeStatus(tx, &order, newStatus, actorID, req.Note); err != nil {
+			return err
+		}
+		if newStatus == models.OrderStatusDelivered {
+			if err := verifyPurchases(tx, &order); err != nil {
+				return err
+			}
+		}
+
+		response, err := s.getOrderResponse(tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		orderResponse = response
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if orderResponse.Status == string(models.OrderStatusCancelled) {
+		s.voidPayments(orderID, actorID)
+		s.publishOrderEvent(notifications.OrderCancelled, notifications.OrderEvent{OrderID: orderID, Reason: req.Note})
+		s.inventoryService.publishBackInStock(restocked...)
+	}
+
+	return orderResponse, nil
+}
+
+// CancelOrder cancels a pending or confirmed order, puts its items back in stock and voids
+// its authorized payment. Orders whose payment was captured have to be refunded first.
+// Customers may only cancel their own orders, admins may cancel any order.
+func (s *OrderService) CancelOrder(orderID, userID uint, isAdmin bool) (*dto.OrderResponse, error) {
+	var orderResponse *dto.OrderResponse
+	var restocked backInStockEvents
+
+	note := "cancelled by customer"
+	if isAdmin {
+		note = "cancelled by admin"
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if !isAdmin {
+			query = query.Where("user_id = ?", userID)
+		}
+
+		var order models.Order
+		if err := query.First(&order, orderID).Error; err != nil {
+			return err
+		}
+
+		if err := validateStatusTransition(order.Status, models.OrderStatusCancelled); err != nil {
+			return err
+		}
+
+		if err := s.cancel(tx, &order, userID, note, &restocked); err != nil {
+			return err
+		}
+
+		response, err := s.getOrderResponse(tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		orderResponse = response
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.voidPayments(orderID, userID)
+	s.publishOrderEvent(notifications.OrderCancelled, notifications.OrderEvent{OrderID: orderID, Reason: note})
+	s.inventoryService.publishBackInStock(restocked...)
+
+	return orderResponse, nil
+}
+
+// cancel gives the stock and coupon of an order back and marks it cancelled. An order with
+// money taken for it, or being taken, is refused, so it is never cancelled without the
+// customer being repaid.
+// The back in stock notifications the returned stock makes due are added to restocked.
+func (s *OrderService) cancel(tx *gorm.DB, order *models.Order, actorID uint, note string, restocked *backInStockEvents) error {
+	var captured int64
+	if err := tx.Model(&models.Payment{}).
+		Where("order_id = ? AND status IN ?", order.ID, []models.PaymentStatus{models.PaymentStatusCapturing, models.PaymentStatusCaptured, models.PaymentStatusPartiallyRefunded}).
+		Count(&captured).Error; err != nil {
+		return err
+	}
+	if captured > 0 {
+		return ErrOrderPaid
+	}
+
+	if err := s.restoreStock(tx, order.ID, actorID, restocked); err != nil {
+		return err
+	}
+	if err := s.couponService.release(tx, order.ID); err != nil {
+		return err
+	}
+	return s.changeStatus(tx, order, models.OrderStatusCancelled, actorID, note)
+}
+
+// voidPayments releases the authorizations still held for a cancelled order. Payments of
+// cancelled orders can no longer be captured, so one the gateway fails to void is only logged
+// and lapses with its authorization.
+func (s *OrderService) voidPayments(orderID, actorID uint) {
+	var payments []models.Payment
+	if err := s.db.Where("order_id = ? AND status = ?", orderID, models.PaymentStatusAuthorized).
+		Find(&payments).Error; err != nil {
+		log.Error().Err(err).Uint("order_id", orderID).Msg("failed to load payments of cancelled order")
+		return
+	}
+
+	for i := range payments {
+		if _, err := s.paymentProvider.Void(payments[i].TransactionID); err != nil {
+			log.Error().Err(err).Uint("payment_id", payments[i].ID).Msg("failed to void payment of cancelled order")
+			continue
+		}
+		if err := s.ApplyPaymentStatus(payments[i].ID, models.PaymentStatusVoided, actorID); err != nil {
+			log.Error().Err(err).Uint("payment_id", payments[i].ID).Msg("failed to record voided payment")
+		}
+	}
+}
+
+// ApplyPaymentStatus stores the new status of a payment and moves its order along with it.
+// A captured payment confirms an order that is still pending. A payment captured for an order
+// that was cancelled in the meantime is voided instead, as the order will not ship.
+func (s *OrderService) ApplyPaymentStatus(paymentID uint, status models.PaymentStatus, actorID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.applyPaymentStatus(tx, paymentID, status, actorID)
+	})
+}
+
+func (s *OrderService) applyPaymentStatus(tx *gorm.DB, paymentID uint, status models.PaymentStatus, actorID uint) error {
+	var payment models.Payment
+	if err := tx.Select("order_id").First(&payment, paymentID).Error; err != nil {
+		return err
+	}
+
+	// The order is locked before its payment, the same order captures and cancellations take
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error; err != nil {
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+		return err
+	}
+
+	if payment.Status == status {
+		return nil
+	}
+
+	if status == models.PaymentStatusCaptured && order.Status == models.OrderStatusCancelled {
+		if _, err := s.paymentProvider.Void(payment.TransactionID); err != nil {
+			return fmt.Errorf("%w: %v", ErrOrderNotPayable, err)
+		}
+		status = models.PaymentStatusVoided
+	}
+
+	if err := tx.Model(&payment).Update("status", status).Error; err != nil {
+		return err
+	}
+
+	if status == models.PaymentStatusCaptured && order.Status == models.OrderStatusPending {
+		return s.changeStatus(tx, &order, models.OrderStatusConfirmed, actorID, "payment captured")
+	}
+
+	return nil
+}
+
+// restoreStock adds the quantities of every item in the order back to the warehouses it was
+// shipped from. Quantities that were already restocked by a refund are skipped.
+func (s *OrderService) restoreStock(tx *gorm.DB, orderID, actorID uint, events *backInStockEvents) error {
+	// Stock moves in variant id order after the products are locked, see lockProducts
+	var items []models.OrderItem
+	if err := tx.Preload("Allocations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("order_id = ?", orderID).Order("variant_id ASC, id ASC").Find(&items).Error; err != nil {
+		return err
+	}
+	productIDs := make([]uint, len(items))
+	for i := range items {
+		productIDs[i] = items[i].ProductID
+	}
+	if _, err := s.inventoryService.lockProducts(tx, productIDs); err != nil {
+		return err
+	}
+
+	restocked, err := s.refundedQuantities(tx, orderID, true)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		quantity := items[i].Quantity - restocked[items[i].ID]
+		if quantity <= 0 {
+			continue
+		}
+		m := movementFor(models.MovementReasonCancellation, orderID, actorID)
+		m.Restocked = events
+		if err := s.inventoryService.returnItem(tx, &items[i], restocked[items[i].ID], quantity, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refundedQuantities sums the refunded quantity per order item. Pending refunds count, as
+// their quantities are claimed already, while failed ones do not. Counting restocked refunds
+// only, just the completed ones count, as only those were put back in stock.
+func (s *OrderService) refundedQuantities(tx *gorm.DB, orderID uint, restockedOnly bool) (map[uint]int, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+
+	query := tx.Table("refund_items").
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.order_id = ?", orderID)
+	if restockedOnly {
+		query = query.Where("refunds.restocked = ? AND refunds.status = ?", true, models.RefundStatusCompleted)
+	} else {
+		query = query.Where("refunds.status <> ?", models.RefundStatusFailed)
+	}
+
+	if err := query.Group("refund_items.order_item_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// publishOrderEvent notifies the customer about an order change. The order and customer
+// details are filled in from the database. The change is already committed at this
+// point, so a failed publish is logged instead of returned.
+func (s *OrderService) publishOrderEvent(eventType string, event notifications.OrderEvent) {
+	var order models.Order
+	if err := s.db.Preload("User").First(&order, event.OrderID).Error; err != nil {
+		log.Error().Err(err).Uint("order_id", event.OrderID).Msg("failed to load order for event")
+		return
+	}
+
+	event.Status = string(order.Status)
+	event.TotalAmount = order.TotalAmount
+	event.Email = order.User.Email
+	event.FirstName = order.User.FirstName
+	event.LastName = order.User.LastName
+
+	if err := s.eventPublisher.Publish(eventType, event, map[string]string{}); err != nil {
+		log.Error().Err(err).Uint("order_id", event.OrderID).Str("event_type", eventType).Msg("failed to publish order event")
+	}
+}
+
+// publishStockAlerts tells inventory managers about products an order took below their
+// reorder threshold or out of stock. The order is already placed, so a failed publish is
+// logged instead of returned.
+func (s *OrderService) publishStockAlerts(alerts []stockAlertEvent) {
+	for _, alert := range alerts {
+		if err := s.eventPublisher.Publish(alert.EventType, alert.Event, map[string]string{}); err != nil {
+			log.Error().Err(err).Uint("product_id", alert.Event.ProductID).Str("event_type", alert.EventType).Msg("failed to publish stock alert")
+		}
+	}
+}
+
+// changeStatus updates the order status inside tx and appends the history entry
+func (s *OrderService) changeStatus(tx *gorm.DB, order *models.Order, status models.OrderStatus, actorID uint, note string) error {
+	previous := order.Status
+	if err := tx.Model(order).Update("status", status).Error; err != nil {
+		return err
+	}
+	return s.recordStatusChange(tx, order.ID, &previous, status, actorID, note)
+}
+
+// recordStatusChange appends a history entry. An actorID of 0 marks a change made by the
+// system, such as a payment gateway webhook.
+func (s *OrderService) recordStatusChange(tx *gorm.DB, orderID uint, from *models.OrderStatus, to models.OrderStatus, actorID uint, note string) error {
+	history := models.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		Note:       note,
+	}
+	if actorID != 0 {
+		history.ChangedBy = &actorID
+	}
+	return tx.Create(&history).Error
+}
+
 func (s *OrderService) getOrderResponse(tx *gorm.DB, orderID uint) (*dto.OrderResponse, error) {
 	var order models.Order
-	if err := tx.Preload("OrderItems.Product.Category").First(&order, orderID).Error; err != nil {
+	if err := tx.Scopes(withOrderDetails).
+		First(&order, orderID).Error; err != nil {
 		return nil, err
 	}
 
@@ -167,40 +826,161 @@ func (s *OrderService) getOrderResponse(tx *gorm.DB, orderID uint) (*dto.OrderRe
 	return &response, nil
 }
 
+func (s *OrderService) convertToAdminOrderResponse(order *models.Order) dto.AdminOrderResponse {
+	return dto.AdminOrderResponse{
+		OrderResponse: s.convertToOrderResponse(order),
+		Customer:      convertToUserResponse(&order.User),
+	}
+}
+
 func (s *OrderService) convertToOrderResponse(order *models.Order) dto.OrderResponse {
+	display := orderCurrency(order)
+
 	orderItems := make([]dto.OrderItemResponse, len(order.OrderItems))
 	for i := range order.OrderItems {
 		item := order.OrderItems[i]
 
+		allocations := make([]dto.OrderItemAllocationResponse, len(item.Allocations))
+		for j := range item.Allocations {
+			allocations[j] = dto.OrderItemAllocationResponse{
+				WarehouseID:   item.Allocations[j].WarehouseID,
+				WarehouseCode: item.Allocations[j].Warehouse.Code,
+				Quantity:      item.Allocations[j].Quantity,
+			}
+		}
+
 		orderItems[i] = dto.OrderItemResponse{
 			ID: item.ID,
 			Product: dto.ProductResponse{
-				ID:          item.Product.ID,
-				CategoryID:  item.Product.CategoryID,
-				Name:        item.Product.Name,
-				Description: item.Product.Description,
-				Price:       item.Product.Price,
-				Stock:       item.Product.Stock,
-				SKU:         item.Product.SKU,
-				IsActive:    item.Product.IsActive,
+				ID:            item.Product.ID,
+				CategoryID:    item.Product.CategoryID,
+				Name:          item.Product.Name,
+				Slug:          item.Product.Slug,
+				Description:   item.Product.Description,
+				Price:         display.convert(item.Product.Price),
+				Currency:      display.code,
+				Stock:         item.Product.Stock,
+				SKU:           item.Product.SKU,
+				TaxClass:      item.Product.TaxClass,
+				IsActive:      item.Product.IsActive,
+				AverageRating: item.Product.AverageRating(),
+				ReviewCount:   item.Product.ReviewCount,
 				Category: dto.CategoryResponse{
 					ID:          item.Product.Category.ID,
 					Name:        item.Product.Category.Name,
+					Slug:        item.Product.Category.Slug,
 					Description: item.Product.Category.Description,
 					IsActive:    item.Product.Category.IsActive,
 				},
 			},
-			Quantity: item.Quantity,
-			Price:    item.Price,
+			VariantID:      item.VariantID,
+			SKU:            item.SKU,
+			VariantName:    item.VariantName,
+			Quantity:       item.Quantity,
+			Price:          display.convert(item.Price),
+			DiscountAmount: display.convert(item.DiscountAmount),
+			TaxAmount:      display.convert(item.TaxAmount),
+			TaxInclusive:   item.TaxInclusive,
+			Allocations:    allocations,
+		}
+	}
+
+	discounts := make([]dto.DiscountLineResponse, len(order.Discounts))
+	discountAmount := money.Zero(display.code)
+	for i := range order.Discounts {
+		discounts[i] = dto.DiscountLineResponse{
+			Code:          order.Discounts[i].Code,
+			PromotionID:   order.Discounts[i].PromotionID,
+			PromotionName: order.Discounts[i].PromotionName,
+			Description:   order.Discounts[i].Description,
+			Amount:        display.convert(order.Discounts[i].Amount),
+		}
+		discountAmount = discountAmount.Add(discounts[i].Amount)
+	}
+
+	// Display totals are built from the converted parts so they add up in the display currency
+	subtotal := display.convert(order.SubtotalAmount)
+	taxLines := make([]dto.TaxLineResponse, len(order.TaxLines))
+	taxAmount := money.Zero(display.code)
+	total := subtotal.Sub(discountAmount)
+	for i := range order.TaxLines {
+		line := order.TaxLines[i]
+
+		taxLines[i] = dto.TaxLineResponse{
+			Name:          line.Name,
+			Rate:          line.Rate,
+			Inclusive:     line.Inclusive,
+			TaxableAmount: display.convert(line.TaxableAmount),
+			Amount:        display.convert(line.Amount),
+		}
+		taxAmount = taxAmount.Add(taxLines[i].Amount)
+		if !line.Inclusive {
+			total = total.Add(taxLines[i].Amount)
+		}
+	}
+
+	statusHistory := make([]dto.OrderStatusHistoryResponse, len(order.StatusHistory))
+	for i := range order.StatusHistory {
+		entry := order.StatusHistory[i]
+
+		var fromStatus string
+		if entry.FromStatus != nil {
+			fromStatus = string(*entry.FromStatus)
+		}
+
+		statusHistory[i] = dto.OrderStatusHistoryResponse{
+			FromStatus: fromStatus,
+			ToStatus:   string(entry.ToStatus),
+			ChangedBy:  entry.ChangedBy,
+			Note:       entry.Note,
+			CreatedAt:  entry.CreatedAt.Format(defaultDateFormat),
 		}
 	}
 
+	payments := make([]dto.PaymentResponse, len(order.Payments))
+	for i := range order.Payments {
+		payments[i] = convertToPaymentResponse(&order.Payments[i])
+	}
+
+	refunds := make([]dto.RefundResponse, len(order.Refunds))
+	for i := range order.Refunds {
+		refunds[i] = convertToRefundResponse(&order.Refunds[i])
+	}
+
 	return dto.OrderResponse{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		Status:      string(order.Status),
-		TotalAmount: order.TotalAmount,
-		OrderItems:  orderItems,
-		CreatedAt:   order.CreatedAt.Format(defaultDateFormat),
+		ID:              order.ID,
+		UserID:          order.UserID,
+		Status:          string(order.Status),
+		SubtotalAmount:  subtotal,
+		Discounts:       discounts,
+		DiscountAmount:  discountAmount,
+		FreeShipping:    order.FreeShipping,
+		TaxLines:        taxLines,
+		TaxAmount:       taxAmount,
+		TotalAmount:     total,
+		Currency:        display.code,
+		ExchangeRate:    display.rate,
+		BaseTotalAmount: order.TotalAmount,
+		OrderItems:      orderItems,
+		ShippingAddress: convertToOrderAddressResponse(&order.ShippingAddress),
+		BillingAddress:  convertToOrderAddressResponse(&order.BillingAddress),
+		StatusHistory:   statusHistory,
+		Payments:        payments,
+		Refunds:         refunds,
+		CreatedAt:       order.CreatedAt.Format(defaultDateFormat),
+	}
+}
+
+func convertToOrderAddressResponse(address *models.AddressSnapshot) dto.OrderAddressResponse {
+	return dto.OrderAddressResponse{
+		FirstName:  address.FirstName,
+		LastName:   address.LastName,
+		Phone:      address.Phone,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
 	}
 }
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	allowed := []struct {
+		from, to models.OrderStatus
+	}{
+		{models.OrderStatusPending, models.OrderStatusConfirmed},
+		{models.OrderStatusPending, models.OrderStatusCancelled},
+		{models.OrderStatusConfirmed, models.OrderStatusShipped},
+		{models.OrderStatusConfirmed, models.OrderStatusCancelled},
+		{models.OrderStatusShipped, models.OrderStatusDelivered},
+	}
+	for _, tc := range allowed {
+		assert.NoError(t, validateStatusTransition(tc.from, tc.to), "%s -> %s should be allowed", tc.from, tc.to)
+	}
+
+	rejected := []struct {
+		from, to models.OrderStatus
+	}{
+		{models.OrderStatusPending, models.OrderStatusShipped},
+		{models.OrderStatusPending, models.OrderStatusPending},
+		{models.OrderStatusShipped, models.OrderStatusCancelled},
+		{models.OrderStatusDelivered, models.OrderStatusCancelled},
+		{models.OrderStatusCancelled, models.OrderStatusPending},
+	}
+	for _, tc := range rejected {
+		err := validateStatusTransition(tc.from, tc.to)
+		var transitionErr *InvalidStatusTransitionError
+		if assert.True(t, errors.As(err, &transitionErr), "%s -> %s should be rejected", tc.from, tc.to) {
+			assert.Equal(t, tc.from, transitionErr.From)
+			assert.Equal(t, tc.to, transitionErr.To)
+		}
+	}
+}
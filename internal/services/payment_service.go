@@ -0,0 +1,643 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"github.com/veetmoradiya3628/go-shop/internal/notifications"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrderNotPayable      = errors.New("order is not awaiting payment")
+	ErrOrderAlreadyPaid     = errors.New("order already has an active payment")
+	ErrNoAuthorizedPayment  = errors.New("order has no authorized payment")
+	ErrNoCapturedPayment    = errors.New("order has no captured payment to refund")
+	ErrInvalidRefund        = errors.New("invalid refund")
+	ErrRefundExceedsCapture = errors.New("refund exceeds the captured amount")
+)
+
+// webhookPaymentStatuses maps gateway webhook event types to the payment status they report
+var webhookPaymentStatuses = map[string]models.PaymentStatus{
+	"payment.captured": models.PaymentStatusCaptured,
+	"payment.voided":   models.PaymentStatusVoided,
+	"payment.failed":   models.PaymentStatusFailed,
+}
+
+type PaymentService struct {
+	db               *gorm.DB
+	provider         interfaces.PaymentProvider
+	orderService     *OrderService
+	inventoryService *InventoryService
+}
+
+func NewPaymentService(db *gorm.DB, provider interfaces.PaymentProvider, orderService *OrderService, inventoryService *InventoryService) *PaymentService {
+	return &PaymentService{
+		db:               db,
+		provider:         provider,
+		orderService:     orderService,
+		inventoryService: inventoryService,
+	}
+}
+
+// CreatePayment authorizes the order total with the payment provider and captures it
+// straight away when requested. Failed attempts are stored so they show up on the order.
+//
+// The payment is stored as pending before the gateway is asked to authorize it and updated with
+// its answer, so the order is not locked while the gateway answers and an authorization the
+// gateway made is never lost. A payment an earlier request left pending is asked for again,
+// under the same idempotency key, so it is not authorized twice.
+func (s *PaymentService) CreatePayment(userID, orderID uint, req *dto.CreatePaymentRequest) (*dto.PaymentResponse, error) {
+	payment, err := s.startPayment(userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.settleAuthorization(payment, req.PaymentMethod); err != nil {
+		return nil, err
+	}
+
+	if req.Capture {
+		return s.CapturePayment(orderID, userID)
+	}
+
+	response := convertToPaymentResponse(payment)
+	return &response, nil
+}
+
+// startPayment stores a pending payment for the order total, or returns the payment an earlier
+// request left pending. The order is locked meanwhile, so concurrent attempts cannot both
+// start a payment.
+func (s *PaymentService) startPayment(userID, orderID uint) (*models.Payment, error) {
+	var payment models.Payment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", orderID, userID).
+			First(&order).Error; err != nil {
+			return err
+		}
+
+		if order.Status != models.OrderStatusPending {
+			return ErrOrderNotPayable
+		}
+
+		err := tx.Where("order_id = ? AND status = ?", orderID, models.PaymentStatusPending).First(&payment).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var activePayments int64
+		if err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status IN ?", orderID, []models.PaymentStatus{models.PaymentStatusAuthorized, models.PaymentStatusCapturing, models.PaymentStatusCaptured}).
+			Count(&activePayments).Error; err != nil {
+			return err
+		}
+		if activePayments > 0 {
+			return ErrOrderAlreadyPaid
+		}
+
+		payment = models.Payment{
+			OrderID:        order.ID,
+			Provider:       s.provider.Name(),
+			Amount:         order.TotalAmount,
+			Status:         models.PaymentStatusPending,
+			IdempotencyKey: uuid.New().String(),
+		}
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// settleAuthorization asks the gateway to authorize a pending payment and records its answer.
+// A payment the gateway did not answer for in time stays pending, as it may have been
+// authorized. An authorization that cannot be recorded is voided, so no money is held for a
+// payment the shop does not know about.
+func (s *PaymentService) settleAuthorization(payment *models.Payment, paymentMethod string) error {
+	result, err := s.provider.Authorize(&interfaces.PaymentRequest{
+		OrderID:        payment.OrderID,
+		Amount:         payment.Amount,
+		PaymentMethod:  paymentMethod,
+		IdempotencyKey: payment.IdempotencyKey,
+	})
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPaymentTimeout) {
+			return err
+		}
+		if failErr := s.failPayment(payment.ID, err.Error()); failErr != nil {
+			return failErr
+		}
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// The order is locked before its payment, the same order captures and cancellations take
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(payment, payment.ID).Error; err != nil {
+			return err
+		}
+		if payment.Status != models.PaymentStatusPending {
+			return nil
+		}
+
+		// An order cancelled while the gateway authorized it will not be paid for
+		status := models.PaymentStatusAuthorized
+		if order.Status == models.OrderStatusCancelled {
+			if _, err := s.provider.Void(result.TransactionID); err != nil {
+				return fmt.Errorf("%w: %v", ErrOrderNotPayable, err)
+			}
+			status = models.PaymentStatusVoided
+		}
+
+		payment.TransactionID = result.TransactionID
+		payment.Status = status
+		return tx.Model(payment).Updates(map[string]interface{}{
+			"transaction_id": result.TransactionID,
+			"status":         status,
+		}).Error
+	})
+	if err != nil {
+		if _, voidErr := s.provider.Void(result.TransactionID); voidErr != nil {
+			log.Error().Err(voidErr).Uint("payment_id", payment.ID).Msg("failed to void unrecorded authorization")
+		} else if failErr := s.failPayment(payment.ID, "authorization could not be recorded"); failErr != nil {
+			log.Error().Err(failErr).Uint("payment_id", payment.ID).Msg("failed to record voided authorization")
+		}
+		return err
+	}
+
+	if payment.Status == models.PaymentStatusVoided {
+		return ErrOrderNotPayable
+	}
+	return nil
+}
+
+// failPayment marks a pending payment failed
+func (s *PaymentService) failPayment(paymentID uint, reason string) error {
+	return s.db.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", paymentID, models.PaymentStatusPending).
+		Updates(map[string]interface{}{
+			"status":         models.PaymentStatusFailed,
+			"failure_reason": reason,
+		}).Error
+}
+
+// CapturePayment captures the authorized payment of an order, which confirms the order. The
+// payment is marked capturing before the gateway is asked, so the order cannot be cancelled
+// while the gateway captures it without being locked meanwhile. Should the capture not be
+// recorded, the gateway's capture webhook records it later.
+func (s *PaymentService) CapturePayment(orderID, actorID uint) (*dto.PaymentResponse, error) {
+	var payment *models.Payment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return err
+		}
+		if order.Status == models.OrderStatusCancelled {
+			return ErrOrderNotPayable
+		}
+
+		var err error
+		if payment, err = s.authorizedPayment(tx, orderID); err != nil {
+			return err
+		}
+		return tx.Model(payment).Update("status", models.PaymentStatusCapturing).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.provider.Capture(payment.TransactionID, payment.Amount); err != nil {
+		// Without an answer the capture may have gone through, the webhook tells
+		if errors.Is(err, interfaces.ErrPaymentTimeout) {
+			return nil, err
+		}
+		if resetErr := s.db.Model(&models.Payment{}).
+			Where("id = ? AND status = ?", payment.ID, models.PaymentStatusCapturing).
+			Update("status", models.PaymentStatusAuthorized).Error; resetErr != nil {
+			return nil, resetErr
+		}
+		return nil, err
+	}
+
+	if err := s.orderService.ApplyPaymentStatus(payment.ID, models.PaymentStatusCaptured, actorID); err != nil {
+		return nil, err
+	}
+
+	return s.getPaymentResponse(payment.ID)
+}
+
+// VoidPayment releases the authorization of an order's payment without charging it
+func (s *PaymentService) VoidPayment(orderID, actorID uint) (*dto.PaymentResponse, error) {
+	payment, err := s.authorizedPayment(s.db, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.provider.Void(payment.TransactionID); err != nil {
+		return nil, err
+	}
+
+	if err := s.orderService.ApplyPaymentStatus(payment.ID, models.PaymentStatusVoided, actorID); err != nil {
+		return nil, err
+	}
+
+	return s.getPaymentResponse(payment.ID)
+}
+
+// HandleWebhook applies a verified gateway event. The event ID is recorded in the same
+// transaction, so a redelivered event is acknowledged without being applied twice.
+// It reports whether the event was new.
+func (s *PaymentService) HandleWebhook(event *dto.PaymentWebhookEvent) (bool, error) {
+	processed := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		record := models.WebhookEvent{
+			EventID:   event.ID,
+			EventType: event.Type,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		processed = true
+
+		status, ok := webhookPaymentStatuses[event.Type]
+		if !ok {
+			return nil
+		}
+
+		var payment models.Payment
+		if err := tx.Where("provider = ? AND transaction_id = ?", s.provider.Name(), event.Data.TransactionID).
+			First(&payment).Error; err != nil {
+			return err
+		}
+
+		// Only authorized payments and those being captured can still change, late or out of
+		// order events are ignored
+		if payment.Status != models.PaymentStatusAuthorized && payment.Status != models.PaymentStatusCapturing {
+			return nil
+		}
+
+		return s.orderService.applyPaymentStatus(tx, payment.ID, status, 0)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return processed, nil
+}
+
+// RefundOrder gives money back for the captured payment of an order. Without items every
+// quantity that has not been refunded yet is refunded. Returned quantities are put back in
+// stock when requested.
+//
+// The refund is stored as pending before the gateway is asked for it and completed once the
+// gateway paid it, so a refund the gateway made is never lost. Refunds an earlier request left
+// pending are asked for again first, under the same idempotency key, so they are not paid twice.
+func (s *PaymentService) RefundOrder(orderID, actorID uint, req *dto.CreateRefundRequest) (*dto.RefundResponse, error) {
+	if err := s.resumeRefunds(orderID); err != nil {
+		return nil, err
+	}
+
+	var refund models.Refund
+	var transactionID string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("OrderItems").
+			First(&order, orderID).Error; err != nil {
+			return err
+		}
+
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status IN ?", orderID, []models.PaymentStatus{models.PaymentStatusCaptured, models.PaymentStatusPartiallyRefunded}).
+			First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoCapturedPayment
+			}
+			return err
+		}
+
+		refunded, err := s.orderService.refundedQuantities(tx, orderID, false)
+		if err != nil {
+			return err
+		}
+
+		orderItems := make(map[uint]*models.OrderItem, len(order.OrderItems))
+		for i := range order.OrderItems {
+			orderItems[order.OrderItems[i].ID] = &order.OrderItems[i]
+		}
+
+		requested := req.Items
+		if len(requested) == 0 {
+			for i := range order.OrderItems {
+				item := &order.OrderItems[i]
+				if remaining := item.Quantity - refunded[item.ID]; remaining > 0 {
+					requested = append(requested, dto.RefundItemRequest{OrderItemID: item.ID, Quantity: remaining})
+				}
+			}
+		}
+
+		var amount money.Money
+		refundItems := make([]models.RefundItem, 0, len(requested))
+		for _, r := range requested {
+			item, ok := orderItems[r.OrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: order item %d does not belong to this order", ErrInvalidRefund, r.OrderItemID)
+			}
+
+			if remaining := item.Quantity - refunded[item.ID]; r.Quantity > remaining {
+				return fmt.Errorf("%w: only %d of order item %d can still be refunded", ErrInvalidRefund, remaining, item.ID)
+			}
+			before := refunded[item.ID]
+			refunded[item.ID] += r.Quantity
+
+			// Discounts and tax added on top of the price are refunded pro rata; allocating them
+			// cumulatively makes the last refund of an item return exactly what is left.
+			lineAmount := item.Price.Mul(r.Quantity)
+			if !item.DiscountAmount.IsZero() {
+				discountShare := item.DiscountAmount.Share(int64(refunded[item.ID]), int64(item.Quantity)).
+					Sub(item.DiscountAmount.Share(int64(before), int64(item.Quantity)))
+				lineAmount = lineAmount.Sub(discountShare)
+			}
+			if !item.TaxInclusive && !item.TaxAmount.IsZero() {
+				taxShare := item.TaxAmount.Share(int64(refunded[item.ID]), int64(item.Quantity)).
+					Sub(item.TaxAmount.Share(int64(before), int64(item.Quantity)))
+				lineAmount = lineAmount.Add(taxShare)
+			}
+			amount = amount.Add(lineAmount)
+			refundItems = append(refundItems, models.RefundItem{
+				OrderItemID: item.ID,
+				Quantity:    r.Quantity,
+				Amount:      lineAmount,
+			})
+		}
+
+		if len(refundItems) == 0 {
+			return fmt.Errorf("%w: nothing left to refund", ErrInvalidRefund)
+		}
+
+		var pendingAmount money.Money
+		if err := tx.Model(&models.Refund{}).Select("SUM(amount)").
+			Where("payment_id = ? AND status = ?", payment.ID, models.RefundStatusPending).
+			Scan(&pendingAmount).Error; err != nil {
+			return err
+		}
+		if amount.Cmp(payment.Amount.Sub(payment.RefundedAmount).Sub(pendingAmount)) > 0 {
+			return ErrRefundExceedsCapture
+		}
+
+		refund = models.Refund{
+			OrderID:        order.ID,
+			PaymentID:      payment.ID,
+			Amount:         amount,
+			Reason:         req.Reason,
+			Restocked:      req.Restock,
+			Status:         models.RefundStatusPending,
+			IdempotencyKey: uuid.New().String(),
+			CreatedBy:      actorID,
+			Items:          refundItems,
+		}
+		transactionID = payment.TransactionID
+		return tx.Create(&refund).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.settleRefund(&refund, transactionID); err != nil {
+		return nil, err
+	}
+
+	response := convertToRefundResponse(&refund)
+	return &response, nil
+}
+
+// resumeRefunds asks the gateway again for the refunds of an order that are still pending
+func (s *PaymentService) resumeRefunds(orderID uint) error {
+	var refunds []models.Refund
+	if err := s.db.Preload("Payment").Preload("Items").
+		Where("order_id = ? AND status = ?", orderID, models.RefundStatusPending).
+		Order("id ASC").
+		Find(&refunds).Error; err != nil {
+		return err
+	}
+
+	for i := range refunds {
+		if err := s.settleRefund(&refunds[i], refunds[i].Payment.TransactionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleRefund asks the gateway to pay a pending refund and records its answer. A refund the
+// gateway did not answer for in time stays pending, as it may have been paid.
+func (s *PaymentService) settleRefund(refund *models.Refund, transactionID string) error {
+	if _, err := s.provider.Refund(transactionID, refund.Amount, refund.IdempotencyKey); err != nil {
+		if errors.Is(err, interfaces.ErrPaymentTimeout) {
+			return err
+		}
+		if updateErr := s.db.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, models.RefundStatusPending).
+			Updates(map[string]interface{}{
+				"status":         models.RefundStatusFailed,
+				"failure_reason": err.Error(),
+			}).Error; updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	completed, err := s.completeRefund(refund)
+	if err != nil {
+		return err
+	}
+	refund.Status = models.RefundStatusCompleted
+
+	if completed {
+		s.orderService.publishOrderEvent(notifications.OrderRefunded, notifications.OrderEvent{
+			OrderID:      refund.OrderID,
+			Reason:       refund.Reason,
+			RefundAmount: refund.Amount,
+		})
+	}
+	return nil
+}
+
+// completeRefund records a refund the gateway paid against its payment and puts its
+// quantities back in stock when it restocks. It reports false when another request already
+// completed the refund. Subscribers of products the restock brings back are notified once it
+// is committed.
+func (s *PaymentService) completeRefund(refund *models.Refund) (bool, error) {
+	completed := false
+	var backInStock backInStockEvents
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("OrderItems.Allocations", func(db *gorm.DB) *gorm.DB {
+				return db.Order("id ASC")
+			}).
+			First(&order, refund.OrderID).Error; err != nil {
+			return err
+		}
+
+		var current models.Refund
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, refund.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != models.RefundStatusPending {
+			return nil
+		}
+
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, refund.PaymentID).Error; err != nil {
+			return err
+		}
+
+		refundedAmount := payment.RefundedAmount.Add(refund.Amount)
+		status := models.PaymentStatusPartiallyRefunded
+		if refundedAmount.Cmp(payment.Amount) >= 0 {
+			status = models.PaymentStatusRefunded
+		}
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"refunded_amount": refundedAmount,
+			"status":          status,
+		}).Error; err != nil {
+			return err
+		}
+
+		if refund.Restocked {
+			// Restocked units go back to the warehouses after the ones earlier returns went to
+			restocked, err := s.orderService.refundedQuantities(tx, order.ID, true)
+			if err != nil {
+				return err
+			}
+			orderItems := make(map[uint]*models.OrderItem, len(order.OrderItems))
+			productIDs := make([]uint, len(order.OrderItems))
+			for i := range order.OrderItems {
+				orderItems[order.OrderItems[i].ID] = &order.OrderItems[i]
+				productIDs[i] = order.OrderItems[i].ProductID
+			}
+			if _, err := s.inventoryService.lockProducts(tx, productIDs); err != nil {
+				return err
+			}
+
+			// Stock moves in variant id order after the products are locked, see lockProducts
+			items := append([]models.RefundItem(nil), refund.Items...)
+			sort.SliceStable(items, func(i, j int) bool {
+				return orderItems[items[i].OrderItemID].VariantID < orderItems[items[j].OrderItemID].VariantID
+			})
+			for i := range items {
+				item := orderItems[items[i].OrderItemID]
+				m := movementFor(models.MovementReasonReturn, refund.ID, refund.CreatedBy)
+				m.Restocked = &backInStock
+				if err := s.inventoryService.returnItem(tx, item, restocked[item.ID], items[i].Quantity, m); err != nil {
+					return err
+				}
+				restocked[item.ID] += items[i].Quantity
+			}
+		}
+
+		completed = true
+		return tx.Model(&current).Update("status", models.RefundStatusCompleted).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	s.inventoryService.publishBackInStock(backInStock...)
+
+	return completed, nil
+}
+
+func (s *PaymentService) authorizedPayment(db *gorm.DB, orderID uint) (*models.Payment, error) {
+	var payment models.Payment
+	if err := db.Where("order_id = ? AND status = ?", orderID, models.PaymentStatusAuthorized).
+		First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoAuthorizedPayment
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (s *PaymentService) getPaymentResponse(paymentID uint) (*dto.PaymentResponse, error) {
+	var payment models.Payment
+	if err := s.db.First(&payment, paymentID).Error; err != nil {
+		return nil, err
+	}
+
+	response := convertToPaymentResponse(&payment)
+	return &response, nil
+}
+
+func convertToPaymentResponse(payment *models.Payment) dto.PaymentResponse {
+	return dto.PaymentResponse{
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		Provider:       payment.Provider,
+		TransactionID:  payment.TransactionID,
+		Amount:         payment.Amount,
+		RefundedAmount: payment.RefundedAmount,
+		Currency:       money.BaseCurrency,
+		Status:         string(payment.Status),
+		FailureReason:  payment.FailureReason,
+		CreatedAt:      payment.CreatedAt.Format(defaultDateFormat),
+	}
+}
+
+func convertToRefundResponse(refund *models.Refund) dto.RefundResponse {
+	items := make([]dto.RefundItemResponse, len(refund.Items))
+	for i := range refund.Items {
+		items[i] = dto.RefundItemResponse{
+			OrderItemID: refund.Items[i].OrderItemID,
+			Quantity:    refund.Items[i].Quantity,
+			Amount:      refund.Items[i].Amount,
+		}
+	}
+
+	return dto.RefundResponse{
+		ID:            refund.ID,
+		OrderID:       refund.OrderID,
+		PaymentID:     refund.PaymentID,
+		Amount:        refund.Amount,
+		Currency:      money.BaseCurrency,
+		Reason:        refund.Reason,
+		Restocked:     refund.Restocked,
+		Status:        string(refund.Status),
+		FailureReason: refund.FailureReason,
+		CreatedBy:     refund.CreatedBy,
+		Items:         items,
+		CreatedAt:     refund.CreatedAt.Format(defaultDateFormat),
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/interfaces"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+var ErrInvalidCoupon = errors.New("coupon cannot be applied")
+
+// pricedLine is a cart or order line on its way through discounts and taxes
+type pricedLine struct {
+	ProductID  uint
+	CategoryID uint
+	TaxClass   string
+	Quantity   int
+	Amount     money.Money // price times quantity
+	Discount   money.Money
+}
+
+// discountLine is one discount shown on a cart or order
+type discountLine struct {
+	CouponID      *uint
+	PromotionID   *uint
+	PromotionName string
+	Code          string
+	Description   string
+	Amount        money.Money
+}
+
+// priceBreakdown prices a set of lines: subtotal, then discounts, then taxes on the discounted amounts
+type priceBreakdown struct {
+	Lines        []pricedLine
+	Subtotal     money.Money
+	Discounts    []discountLine
+	Discount     money.Money
+	FreeShipping bool
+	Taxes        *interfaces.TaxResult
+}
+
+func newPriceBreakdown(lines []pricedLine) *priceBreakdown {
+	b := &priceBreakdown{Lines: lines}
+	for i := range lines {
+		b.Subtotal = b.Subtotal.Add(lines[i].Amount)
+	}
+	return b
+}
+
+// cartPricedLines returns the cart's items as priced lines, in cart item order
+func cartPricedLines(cart *models.Cart) []pricedLine {
+	lines := make([]pricedLine, len(cart.CartItems))
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+		lines[i] = pricedLine{
+			ProductID:  item.ProductID,
+			CategoryID: item.Product.CategoryID,
+			TaxClass:   item.Product.TaxClass,
+			Quantity:   item.Quantity,
+			Amount:     item.Variant.UnitPrice(&item.Product).Mul(item.Quantity),
+		}
+	}
+	return lines
+}
+
+// applyCoupon validates the coupon against the lines and spreads its discount over the
+// eligible ones. Usage limits depend on stored redemptions and are checked by the CouponService.
+func (b *priceBreakdown) applyCoupon(coupon *models.Coupon, now time.Time) error {
+	if err := validateCouponWindow(coupon, now); err != nil {
+		return err
+	}
+	if b.Subtotal.Cmp(coupon.MinCartValue) < 0 {
+		return fmt.Errorf("%w: the cart must be at least %s", ErrInvalidCoupon, coupon.MinCartValue.Format())
+	}
+
+	eligible := couponEligibleLines(coupon, b.Lines)
+	if len(eligible) == 0 {
+		return fmt.Errorf("%w: no item in the cart qualifies", ErrInvalidCoupon)
+	}
+
+	var eligibleTotal money.Money
+	for _, i := range eligible {
+		eligibleTotal = eligibleTotal.Add(b.Lines[i].payable())
+	}
+
+	var amount money.Money
+	switch coupon.Type {
+	case models.CouponTypePercentage:
+		amount = eligibleTotal.Multiply(coupon.PercentOff.Percent())
+	case models.CouponTypeFixed:
+		amount = coupon.AmountOff
+	case models.CouponTypeFreeShipping:
+		b.FreeShipping = true
+	}
+	if amount.Cmp(eligibleTotal) > 0 {
+		amount = eligibleTotal
+	}
+
+	couponID := coupon.ID
+	b.addDiscount(discountLine{
+		CouponID:    &couponID,
+		Code:        coupon.Code,
+		Description: coupon.Description,
+		Amount:      amount,
+	}, eligible, eligibleTotal)
+	return nil
+}
+
+// addDiscount records a discount and allocates it over the given lines in proportion to
+// what is still payable on them, so refunds and taxes see the discounted line amounts.
+func (b *priceBreakdown) addDiscount(discount discountLine, lines []int, base money.Money) {
+	b.addLineDiscounts(discount, b.allocate(discount.Amount, lines, base))
+}
+
+// addLineDiscounts records a discount already split per line, indexed like b.Lines
+func (b *priceBreakdown) addLineDiscounts(discount discountLine, lineAmounts []money.Money) {
+	b.Discounts = append(b.Discounts, discount)
+	b.Discount = b.Discount.Add(discount.Amount)
+	for i := range lineAmounts {
+		b.Lines[i].Discount = b.Lines[i].Discount.Add(lineAmounts[i])
+	}
+}
+
+// allocate splits an amount over the given lines in proportion to what is still payable on
+// them. The result is indexed like b.Lines.
+func (b *priceBreakdown) allocate(amount money.Money, lines []int, base money.Money) []money.Money {
+	lineAmounts := make([]money.Money, len(b.Lines))
+	if amount.IsZero() || base.IsZero() {
+		return lineAmounts
+	}
+
+	// Allocate cumulatively so the shares add up to the amount exactly
+	var covered money.Money
+	var allocated money.Money
+	for _, i := range lines {
+		covered = covered.Add(b.Lines[i].payable())
+		upTo := amount.Share(covered.Amount, base.Amount)
+		lineAmounts[i] = upTo.Sub(allocated)
+		allocated = upTo
+	}
+	return lineAmounts
+}
+
+// payable is what is left to pay on the line after the discounts so far
+func (l *pricedLine) payable() money.Money {
+	return l.Amount.Sub(l.Discount)
+}
+
+// couponDiscount returns the part of the discount that came from the coupon
+func couponDiscount(b *priceBreakdown, couponID uint) money.Money {
+	var amount money.Money
+	for _, discount := range b.Discounts {
+		if discount.CouponID != nil && *discount.CouponID == couponID {
+			amount = amount.Add(discount.Amount)
+		}
+	}
+	return amount
+}
+
+// calculateTaxes taxes the discounted lines for the destination
+func (b *priceBreakdown) calculateTaxes(calculator interfaces.TaxCalculator, address interfaces.TaxAddress) error {
+	req := &interfaces.TaxRequest{
+		Address: address,
+		Items:   make([]interfaces.TaxableItem, len(b.Lines)),
+	}
+	for i := range b.Lines {
+		req.Items[i] = interfaces.TaxableItem{
+			TaxClass: b.Lines[i].TaxClass,
+			Amount:   b.Lines[i].payable(),
+		}
+	}
+
+	taxes, err := calculator.Calculate(req)
+	if err != nil {
+		return err
+	}
+	b.Taxes = taxes
+	return nil
+}
+
+// Total is the amount to pay: the discounted subtotal plus tax added on top
+func (b *priceBreakdown) Total() money.Money {
+	if b.Taxes == nil {
+		return b.Subtotal.Sub(b.Discount)
+	}
+	return b.Taxes.Total
+}
+
+func validateCouponWindow(coupon *models.Coupon, now time.Time) error {
+	switch {
+	case !coupon.IsActive:
+		return fmt.Errorf("%w: coupon is not active", ErrInvalidCoupon)
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return fmt.Errorf("%w: coupon is not valid yet", ErrInvalidCoupon)
+	case coupon.EndsAt != nil && !now.Before(*coupon.EndsAt):
+		return fmt.Errorf("%w: coupon has expired", ErrInvalidCoupon)
+	}
+	return nil
+}
+
+// couponEligibleLines returns the indexes of the lines the coupon applies to. A coupon
+// without product or category restrictions applies to every line.
+func couponEligibleLines(coupon *models.Coupon, lines []pricedLine) []int {
+	return eligibleLines(coupon.Products, coupon.Categories, lines)
+}
+
+// eligibleLines returns the indexes of the lines matching the product or category
+// restrictions, or of every line when there are none
+func eligibleLines(restrictProducts []models.Product, restrictCategories []models.Category, lines []pricedLine) []int {
+	restricted := len(restrictProducts) > 0 || len(restrictCategories) > 0
+
+	products := make(map[uint]bool, len(restrictProducts))
+	for i := range restrictProducts {
+		products[restrictProducts[i].ID] = true
+	}
+	categories := make(map[uint]bool, len(restrictCategories))
+	for i := range restrictCategories {
+		categories[restrictCategories[i].ID] = true
+	}
+
+	var eligible []int
+	for i := range lines {
+		if !restricted || products[lines[i].ProductID] || categories[lines[i].CategoryID] {
+			eligible = append(eligible, i)
+		}
+	}
+	return eligible
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func testPricedLines() []pricedLine {
+	return []pricedLine{
+		{ProductID: 1, CategoryID: 10, Quantity: 1, Amount: money.New(10_00, "USD")},
+		{ProductID: 2, CategoryID: 10, Quantity: 3, Amount: money.New(20_00, "USD")},
+		{ProductID: 3, CategoryID: 20, Quantity: 1, Amount: money.New(3_33, "USD")},
+	}
+}
+
+func lineDiscounts(b *priceBreakdown) money.Money {
+	var total money.Money
+	for i := range b.Lines {
+		total = total.Add(b.Lines[i].Discount)
+	}
+	return total
+}
+
+func TestApplyCouponPercentage(t *testing.T) {
+	b := newPriceBreakdown(testPricedLines())
+	coupon := &models.Coupon{Code: "SAVE15", Type: models.CouponTypePercentage, PercentOff: money.MustParseRate("15"), IsActive: true}
+
+	require.NoError(t, b.applyCoupon(coupon, time.Now()))
+
+	// 15% of 33.33 is 4.9995
+	assert.Equal(t, int64(5_00), b.Discount.Amount)
+	assert.Equal(t, b.Discount, lineDiscounts(b))
+	assert.Equal(t, int64(28_33), b.Total().Amount)
+	if assert.Len(t, b.Discounts, 1) {
+		assert.Equal(t, "SAVE15", b.Discounts[0].Code)
+	}
+}
+
+func TestApplyCouponFixedIsCappedAtEligibleTotal(t *testing.T) {
+	b := newPriceBreakdown(testPricedLines())
+	coupon := &models.Coupon{
+		Code:      "TENOFF",
+		Type:      models.CouponTypeFixed,
+		AmountOff: money.New(10_00, "USD"),
+		IsActive:  true,
+		Products:  []models.Product{{ID: 3}},
+	}
+
+	require.NoError(t, b.applyCoupon(coupon, time.Now()))
+
+	assert.Equal(t, int64(3_33), b.Discount.Amount)
+	assert.True(t, b.Lines[0].Discount.IsZero())
+	assert.True(t, b.Lines[1].Discount.IsZero())
+	assert.Equal(t, int64(3_33), b.Lines[2].Discount.Amount)
+}
+
+func TestApplyCouponAllocationSumsExactly(t *testing.T) {
+	lines := []pricedLine{
+		{ProductID: 1, Amount: money.New(1_00, "USD")},
+		{ProductID: 2, Amount: money.New(1_00, "USD")},
+		{ProductID: 3, Amount: money.New(1_00, "USD")},
+	}
+	b := newPriceBreakdown(lines)
+	coupon := &models.Coupon{Code: "ONE", Type: models.CouponTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true}
+
+	require.NoError(t, b.applyCoupon(coupon, time.Now()))
+
+	assert.Equal(t, int64(1_00), lineDiscounts(b).Amount)
+	for i := range b.Lines {
+		assert.InDelta(t, 33, b.Lines[i].Discount.Amount, 1)
+	}
+}
+
+func TestApplyCouponCategoryRestriction(t *testing.T) {
+	b := newPriceBreakdown(testPricedLines())
+	coupon := &models.Coupon{
+		Code:       "CAT10",
+		Type:       models.CouponTypePercentage,
+		PercentOff: money.MustParseRate("10"),
+		IsActive:   true,
+		Categories: []models.Category{{ID: 10}},
+	}
+
+	require.NoError(t, b.applyCoupon(coupon, time.Now()))
+
+	assert.Equal(t, int64(3_00), b.Discount.Amount)
+	assert.True(t, b.Lines[2].Discount.IsZero())
+
+	coupon.Categories = []models.Category{{ID: 99}}
+	err := newPriceBreakdown(testPricedLines()).applyCoupon(coupon, time.Now())
+	assert.True(t, errors.Is(err, ErrInvalidCoupon))
+}
+
+func TestApplyCouponFreeShipping(t *testing.T) {
+	b := newPriceBreakdown(testPricedLines())
+	coupon := &models.Coupon{Code: "SHIPFREE", Type: models.CouponTypeFreeShipping, IsActive: true}
+
+	require.NoError(t, b.applyCoupon(coupon, time.Now()))
+
+	assert.True(t, b.FreeShipping)
+	assert.True(t, b.Discount.IsZero())
+	assert.Equal(t, int64(33_33), b.Total().Amount)
+}
+
+func TestApplyCouponRejected(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	rejected := map[string]*models.Coupon{
+		"inactive":  {Type: models.CouponTypeFixed, AmountOff: money.New(1_00, "USD")},
+		"not yet":   {Type: models.CouponTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true, StartsAt: &later},
+		"expired":   {Type: models.CouponTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true, EndsAt: &earlier},
+		"min value": {Type: models.CouponTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true, MinCartValue: money.New(50_00, "USD")},
+	}
+	for name, coupon := range rejected {
+		b := newPriceBreakdown(testPricedLines())
+		err := b.applyCoupon(coupon, now)
+		assert.True(t, errors.Is(err, ErrInvalidCoupon), name)
+		assert.Empty(t, b.Discounts, name)
+	}
+}
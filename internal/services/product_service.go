@@ -1,34 +1,82 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
 	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/notifications"
 	"github.com/veetmoradiya3628/go-shop/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrProductInStock      = errors.New("product is in stock")
+	ErrCategoryCycle       = errors.New("category cannot be nested under itself")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
 )
 
 type ProductService struct {
-	db *gorm.DB
+	db               *gorm.DB
+	currencyService  *CurrencyService
+	inventoryService *InventoryService
 }
 
-func NewProductService(db *gorm.DB) *ProductService {
-	return &ProductService{db: db}
+func NewProductService(db *gorm.DB, currencyService *CurrencyService, inventoryService *InventoryService) *ProductService {
+	return &ProductService{db: db, currencyService: currencyService, inventoryService: inventoryService}
 }
 
+// CreateCategory creates a category, nested under its parent when one is given
 func (s *ProductService) CreateCategory(req *dto.CreateCategoryRequest) (*dto.CategoryResponse, error) {
 	category := models.Category{
+		ParentID:    req.ParentID,
 		Name:        req.Name,
 		Description: req.Description,
 	}
-	if err := s.db.Create(&category).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if req.ParentID != nil {
+			if err := tx.Select("id").First(&models.Category{}, *req.ParentID).Error; err != nil {
+				return fmt.Errorf("parent category: %w", err)
+			}
+		}
+
+		var err error
+		category.Slug, err = categorySlugs.initial(tx, req.Slug, req.Name)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		return categorySlugs.moved(tx, category.ID, "", category.Slug)
+	})
+	if err != nil {
 		return nil, err
 	}
-	return &dto.CategoryResponse{
-		ID:          category.ID,
-		Name:        category.Name,
-		Description: category.Description,
-		IsActive:    category.IsActive,
-	}, nil
+	response := convertToCategoryResponse(&category)
+	return &response, nil
+}
+
+// GetCategoryBySlug returns the category with the slug. For a slug the category had before,
+// only its current slug is returned.
+func (s *ProductService) GetCategoryBySlug(slug string) (*dto.CategoryResponse, *dto.SlugRedirectResponse, error) {
+	id, moved, err := categorySlugs.resolve(s.db, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var category models.Category
+	if err := s.db.First(&category, id).Error; err != nil {
+		return nil, nil, err
+	}
+	if moved {
+		return nil, &dto.SlugRedirectResponse{Slug: category.Slug}, nil
+	}
+	response := convertToCategoryResponse(&category)
+	return &response, nil, nil
 }
 
 func (s *ProductService) GetCategories() ([]dto.CategoryResponse, error) {
@@ -38,79 +86,273 @@ func (s *ProductService) GetCategories() ([]dto.CategoryResponse, error) {
 	}
 	response := make([]dto.CategoryResponse, len(categories))
 	for i := range categories {
-		response[i] = dto.CategoryResponse{
-			ID:          categories[i].ID,
-			Name:        categories[i].Name,
-			Description: categories[i].Description,
-			IsActive:    categories[i].IsActive,
-		}
+		response[i] = convertToCategoryResponse(&categories[i])
 	}
 	return response, nil
 }
 
-func (s *ProductService) UpdateCategory(id uint, req *dto.UpdateCategoryRequest) (*dto.CategoryResponse, error) {
-	var category models.Category
-	if err := s.db.First(&category, id).Error; err != nil {
+// GetCategoryTree returns the active categories nested under their parents. The children
+// of an inactive category are left out with it.
+func (s *ProductService) GetCategoryTree() ([]dto.CategoryTreeResponse, error) {
+	var categories []models.Category
+	if err := s.db.Where("is_active = ?", true).Order("name ASC").Find(&categories).Error; err != nil {
 		return nil, err
 	}
-	category.Name = req.Name
-	category.Description = req.Description
-	if req.IsActive != nil {
-		category.IsActive = *req.IsActive
+
+	children := make(map[uint][]*models.Category)
+	var roots []*models.Category
+	for i := range categories {
+		if categories[i].ParentID == nil {
+			roots = append(roots, &categories[i])
+			continue
+		}
+		children[*categories[i].ParentID] = append(children[*categories[i].ParentID], &categories[i])
 	}
-	if err := s.db.Save(&category).Error; err != nil {
+
+	var build func(level []*models.Category) []dto.CategoryTreeResponse
+	build = func(level []*models.Category) []dto.CategoryTreeResponse {
+		nodes := make([]dto.CategoryTreeResponse, len(level))
+		for i, category := range level {
+			nodes[i] = dto.CategoryTreeResponse{
+				CategoryResponse: convertToCategoryResponse(category),
+				Children:         build(children[category.ID]),
+			}
+		}
+		return nodes
+	}
+	return build(roots), nil
+}
+
+// UpdateCategory updates a category. Moving it under one of its own descendants is refused.
+func (s *ProductService) UpdateCategory(id uint, req *dto.UpdateCategoryRequest) (*dto.CategoryResponse, error) {
+	var category models.Category
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
+			return err
+		}
+		oldSlug := category.Slug
+		slug, err := categorySlugs.next(tx, category.ID, category.Slug, req.Slug, category.Name, req.Name)
+		if err != nil {
+			return err
+		}
+		category.Slug = slug
+		category.Name = req.Name
+		category.Description = req.Description
+		if req.IsActive != nil {
+			category.IsActive = *req.IsActive
+		}
+		if req.ParentID != nil {
+			category.ParentID = nil
+			if *req.ParentID != 0 {
+				if err := ensureNotDescendant(tx, category.ID, *req.ParentID); err != nil {
+					return err
+				}
+				category.ParentID = req.ParentID
+			}
+		}
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+		return categorySlugs.moved(tx, category.ID, oldSlug, category.Slug)
+	})
+	if err != nil {
 		return nil, err
 	}
-	return &dto.CategoryResponse{
+	response := convertToCategoryResponse(&category)
+	return &response, nil
+}
+
+// DeleteCategory deletes a category. A category with children is only deleted when they are
+// moved to another parent, moveChildrenTo, with 0 moving them to the top level.
+func (s *ProductService) DeleteCategory(id uint, moveChildrenTo *uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			if moveChildrenTo == nil {
+				return fmt.Errorf("%w: %d subcategories", ErrCategoryHasChildren, children)
+			}
+			var parentID *uint
+			if *moveChildrenTo != 0 {
+				if err := ensureNotDescendant(tx, id, *moveChildrenTo); err != nil {
+					return err
+				}
+				parentID = moveChildrenTo
+			}
+			if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", parentID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&category).Error
+	})
+}
+
+// ensureNotDescendant makes sure parentID exists and is neither the category nor one of its
+// descendants, so nesting the category under it keeps the tree free of cycles. The ancestors
+// are locked, so concurrent moves cannot close a cycle between them.
+func ensureNotDescendant(tx *gorm.DB, categoryID, parentID uint) error {
+	for id := parentID; ; {
+		if id == categoryID {
+			return fmt.Errorf("%w: category %d is the category itself or one of its subcategories", ErrCategoryCycle, parentID)
+		}
+		var ancestor models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "parent_id").First(&ancestor, id).Error; err != nil {
+			return fmt.Errorf("parent category: %w", err)
+		}
+		if ancestor.ParentID == nil {
+			return nil
+		}
+		id = *ancestor.ParentID
+	}
+}
+
+// categoryWithDescendants selects the ID of a category and of every category below it
+func categoryWithDescendants(db *gorm.DB, categoryID uint) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id WHERE categories.deleted_at IS NULL
+	) SELECT id FROM tree`, categoryID)
+}
+
+func convertToCategoryResponse(category *models.Category) dto.CategoryResponse {
+	return dto.CategoryResponse{
 		ID:          category.ID,
+		ParentID:    category.ParentID,
 		Name:        category.Name,
+		Slug:        category.Slug,
 		Description: category.Description,
 		IsActive:    category.IsActive,
-	}, nil
-}
-
-func (s *ProductService) DeleteCategory(id uint) error {
-	if err := s.db.Delete(&models.Category{}, id).Error; err != nil {
-		return err
 	}
-	return nil
 }
 
-func (s *ProductService) CreateProduct(req *dto.CreateProductRequest) (*dto.ProductResponse, error) {
+// CreateProduct creates a product with the requested variants, or with a single default
+// variant carrying the product's SKU and stock. Initial stock is booked in the inventory
+// ledger and kept in the primary warehouse.
+func (s *ProductService) CreateProduct(req *dto.CreateProductRequest, actorID uint) (*dto.ProductResponse, error) {
 	product := models.Product{
-		CategoryID:  req.CategoryID,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Stock:       req.Stock,
-		SKU:         req.SKU,
+		CategoryID:       req.CategoryID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Price:            req.Price,
+		SKU:              req.SKU,
+		TaxClass:         req.TaxClass,
+		ReorderThreshold: req.ReorderThreshold,
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = models.TaxClassStandard
 	}
-	if err := s.db.Create(&product).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		product.Slug, err = productSlugs.initial(tx, req.Slug, req.Name)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if err := productSlugs.moved(tx, product.ID, "", product.Slug); err != nil {
+			return err
+		}
+
+		variants := req.Variants
+		if len(variants) == 0 {
+			variants = []dto.ProductVariantRequest{{SKU: product.SKU, Stock: req.Stock}}
+		}
+		for i := range variants {
+			if _, err := s.createVariant(tx, &product, &variants[i], i == 0, actorID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return s.GetProduct(product.ID)
+	return s.GetProduct(product.ID, "")
+}
+
+// productFromPrice is the lowest price a product can be bought for, that of its cheapest active
+// variant. Products without active variants fall back to their own price.
+const productFromPrice = `COALESCE((SELECT MIN(COALESCE(product_variants.price, products.price)) FROM product_variants
+WHERE product_variants.product_id = products.id AND product_variants.is_active = true AND product_variants.deleted_at IS NULL), products.price)`
+
+// productSorts maps the sort options of the product listing to their ORDER BY clause
+var productSorts = map[string]string{
+	"price_asc":  productFromPrice + " ASC, id ASC",
+	"price_desc": productFromPrice + " DESC, id ASC",
+	"newest":     "created_at DESC, id DESC",
+	"name":       "name ASC, id ASC",
 }
 
-func (s *ProductService) GetProducts(page, limit int) ([]dto.ProductResponse, *utils.PaginationMeta, error) {
+// GetProducts returns the active products matching the listing filters
+func (s *ProductService) GetProducts(query *dto.ProductListQuery, currency string) ([]dto.ProductResponse, *utils.PaginationMeta, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, limit := query.Page, query.Limit
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 {
 		limit = 10
 	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	filtered := s.db.Model(&models.Product{}).Where("is_active = ?", true)
+	if query.CategoryID != 0 {
+		filtered = filtered.Where("category_id IN (?)", categoryWithDescendants(s.db, query.CategoryID))
+	}
+	if query.MinPrice != nil {
+		filtered = filtered.Where(productFromPrice+" >= ?", *query.MinPrice)
+	}
+	if query.MaxPrice != nil {
+		filtered = filtered.Where(productFromPrice+" <= ?", *query.MaxPrice)
+	}
+	if query.InStock {
+		filtered = filtered.Where("stock > 0")
+	}
+	if q := strings.TrimSpace(query.Q); q != "" {
+		pattern := "%" + likeEscaper.Replace(q) + "%"
+		filtered = filtered.Where("(name ILIKE ? OR description ILIKE ? OR sku ILIKE ?)", pattern, pattern, pattern)
+	}
+
+	sort, ok := productSorts[query.Sort]
+	if !ok {
+		sort = productSorts["newest"]
+	}
+
 	offset := (page - 1) * limit
 	var products []models.Product
 	var total int64
-	s.db.Model(&models.Product{}).Where("is_active = ?", true).Count(&total)
-	if err := s.db.Preload("Category").Preload("Images").
-		Where("is_active = ?", true).
+
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.withVariants(filtered.Session(&gorm.Session{})).
+		Preload("Category").Preload("Images").
+		Order(sort).
 		Offset(offset).Limit(limit).
 		Find(&products).Error; err != nil {
 		return nil, nil, err
 	}
+
 	response := make([]dto.ProductResponse, len(products))
 	for i := range products {
-		response[i] = s.convertToProductResponse(&products[i])
+		response[i] = s.convertToProductResponse(&products[i], display)
 	}
 	meta := &utils.PaginationMeta{
 		Total:      total,
@@ -121,32 +363,131 @@ func (s *ProductService) GetProducts(page, limit int) ([]dto.ProductResponse, *u
 	return response, meta, nil
 }
 
-func (s *ProductService) GetProduct(id uint) (*dto.ProductResponse, error) {
+// likeEscaper escapes the LIKE wildcards in user input, so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (s *ProductService) GetProduct(id uint, currency string) (*dto.ProductResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
 	var product models.Product
-	if err := s.db.Preload("Category").Preload("Images").First(&product, id).Error; err != nil {
+	if err := s.withVariants(s.db).Preload("Category").Preload("Images").First(&product, id).Error; err != nil {
 		return nil, err
 	}
-	response := s.convertToProductResponse(&product)
+	response := s.convertToProductResponse(&product, display)
 	return &response, nil
 }
 
-func (s *ProductService) UpdateProduct(id uint, req *dto.UpdateProductRequest) (*dto.ProductResponse, error) {
+// GetProductBySlug returns the product with the slug. For a slug the product had before, only
+// its current slug is returned.
+func (s *ProductService) GetProductBySlug(slug, currency string) (*dto.ProductResponse, *dto.SlugRedirectResponse, error) {
+	id, moved, err := productSlugs.resolve(s.db, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	product, err := s.GetProduct(id, currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	if moved {
+		return nil, &dto.SlugRedirectResponse{Slug: product.Slug}, nil
+	}
+	return product, nil, nil
+}
+
+// UpdateProduct updates a product. A changed stock count is booked as a manual adjustment
+// of the default variant in the primary warehouse; products with several variants have their
+// stock changed per variant.
+func (s *ProductService) UpdateProduct(id uint, req *dto.UpdateProductRequest, actorID uint) (*dto.ProductResponse, error) {
+	var restocked *notifications.BackInStockEvent
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error; err != nil {
+			return err
+		}
+		oldSlug := product.Slug
+		slug, err := productSlugs.next(tx, product.ID, product.Slug, req.Slug, product.Name, req.Name)
+		if err != nil {
+			return err
+		}
+		product.Slug = slug
+		product.CategoryID = req.CategoryID
+		product.Name = req.Name
+		product.Description = req.Description
+		product.Price = req.Price
+		if req.TaxClass != "" {
+			product.TaxClass = req.TaxClass
+		}
+		if req.IsActive != nil {
+			product.IsActive = *req.IsActive
+		}
+		product.ReorderThreshold = req.ReorderThreshold
+		if err := tx.Omit("Stock", "StockAlert").Save(&product).Error; err != nil {
+			return err
+		}
+		if err := productSlugs.moved(tx, product.ID, oldSlug, product.Slug); err != nil {
+			return err
+		}
+		if req.Stock != product.Stock {
+			var variants int64
+			if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variants).Error; err != nil {
+				return err
+			}
+			if variants > 1 {
+				return fmt.Errorf("%w: the product has %d variants, change their stock instead", ErrInvalidStockAdjustment, variants)
+			}
+			variant, err := findVariant(tx, product.ID, 0)
+			if err != nil {
+				return err
+			}
+			if err := s.inventoryService.setTotal(tx, variant, req.Stock, movementFor(models.MovementReasonAdjustment, 0, actorID)); err != nil {
+				return err
+			}
+		}
+		// A lowered threshold can end a low stock alert without any stock coming in
+		if err := s.inventoryService.settleStockAlert(tx, product.ID); err != nil {
+			return err
+		}
+
+		restocked, err = s.inventoryService.backInStock(tx, product.ID, product.Stock)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.inventoryService.publishBackInStock(restocked)
+
+	return s.GetProduct(id, "")
+}
+
+// NotifyWhenInStock subscribes the user to an email once the out of stock product can be
+// ordered again. Subscribing twice keeps the first subscription.
+func (s *ProductService) NotifyWhenInStock(userID, productID uint) (*dto.BackInStockSubscriptionResponse, error) {
 	var product models.Product
-	if err := s.db.First(&product, id).Error; err != nil {
+	if err := s.db.Select("id", "stock").Where("is_active = ?", true).First(&product, productID).Error; err != nil {
 		return nil, err
 	}
-	product.CategoryID = req.CategoryID
-	product.Name = req.Name
-	product.Description = req.Description
-	product.Price = req.Price
-	product.Stock = req.Stock
-	if req.IsActive != nil {
-		product.IsActive = *req.IsActive
+	if product.Stock > 0 {
+		return nil, ErrProductInStock
+	}
+
+	subscription := models.BackInStockSubscription{UserID: userID, ProductID: productID}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&subscription).Error; err != nil {
+		return nil, err
 	}
-	if err := s.db.Save(&product).Error; err != nil {
+	if err := s.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&subscription).Error; err != nil {
 		return nil, err
 	}
-	return s.GetProduct(product.ID)
+
+	return &dto.BackInStockSubscriptionResponse{
+		ProductID: subscription.ProductID,
+		CreatedAt: subscription.CreatedAt.Format(defaultDateFormat),
+	}, nil
 }
 
 func (s *ProductService) DeleteProduct(id uint) error {
@@ -156,11 +497,20 @@ func (s *ProductService) DeleteProduct(id uint) error {
 	return nil
 }
 
-func (s *ProductService) AddProductImage(productID uint, url, altText string) error {
+// AddProductImage adds an image to a product, shown for one of its variants when variantID
+// is set
+func (s *ProductService) AddProductImage(productID uint, variantID *uint, url, altText string) error {
+	if variantID != nil {
+		if _, err := findVariant(s.db, productID, *variantID); err != nil {
+			return err
+		}
+	}
+
 	var count int64
 	s.db.Model(&models.ProductImage{}).Where("product_id = ?", productID).Count(&count)
 	image := models.ProductImage{
 		ProductID: productID,
+		VariantID: variantID,
 		URL:       url,
 		AltText:   altText,
 		IsPrimary: count == 0, // First image is primary
@@ -168,31 +518,90 @@ func (s *ProductService) AddProductImage(productID uint, url, altText string) er
 	return s.db.Create(&image).Error
 }
 
-func (s *ProductService) convertToProductResponse(product *models.Product) dto.ProductResponse {
+func (s *ProductService) convertToProductResponse(product *models.Product, display displayCurrency) dto.ProductResponse {
 	images := make([]dto.ProductImageResponse, len(product.Images))
 	for i := range product.Images {
 		images[i] = dto.ProductImageResponse{
 			ID:        product.Images[i].ID,
+			VariantID: product.Images[i].VariantID,
 			URL:       product.Images[i].URL,
 			AltText:   product.Images[i].AltText,
 			IsPrimary: product.Images[i].IsPrimary,
 		}
 	}
+
+	// The option matrix only offers values some variant has
+	optionNames := make(map[uint]string, len(product.Options))
+	used := make(map[uint]bool)
+	for i := range product.Variants {
+		for _, value := range product.Variants[i].OptionValues {
+			used[value.ID] = true
+		}
+	}
+	options := make([]dto.ProductOptionResponse, 0, len(product.Options))
+	for _, option := range product.Options {
+		optionNames[option.ID] = option.Name
+		response := dto.ProductOptionResponse{Name: option.Name, Values: []string{}}
+		for _, value := range option.Values {
+			if used[value.ID] {
+				response.Values = append(response.Values, value.Value)
+			}
+		}
+		options = append(options, response)
+	}
+
+	variants := make([]dto.ProductVariantResponse, len(product.Variants))
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		variants[i] = dto.ProductVariantResponse{
+			ID:        variant.ID,
+			SKU:       variant.SKU,
+			Price:     display.convert(variant.UnitPrice(product)),
+			Stock:     variant.Stock,
+			IsDefault: variant.IsDefault,
+			IsActive:  variant.IsActive,
+			Options:   make(map[string]string, len(variant.OptionValues)),
+			Images:    []dto.ProductImageResponse{},
+		}
+		for _, value := range variant.OptionValues {
+			variants[i].Options[optionNames[value.OptionID]] = value.Value
+		}
+		for _, image := range images {
+			if image.VariantID != nil && *image.VariantID == variant.ID {
+				variants[i].Images = append(variants[i].Images, image)
+			}
+		}
+	}
+
 	return dto.ProductResponse{
-		ID:          product.ID,
-		CategoryID:  product.CategoryID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		SKU:         product.SKU,
-		IsActive:    product.IsActive,
-		Category: dto.CategoryResponse{
-			ID:          product.Category.ID,
-			Name:        product.Category.Name,
-			Description: product.Category.Description,
-			IsActive:    product.Category.IsActive,
-		},
-		Images: images,
+		ID:               product.ID,
+		CategoryID:       product.CategoryID,
+		Name:             product.Name,
+		Slug:             product.Slug,
+		Description:      product.Description,
+		Price:            display.convert(product.Price),
+		Currency:         display.code,
+		Stock:            product.Stock,
+		SKU:              product.SKU,
+		TaxClass:         product.TaxClass,
+		IsActive:         product.IsActive,
+		Category:         convertToCategoryResponse(&product.Category),
+		ReorderThreshold: product.ReorderThreshold,
+		AverageRating:    product.AverageRating(),
+		ReviewCount:      product.ReviewCount,
+		Images:           images,
+		Options:          options,
+		Variants:         variants,
 	}
 }
+
+// withVariants preloads a product's option matrix and variants, the default variant first
+func (s *ProductService) withVariants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload("Options.Values", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("is_default DESC, id ASC")
+	}).Preload("Variants.OptionValues")
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func TestCategoryTree(t *testing.T) {
+	reservations := newTestReservationService(t)
+	service := NewProductService(reservations.db, NewCurrencyService(reservations.db), reservations.inventoryService)
+
+	create := func(name string, parentID *uint) uint {
+		category, err := service.CreateCategory(&dto.CreateCategoryRequest{Name: name, ParentID: parentID})
+		require.NoError(t, err)
+		return category.ID
+	}
+	apparel := create("Apparel", nil)
+	men := create("Men", &apparel)
+	shirts := create("Shirts", &men)
+
+	tree, err := service.GetCategoryTree()
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.Len(t, tree[0].Children, 1)
+	require.Len(t, tree[0].Children[0].Children, 1)
+	assert.Equal(t, shirts, tree[0].Children[0].Children[0].ID)
+
+	// Nesting a category under itself or its descendants is refused
+	_, err = service.UpdateCategory(apparel, &dto.UpdateCategoryRequest{Name: "Apparel", ParentID: &shirts})
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	_, err = service.UpdateCategory(men, &dto.UpdateCategoryRequest{Name: "Men", ParentID: &men})
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+
+	// Products of subcategories are listed with their ancestors
+	product, err := service.CreateProduct(&dto.CreateProductRequest{CategoryID: shirts, Name: "Oxford", Price: money.New(25_00, "USD"), SKU: "OXFORD"}, 0)
+	require.NoError(t, err)
+	products, _, err := service.GetProducts(&dto.ProductListQuery{CategoryID: apparel}, "")
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, product.ID, products[0].ID)
+
+	// Children are never orphaned
+	assert.ErrorIs(t, service.DeleteCategory(men, nil), ErrCategoryHasChildren)
+	assert.ErrorIs(t, service.DeleteCategory(men, &shirts), ErrCategoryCycle)
+	require.NoError(t, service.DeleteCategory(men, &apparel))
+	category, err := service.UpdateCategory(shirts, &dto.UpdateCategoryRequest{Name: "Shirts"})
+	require.NoError(t, err)
+	assert.Equal(t, apparel, *category.ParentID)
+}
+
+func TestSlugsFollowRenames(t *testing.T) {
+	reservations := newTestReservationService(t)
+	service := NewProductService(reservations.db, NewCurrencyService(reservations.db), reservations.inventoryService)
+
+	category, err := service.CreateCategory(&dto.CreateCategoryRequest{Name: "Hats"})
+	require.NoError(t, err)
+	create := func(name, sku string) *dto.ProductResponse {
+		product, err := service.CreateProduct(&dto.CreateProductRequest{CategoryID: category.ID, Name: name, Price: money.New(25_00, "USD"), SKU: sku}, 0)
+		require.NoError(t, err)
+		return product
+	}
+	first := create("Café Beret", "BERET-1")
+	second := create("Café Beret", "BERET-2")
+	assert.Equal(t, "cafe-beret", first.Slug)
+	assert.Equal(t, "cafe-beret-2", second.Slug)
+
+	// Renaming generates a new slug and the old one points to it
+	renamed, err := service.UpdateProduct(first.ID, &dto.UpdateProductRequest{CategoryID: category.ID, Name: "Wool Beret", Price: first.Price}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "wool-beret", renamed.Slug)
+
+	_, redirect, err := service.GetProductBySlug("cafe-beret", "")
+	require.NoError(t, err)
+	require.NotNil(t, redirect)
+	assert.Equal(t, "wool-beret", redirect.Slug)
+
+	// A slug given by hand must be free, a freed slug can be taken over
+	_, err = service.UpdateProduct(second.ID, &dto.UpdateProductRequest{CategoryID: category.ID, Name: "Café Beret", Slug: "wool-beret", Price: second.Price}, 0)
+	assert.ErrorIs(t, err, ErrSlugTaken)
+	_, err = service.UpdateProduct(second.ID, &dto.UpdateProductRequest{CategoryID: category.ID, Name: "Café Beret", Slug: "cafe-beret", Price: second.Price}, 0)
+	require.NoError(t, err)
+
+	product, redirect, err := service.GetProductBySlug("cafe-beret", "")
+	require.NoError(t, err)
+	assert.Nil(t, redirect)
+	assert.Equal(t, second.ID, product.ID)
+
+	_, redirect, err = service.GetProductBySlug("cafe-beret-2", "")
+	require.NoError(t, err)
+	require.NotNil(t, redirect)
+	assert.Equal(t, "cafe-beret", redirect.Slug)
+
+	_, err = service.UpdateCategory(category.ID, &dto.UpdateCategoryRequest{Name: "Caps"})
+	require.NoError(t, err)
+	_, categoryRedirect, err := service.GetCategoryBySlug("hats")
+	require.NoError(t, err)
+	require.NotNil(t, categoryRedirect)
+	assert.Equal(t, "caps", categoryRedirect.Slug)
+}
+
+func TestProductListingPricesByCheapestVariant(t *testing.T) {
+	reservations := newTestReservationService(t)
+	service := NewProductService(reservations.db, NewCurrencyService(reservations.db), reservations.inventoryService)
+
+	category, err := service.CreateCategory(&dto.CreateCategoryRequest{Name: "Scarves"})
+	require.NoError(t, err)
+	create := func(name, sku string, price int64) uint {
+		product, err := service.CreateProduct(&dto.CreateProductRequest{CategoryID: category.ID, Name: name, Price: money.New(price, "USD"), SKU: sku}, 0)
+		require.NoError(t, err)
+		return product.ID
+	}
+	linen := create("Linen Scarf", "SCARF-LINEN", 30_00)
+	cotton := create("Cotton Scarf", "SCARF-COTTON", 20_00)
+	// The linen scarf's only variant sells for less than the product price
+	require.NoError(t, reservations.db.Model(&models.ProductVariant{}).Where("product_id = ?", linen).Update("price", money.New(10_00, "USD")).Error)
+
+	list := func(query dto.ProductListQuery) []uint {
+		t.Helper()
+		query.CategoryID = category.ID
+		products, _, err := service.GetProducts(&query, "")
+		require.NoError(t, err)
+		ids := make([]uint, len(products))
+		for i := range products {
+			ids[i] = products[i].ID
+		}
+		return ids
+	}
+	below := money.New(15_00, "USD")
+	assert.Equal(t, []uint{linen}, list(dto.ProductListQuery{MaxPrice: &below}))
+	assert.Equal(t, []uint{cotton}, list(dto.ProductListQuery{MinPrice: &below}))
+	assert.Equal(t, []uint{linen, cotton}, list(dto.ProductListQuery{Sort: "price_asc"}))
+	assert.Equal(t, []uint{cotton, linen}, list(dto.ProductListQuery{Sort: "price_desc"}))
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPromotionRequest = errors.New("invalid promotion")
+
+type PromotionService struct {
+	db *gorm.DB
+}
+
+func NewPromotionService(db *gorm.DB) *PromotionService {
+	return &PromotionService{db: db}
+}
+
+// withPromotionDetails preloads everything the evaluator and convertToPromotionResponse need
+func withPromotionDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tiers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("min_quantity ASC")
+	}).Preload("Categories").Preload("Products")
+}
+
+func (s *PromotionService) GetPromotions() ([]dto.PromotionResponse, error) {
+	var promotions []models.Promotion
+	if err := withPromotionDetails(s.db).
+		Order("priority DESC, id ASC").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.PromotionResponse, len(promotions))
+	for i := range promotions {
+		response[i] = s.convertToPromotionResponse(&promotions[i])
+	}
+	return response, nil
+}
+
+func (s *PromotionService) CreatePromotion(req *dto.PromotionRequest) (*dto.PromotionResponse, error) {
+	promotion := models.Promotion{IsActive: true}
+	if err := s.applyPromotionRequest(s.db, &promotion, req); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&promotion).Error; err != nil {
+			return err
+		}
+		// gorm skips false on create because of the column default
+		if !promotion.IsActive {
+			return tx.Model(&promotion).Update("is_active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getPromotion(promotion.ID)
+}
+
+func (s *PromotionService) UpdatePromotion(id uint, req *dto.PromotionRequest) (*dto.PromotionResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var promotion models.Promotion
+		if err := tx.First(&promotion, id).Error; err != nil {
+			return err
+		}
+
+		if err := s.applyPromotionRequest(tx, &promotion, req); err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Tiers", "Categories", "Products").Save(&promotion).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("promotion_id = ?", promotion.ID).Delete(&models.PromotionTier{}).Error; err != nil {
+			return err
+		}
+		if len(promotion.Tiers) > 0 {
+			if err := tx.Create(&promotion.Tiers).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&promotion).Association("Categories").Replace(promotion.Categories); err != nil {
+			return err
+		}
+		return tx.Model(&promotion).Association("Products").Replace(promotion.Products)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getPromotion(id)
+}
+
+func (s *PromotionService) DeletePromotion(id uint) error {
+	result := s.db.Delete(&models.Promotion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *PromotionService) getPromotion(id uint) (*dto.PromotionResponse, error) {
+	var promotion models.Promotion
+	if err := withPromotionDetails(s.db).First(&promotion, id).Error; err != nil {
+		return nil, err
+	}
+
+	response := s.convertToPromotionResponse(&promotion)
+	return &response, nil
+}
+
+// activePromotions loads the promotions running at the given time
+func (s *PromotionService) activePromotions(tx *gorm.DB, now time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := withPromotionDetails(tx).
+		Where("is_active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Find(&promotions).Error
+	return promotions, err
+}
+
+func (s *PromotionService) applyPromotionRequest(tx *gorm.DB, promotion *models.Promotion, req *dto.PromotionRequest) error {
+	promotionType := models.PromotionType(req.Type)
+	switch {
+	case promotionType == models.PromotionTypePercentage && req.PercentOff.IsZero():
+		return fmt.Errorf("%w: percentage promotions need percent_off", ErrInvalidPromotionRequest)
+	case promotionType == models.PromotionTypeFixed && !req.AmountOff.IsPositive():
+		return fmt.Errorf("%w: fixed promotions need amount_off", ErrInvalidPromotionRequest)
+	case promotionType == models.PromotionTypeBuyXGetY && (req.BuyQuantity < 1 || req.GetQuantity < 1):
+		return fmt.Errorf("%w: buy_x_get_y promotions need buy_quantity and get_quantity", ErrInvalidPromotionRequest)
+	case promotionType == models.PromotionTypeTiered && len(req.Tiers) == 0:
+		return fmt.Errorf("%w: tiered promotions need tiers", ErrInvalidPromotionRequest)
+	case req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotionRequest)
+	}
+
+	promotion.Name = req.Name
+	promotion.Description = req.Description
+	promotion.Type = promotionType
+	promotion.Priority = req.Priority
+	promotion.Stackable = req.Stackable
+	promotion.PercentOff = money.MustParseRate("0")
+	promotion.AmountOff = money.Zero(money.BaseCurrency)
+	promotion.BuyQuantity = 0
+	promotion.GetQuantity = 0
+	promotion.Tiers = nil
+	switch promotionType {
+	case models.PromotionTypePercentage:
+		promotion.PercentOff = req.PercentOff
+	case models.PromotionTypeFixed:
+		promotion.AmountOff = req.AmountOff
+	case models.PromotionTypeBuyXGetY:
+		promotion.BuyQuantity = req.BuyQuantity
+		promotion.GetQuantity = req.GetQuantity
+	case models.PromotionTypeTiered:
+		seen := make(map[int]bool, len(req.Tiers))
+		for _, tier := range req.Tiers {
+			if seen[tier.MinQuantity] {
+				return fmt.Errorf("%w: duplicate tier for min_quantity %d", ErrInvalidPromotionRequest, tier.MinQuantity)
+			}
+			seen[tier.MinQuantity] = true
+			promotion.Tiers = append(promotion.Tiers, models.PromotionTier{
+				PromotionID: promotion.ID,
+				MinQuantity: tier.MinQuantity,
+				PercentOff:  tier.PercentOff,
+			})
+		}
+	}
+	promotion.MinCartValue = req.MinCartValue
+	promotion.StartsAt = req.StartsAt
+	promotion.EndsAt = req.EndsAt
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+
+	promotion.Categories = nil
+	if len(req.CategoryIDs) > 0 {
+		if err := tx.Find(&promotion.Categories, req.CategoryIDs).Error; err != nil {
+			return err
+		}
+		if len(promotion.Categories) != len(req.CategoryIDs) {
+			return fmt.Errorf("%w: unknown category in category_ids", ErrInvalidPromotionRequest)
+		}
+	}
+
+	promotion.Products = nil
+	if len(req.ProductIDs) > 0 {
+		if err := tx.Find(&promotion.Products, req.ProductIDs).Error; err != nil {
+			return err
+		}
+		if len(promotion.Products) != len(req.ProductIDs) {
+			return fmt.Errorf("%w: unknown product in product_ids", ErrInvalidPromotionRequest)
+		}
+	}
+
+	return nil
+}
+
+func (s *PromotionService) convertToPromotionResponse(promotion *models.Promotion) dto.PromotionResponse {
+	tiers := make([]dto.PromotionTierResponse, len(promotion.Tiers))
+	for i := range promotion.Tiers {
+		tiers[i] = dto.PromotionTierResponse{
+			MinQuantity: promotion.Tiers[i].MinQuantity,
+			PercentOff:  promotion.Tiers[i].PercentOff,
+		}
+	}
+	categoryIDs := make([]uint, len(promotion.Categories))
+	for i := range promotion.Categories {
+		categoryIDs[i] = promotion.Categories[i].ID
+	}
+	productIDs := make([]uint, len(promotion.Products))
+	for i := range promotion.Products {
+		productIDs[i] = promotion.Products[i].ID
+	}
+
+	return dto.PromotionResponse{
+		ID:           promotion.ID,
+		Name:         promotion.Name,
+		Description:  promotion.Description,
+		Type:         string(promotion.Type),
+		Priority:     promotion.Priority,
+		Stackable:    promotion.Stackable,
+		PercentOff:   promotion.PercentOff,
+		AmountOff:    promotion.AmountOff,
+		MinCartValue: promotion.MinCartValue,
+		BuyQuantity:  promotion.BuyQuantity,
+		GetQuantity:  promotion.GetQuantity,
+		Tiers:        tiers,
+		StartsAt:     promotion.StartsAt,
+		EndsAt:       promotion.EndsAt,
+		IsActive:     promotion.IsActive,
+		CategoryIDs:  categoryIDs,
+		ProductIDs:   productIDs,
+		CreatedAt:    promotion.CreatedAt.Format(defaultDateFormat),
+	}
+}
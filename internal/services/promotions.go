@@ -0,0 +1,178 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+// PromotionAdjustment is the discount one promotion gives on a cart
+type PromotionAdjustment struct {
+	PromotionID  uint
+	Name         string
+	Description  string
+	Amount       money.Money
+	FreeShipping bool
+	// ItemAmounts is the part of Amount taken off each cart item, in cart item order
+	ItemAmounts []money.Money
+}
+
+// EvaluatePromotions returns the adjustments the promotions give on a cart loaded with its
+// items and their products, in the order they apply. Each promotion sees the item amounts
+// left by the ones before it.
+func EvaluatePromotions(cart *models.Cart, promotions []models.Promotion, now time.Time) []PromotionAdjustment {
+	return newPriceBreakdown(cartPricedLines(cart)).applyPromotions(promotions, now)
+}
+
+// applyPromotions applies the qualifying promotions by descending priority and records
+// their discounts. A promotion that is not stackable is skipped once another one applied,
+// and ends the evaluation when it applies itself.
+func (b *priceBreakdown) applyPromotions(promotions []models.Promotion, now time.Time) []PromotionAdjustment {
+	ordered := make([]*models.Promotion, len(promotions))
+	for i := range promotions {
+		ordered[i] = &promotions[i]
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	var adjustments []PromotionAdjustment
+	for _, promotion := range ordered {
+		if len(adjustments) > 0 && !promotion.Stackable {
+			continue
+		}
+
+		adjustment, ok := b.evaluatePromotion(promotion, now)
+		if !ok {
+			continue
+		}
+
+		promotionID := promotion.ID
+		b.addLineDiscounts(discountLine{
+			PromotionID:   &promotionID,
+			PromotionName: promotion.Name,
+			Description:   promotion.Description,
+			Amount:        adjustment.Amount,
+		}, adjustment.ItemAmounts)
+		if adjustment.FreeShipping {
+			b.FreeShipping = true
+		}
+		adjustments = append(adjustments, adjustment)
+
+		if !promotion.Stackable {
+			break
+		}
+	}
+	return adjustments
+}
+
+// evaluatePromotion works out what the promotion takes off the lines as they stand, without
+// recording it. It reports false when the promotion does not apply.
+func (b *priceBreakdown) evaluatePromotion(promotion *models.Promotion, now time.Time) (PromotionAdjustment, bool) {
+	adjustment := PromotionAdjustment{
+		PromotionID: promotion.ID,
+		Name:        promotion.Name,
+		Description: promotion.Description,
+	}
+
+	switch {
+	case !promotion.IsActive:
+		return adjustment, false
+	case promotion.StartsAt != nil && now.Before(*promotion.StartsAt):
+		return adjustment, false
+	case promotion.EndsAt != nil && !now.Before(*promotion.EndsAt):
+		return adjustment, false
+	}
+
+	lines := eligibleLines(promotion.Products, promotion.Categories, b.Lines)
+	if len(lines) == 0 {
+		return adjustment, false
+	}
+
+	// The minimum is checked against the qualifying items before any discount
+	var value, base money.Money
+	quantity := 0
+	for _, i := range lines {
+		value = value.Add(b.Lines[i].Amount)
+		base = base.Add(b.Lines[i].payable())
+		quantity += b.Lines[i].Quantity
+	}
+	if value.Cmp(promotion.MinCartValue) < 0 {
+		return adjustment, false
+	}
+
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		adjustment.ItemAmounts = b.allocate(base.Multiply(promotion.PercentOff.Percent()), lines, base)
+	case models.PromotionTypeFixed:
+		amount := promotion.AmountOff
+		if amount.Cmp(base) > 0 {
+			amount = base
+		}
+		adjustment.ItemAmounts = b.allocate(amount, lines, base)
+	case models.PromotionTypeTiered:
+		tier := reachedTier(promotion.Tiers, quantity)
+		if tier == nil {
+			return adjustment, false
+		}
+		adjustment.ItemAmounts = b.allocate(base.Multiply(tier.PercentOff.Percent()), lines, base)
+	case models.PromotionTypeBuyXGetY:
+		adjustment.ItemAmounts = b.freeUnits(promotion.BuyQuantity, promotion.GetQuantity, lines, quantity)
+	case models.PromotionTypeFreeShipping:
+		adjustment.FreeShipping = true
+	default:
+		return adjustment, false
+	}
+
+	for i := range adjustment.ItemAmounts {
+		adjustment.Amount = adjustment.Amount.Add(adjustment.ItemAmounts[i])
+	}
+	if adjustment.Amount.IsZero() && !adjustment.FreeShipping {
+		return adjustment, false
+	}
+	return adjustment, true
+}
+
+// reachedTier returns the tier with the highest minimum the quantity reaches
+func reachedTier(tiers []models.PromotionTier, quantity int) *models.PromotionTier {
+	var reached *models.PromotionTier
+	for i := range tiers {
+		if tiers[i].MinQuantity <= quantity && (reached == nil || tiers[i].MinQuantity > reached.MinQuantity) {
+			reached = &tiers[i]
+		}
+	}
+	return reached
+}
+
+// freeUnits gives away get units of every buy+get units across the lines, cheapest units
+// first. The result is indexed like b.Lines.
+func (b *priceBreakdown) freeUnits(buy, get int, lines []int, quantity int) []money.Money {
+	lineAmounts := make([]money.Money, len(b.Lines))
+	if buy <= 0 || get <= 0 {
+		return lineAmounts
+	}
+	free := quantity / (buy + get) * get
+
+	// Compare unit prices as payable/quantity without dividing
+	cheapest := append([]int(nil), lines...)
+	sort.SliceStable(cheapest, func(i, j int) bool {
+		a, c := &b.Lines[cheapest[i]], &b.Lines[cheapest[j]]
+		return a.payable().Amount*int64(c.Quantity) < c.payable().Amount*int64(a.Quantity)
+	})
+
+	for _, i := range cheapest {
+		if free == 0 {
+			break
+		}
+		line := &b.Lines[i]
+		units := min(free, line.Quantity)
+		lineAmounts[i] = line.payable().Share(int64(units), int64(line.Quantity))
+		free -= units
+	}
+	return lineAmounts
+}
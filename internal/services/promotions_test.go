@@ -0,0 +1,166 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func testPromotionCart() *models.Cart {
+	return &models.Cart{
+		CartItems: []models.CartItem{
+			{ProductID: 1, Quantity: 2, Product: models.Product{ID: 1, CategoryID: 10, Price: money.New(30_00, "USD")}},
+			{ProductID: 2, Quantity: 1, Product: models.Product{ID: 2, CategoryID: 10, Price: money.New(10_00, "USD")}},
+			{ProductID: 3, Quantity: 1, Product: models.Product{ID: 3, CategoryID: 20, Price: money.New(50_00, "USD")}},
+		},
+	}
+}
+
+func TestEvaluatePromotionsBuyXGetY(t *testing.T) {
+	promotion := models.Promotion{
+		ID:          1,
+		Name:        "Buy 2 get 1 free",
+		Type:        models.PromotionTypeBuyXGetY,
+		BuyQuantity: 2,
+		GetQuantity: 1,
+		IsActive:    true,
+		Categories:  []models.Category{{ID: 10}},
+	}
+
+	adjustments := EvaluatePromotions(testPromotionCart(), []models.Promotion{promotion}, time.Now())
+
+	require.Len(t, adjustments, 1)
+	// Three units in category 10, the cheapest one is free
+	assert.Equal(t, int64(10_00), adjustments[0].Amount.Amount)
+	assert.True(t, adjustments[0].ItemAmounts[0].IsZero())
+	assert.Equal(t, int64(10_00), adjustments[0].ItemAmounts[1].Amount)
+	assert.Equal(t, "Buy 2 get 1 free", adjustments[0].Name)
+}
+
+func TestEvaluatePromotionsOrderThreshold(t *testing.T) {
+	promotion := models.Promotion{
+		ID:           1,
+		Type:         models.PromotionTypePercentage,
+		PercentOff:   money.MustParseRate("10"),
+		MinCartValue: money.New(100_00, "USD"),
+		IsActive:     true,
+	}
+
+	adjustments := EvaluatePromotions(testPromotionCart(), []models.Promotion{promotion}, time.Now())
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, int64(12_00), adjustments[0].Amount.Amount)
+
+	var allocated money.Money
+	for _, amount := range adjustments[0].ItemAmounts {
+		allocated = allocated.Add(amount)
+	}
+	assert.Equal(t, adjustments[0].Amount, allocated)
+
+	promotion.MinCartValue = money.New(200_00, "USD")
+	assert.Empty(t, EvaluatePromotions(testPromotionCart(), []models.Promotion{promotion}, time.Now()))
+}
+
+func TestEvaluatePromotionsTiered(t *testing.T) {
+	promotion := models.Promotion{
+		ID:       1,
+		Type:     models.PromotionTypeTiered,
+		IsActive: true,
+		Tiers: []models.PromotionTier{
+			{MinQuantity: 5, PercentOff: money.MustParseRate("20")},
+			{MinQuantity: 3, PercentOff: money.MustParseRate("10")},
+		},
+		Categories: []models.Category{{ID: 10}},
+	}
+
+	adjustments := EvaluatePromotions(testPromotionCart(), []models.Promotion{promotion}, time.Now())
+	require.Len(t, adjustments, 1)
+	// Three units reach the 10% tier: 10% of 70.00
+	assert.Equal(t, int64(7_00), adjustments[0].Amount.Amount)
+	assert.True(t, adjustments[0].ItemAmounts[2].IsZero())
+
+	cart := testPromotionCart()
+	cart.CartItems[0].Quantity = 4
+	adjustments = EvaluatePromotions(cart, []models.Promotion{promotion}, time.Now())
+	require.Len(t, adjustments, 1)
+	// Five units reach the 20% tier: 20% of 130.00
+	assert.Equal(t, int64(26_00), adjustments[0].Amount.Amount)
+}
+
+func TestEvaluatePromotionsStacking(t *testing.T) {
+	fixed := models.Promotion{
+		ID:        1,
+		Type:      models.PromotionTypeFixed,
+		AmountOff: money.New(10_00, "USD"),
+		Priority:  10,
+		Stackable: true,
+		IsActive:  true,
+	}
+	percentage := models.Promotion{
+		ID:         2,
+		Type:       models.PromotionTypePercentage,
+		PercentOff: money.MustParseRate("10"),
+		Priority:   5,
+		Stackable:  true,
+		IsActive:   true,
+	}
+	exclusive := models.Promotion{
+		ID:         3,
+		Type:       models.PromotionTypePercentage,
+		PercentOff: money.MustParseRate("50"),
+		Priority:   1,
+		IsActive:   true,
+	}
+
+	// Stackable promotions apply in priority order, each on what the previous ones left
+	adjustments := EvaluatePromotions(testPromotionCart(), []models.Promotion{exclusive, percentage, fixed}, time.Now())
+	require.Len(t, adjustments, 2)
+	assert.Equal(t, uint(1), adjustments[0].PromotionID)
+	assert.Equal(t, int64(10_00), adjustments[0].Amount.Amount)
+	assert.Equal(t, uint(2), adjustments[1].PromotionID)
+	assert.Equal(t, int64(11_00), adjustments[1].Amount.Amount)
+
+	// A promotion that does not stack wins alone when it comes first
+	exclusive.Priority = 20
+	adjustments = EvaluatePromotions(testPromotionCart(), []models.Promotion{exclusive, percentage, fixed}, time.Now())
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, uint(3), adjustments[0].PromotionID)
+	assert.Equal(t, int64(60_00), adjustments[0].Amount.Amount)
+}
+
+func TestEvaluatePromotionsSkipsInactive(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	promotions := []models.Promotion{
+		{ID: 1, Type: models.PromotionTypeFixed, AmountOff: money.New(1_00, "USD")},
+		{ID: 2, Type: models.PromotionTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true, StartsAt: &later},
+		{ID: 3, Type: models.PromotionTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true, EndsAt: &earlier},
+		{ID: 4, Type: models.PromotionTypeFixed, AmountOff: money.New(1_00, "USD"), IsActive: true, Products: []models.Product{{ID: 99}}},
+	}
+	assert.Empty(t, EvaluatePromotions(testPromotionCart(), promotions, now))
+}
+
+func TestApplyCouponAfterPromotions(t *testing.T) {
+	b := newPriceBreakdown(cartPricedLines(testPromotionCart()))
+	b.applyPromotions([]models.Promotion{{
+		ID:        1,
+		Name:      "Ten off",
+		Type:      models.PromotionTypeFixed,
+		AmountOff: money.New(10_00, "USD"),
+		IsActive:  true,
+	}}, time.Now())
+
+	coupon := &models.Coupon{ID: 7, Code: "SAVE10", Type: models.CouponTypePercentage, PercentOff: money.MustParseRate("10"), IsActive: true}
+	require.NoError(t, b.applyCoupon(coupon, time.Now()))
+
+	require.Len(t, b.Discounts, 2)
+	assert.Equal(t, "Ten off", b.Discounts[0].PromotionName)
+	assert.Equal(t, int64(11_00), couponDiscount(b, coupon.ID).Amount)
+	assert.Equal(t, int64(21_00), b.Discount.Amount)
+	assert.Equal(t, b.Discount, lineDiscounts(b))
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sweepBatchSize bounds how many expired reservations one sweeper transaction releases
+const sweepBatchSize = 100
+
+// ReservationService holds stock for checkouts in progress. Stock is taken through the
+// InventoryService's conditional UPDATEs, so concurrent checkouts cannot take more than is
+// available.
+type ReservationService struct {
+	db               *gorm.DB
+	inventoryService *InventoryService
+	ttl              time.Duration
+}
+
+func NewReservationService(db *gorm.DB, inventoryService *InventoryService, ttl time.Duration) *ReservationService {
+	return &ReservationService{db: db, inventoryService: inventoryService, ttl: ttl}
+}
+
+// StartCheckout reserves the items of the user's cart for the reservation TTL. Reservations
+// from an earlier checkout attempt are released first, so retrying only refreshes the hold.
+func (s *ReservationService) StartCheckout(userID uint) (*dto.CheckoutResponse, error) {
+	var response *dto.CheckoutResponse
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var cart models.Cart
+		if err := tx.Preload("CartItems.Product").Preload("CartItems.Variant").Where("user_id = ?", userID).First(&cart).Error; err != nil {
+			return errors.New("cart not found")
+		}
+		if len(cart.CartItems) == 0 {
+			return errors.New("cart is empty")
+		}
+
+		// Products are locked before any stock moves and items are reserved in variant id
+		// order, so checkouts of the same products cannot deadlock, see lockProducts
+		reservations, err := s.lockActive(tx, userID)
+		if err != nil {
+			return err
+		}
+		if _, err := s.inventoryService.lockProducts(tx, stockProductIDs(cart.CartItems, reservations)); err != nil {
+			return err
+		}
+		if err := s.release(tx, reservations, userID); err != nil {
+			return err
+		}
+
+		// Stock is held in the warehouses the order would ship from, so a checkout that goes
+		// through ships from the same warehouses the reservation held stock in
+		ranked, err := s.inventoryService.rankWarehouses(tx, s.destination(tx, userID))
+		if err != nil {
+			return err
+		}
+
+		expiresAt := time.Now().Add(s.ttl)
+		response = &dto.CheckoutResponse{
+			Items:     make([]dto.ReservationItemResponse, len(cart.CartItems)),
+			ExpiresAt: expiresAt,
+		}
+		for _, i := range byVariant(cart.CartItems) {
+			item := &cart.CartItems[i]
+			allocations, err := s.inventoryService.plan(tx, &item.Variant, item.Quantity, ranked)
+			if err != nil {
+				return err
+			}
+
+			for _, allocation := range allocations {
+				reservation := models.StockReservation{
+					UserID:      userID,
+					ProductID:   item.ProductID,
+					VariantID:   item.VariantID,
+					WarehouseID: allocation.WarehouseID,
+					Quantity:    allocation.Quantity,
+					Status:      models.ReservationStatusActive,
+					ExpiresAt:   expiresAt,
+				}
+				if err := tx.Create(&reservation).Error; err != nil {
+					return err
+				}
+
+				if err := s.inventoryService.take(tx, &item.Variant, allocation.WarehouseID, allocation.Quantity,
+					movementFor(models.MovementReasonReservation, reservation.ID, userID)); err != nil {
+					return err
+				}
+			}
+
+			response.Items[i] = dto.ReservationItemResponse{
+				ProductID:   item.ProductID,
+				VariantID:   item.VariantID,
+				SKU:         item.Variant.SKU,
+				ProductName: item.Product.Name,
+				Quantity:    item.Quantity,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// commit takes the stock for an order's items from the warehouses ranked for the
+// destination and returns where each item was taken from. The stock the user holds is
+// handed back and the items are taken again as a sale; both happen under the row locks of
+// this transaction, so no other checkout can get in between. The reservations are marked as
+// consumed by the order. It must run in the order transaction.
+func (s *ReservationService) commit(tx *gorm.DB, userID, orderID uint, items []models.CartItem, destination models.AddressSnapshot) ([][]stockAllocation, error) {
+	reservations, err := s.lockActive(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.inventoryService.lockProducts(tx, stockProductIDs(items, reservations)); err != nil {
+		return nil, err
+	}
+
+	if err := s.giveBack(tx, reservations, userID); err != nil {
+		return nil, err
+	}
+
+	ranked, err := s.inventoryService.rankWarehouses(tx, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := make([][]stockAllocation, len(items))
+	for _, i := range byVariant(items) {
+		allocations[i], err = s.inventoryService.takeAllocated(tx, &items[i].Variant, items[i].Quantity, ranked,
+			movementFor(models.MovementReasonSale, orderID, userID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(reservations) == 0 {
+		return allocations, nil
+	}
+	if err := tx.Model(&models.StockReservation{}).
+		Where("id IN ?", reservationIDs(reservations)).
+		Updates(map[string]interface{}{"status": models.ReservationStatusCommitted, "order_id": orderID}).Error; err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// destination is where the user's order will most likely ship to, their default shipping
+// address. Without one warehouses are ranked by priority alone.
+func (s *ReservationService) destination(tx *gorm.DB, userID uint) models.AddressSnapshot {
+	var address models.Address
+	if err := tx.Where("user_id = ? AND is_default_shipping = ?", userID, true).First(&address).Error; err != nil {
+		return models.AddressSnapshot{}
+	}
+	return address.Snapshot()
+}
+
+// ReleaseExpired gives back the stock of reservations that expired before now and returns
+// how many were released. Rows locked by a checkout in progress are left for the next run.
+// Unlike a retried checkout, which holds the stock again right away, an expiry puts it back
+// on sale, so subscribers of products it brings back in stock are notified.
+func (s *ReservationService) ReleaseExpired(now time.Time) (int, error) {
+	released := 0
+	for {
+		var batch int
+		var restocked backInStockEvents
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var reservations []models.StockReservation
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND expires_at <= ?", models.ReservationStatusActive, now).
+				Order("id ASC").Limit(sweepBatchSize).
+				Find(&reservations).Error; err != nil {
+				return err
+			}
+			batch = len(reservations)
+			if batch == 0 {
+				return nil
+			}
+
+			stockBefore, err := s.inventoryService.lockProducts(tx, stockProductIDs(nil, reservations))
+			if err != nil {
+				return err
+			}
+			if err := s.release(tx, reservations, 0); err != nil {
+				return err
+			}
+
+			productIDs := make([]uint, 0, len(stockBefore))
+			for productID := range stockBefore {
+				productIDs = append(productIDs, productID)
+			}
+			sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+			for _, productID := range productIDs {
+				event, err := s.inventoryService.backInStock(tx, productID, stockBefore[productID])
+				if err != nil {
+					return err
+				}
+				restocked.add(event)
+			}
+			return nil
+		})
+		if err != nil {
+			return released, err
+		}
+
+		s.inventoryService.publishBackInStock(restocked...)
+
+		released += batch
+		if batch < sweepBatchSize {
+			return released, nil
+		}
+	}
+}
+
+// RunSweeper releases expired reservations every interval until the context is done
+func (s *ReservationService) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			released, err := s.ReleaseExpired(now)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to release expired stock reservations")
+				continue
+			}
+			if released > 0 {
+				log.Info().Int("released", released).Msg("released expired stock reservations")
+			}
+		}
+	}
+}
+
+// lockActive loads the user's active reservations, expired or not, with a row lock so the
+// sweeper cannot release them at the same time
+func (s *ReservationService) lockActive(tx *gorm.DB, userID uint) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND status = ?", userID, models.ReservationStatusActive).
+		Order("id ASC").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// release gives back the stock of locked active reservations and marks them released
+func (s *ReservationService) release(tx *gorm.DB, reservations []models.StockReservation, actorID uint) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	if err := s.giveBack(tx, reservations, actorID); err != nil {
+		return err
+	}
+
+	return tx.Model(&models.StockReservation{}).
+		Where("id IN ?", reservationIDs(reservations)).
+		Update("status", models.ReservationStatusReleased).Error
+}
+
+// giveBack returns the stock held by locked reservations without changing their status. Like
+// a checkout it locks the products first and returns the variants in variant id order.
+func (s *ReservationService) giveBack(tx *gorm.DB, reservations []models.StockReservation, actorID uint) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+	if _, err := s.inventoryService.lockProducts(tx, stockProductIDs(nil, reservations)); err != nil {
+		return err
+	}
+
+	ordered := make([]*models.StockReservation, len(reservations))
+	for i := range reservations {
+		ordered[i] = &reservations[i]
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].VariantID < ordered[j].VariantID
+	})
+
+	for _, reservation := range ordered {
+		variant := &models.ProductVariant{ID: reservation.VariantID, ProductID: reservation.ProductID}
+		if err := s.inventoryService.put(tx, variant, reservation.WarehouseID, reservation.Quantity,
+			movementFor(models.MovementReasonReservationRelease, reservation.ID, actorID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byVariant returns the indexes of the cart items in variant id order, the order their stock
+// is moved in
+func byVariant(items []models.CartItem) []int {
+	indexes := make([]int, len(items))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return items[indexes[a]].VariantID < items[indexes[b]].VariantID
+	})
+	return indexes
+}
+
+// stockProductIDs lists the products of cart items and reservations, whose rows are locked
+// before their stock moves
+func stockProductIDs(items []models.CartItem, reservations []models.StockReservation) []uint {
+	ids := make([]uint, 0, len(items)+len(reservations))
+	for i := range items {
+		ids = append(ids, items[i].ProductID)
+	}
+	for i := range reservations {
+		ids = append(ids, reservations[i].ProductID)
+	}
+	return ids
+}
+
+func reservationIDs(reservations []models.StockReservation) []uint {
+	ids := make([]uint, len(reservations))
+	for i := range reservations {
+		ids[i] = reservations[i].ID
+	}
+	return ids
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+// createTestProduct creates a product with a default variant holding the stock in the
+// primary warehouse. The variant is the product's only entry in Variants.
+func createTestProduct(t *testing.T, service *ReservationService, stock int) models.Product {
+	t.Helper()
+
+	suffix := time.Now().UnixNano()
+	category := models.Category{Name: "Test", Slug: fmt.Sprintf("test-%d", suffix)}
+	require.NoError(t, service.db.Create(&category).Error)
+
+	product := models.Product{
+		CategoryID: category.ID,
+		Name:       "Widget",
+		Slug:       fmt.Sprintf("widget-%d", suffix),
+		Price:      money.New(10_00, "USD"),
+		SKU:        fmt.Sprintf("SKU-%d", suffix),
+	}
+	require.NoError(t, service.db.Create(&product).Error)
+
+	variant := models.ProductVariant{ProductID: product.ID, SKU: product.SKU, IsDefault: true}
+	require.NoError(t, service.db.Create(&variant).Error)
+	require.NoError(t, service.inventoryService.put(service.db, &variant, primaryWarehouseID(t, service), stock, movementFor(models.MovementReasonImport, 0, 0)))
+	product.Variants = []models.ProductVariant{variant}
+	return product
+}
+
+func newTestReservationService(t *testing.T) *ReservationService {
+	db := openTestDB(t)
+	return NewReservationService(db, NewInventoryService(db, nil, AllocationByPriority), time.Minute)
+}
+
+func primaryWarehouseID(t *testing.T, service *ReservationService) uint {
+	t.Helper()
+
+	warehouse, err := service.inventoryService.primaryWarehouse(service.db)
+	require.NoError(t, err)
+	return warehouse.ID
+}
+
+func productStock(t *testing.T, service *ReservationService, productID uint) int {
+	t.Helper()
+
+	var product models.Product
+	require.NoError(t, service.db.First(&product, productID).Error)
+	return product.Stock
+}
+
+func TestTakeStockNeverGoesNegative(t *testing.T) {
+	service := newTestReservationService(t)
+	product := createTestProduct(t, service, 10)
+	warehouseID := primaryWarehouseID(t, service)
+
+	const buyers = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, rejected := 0, 0
+	for range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := service.inventoryService.take(service.db, &product.Variants[0], warehouseID, 1, movementFor(models.MovementReasonSale, 0, 0))
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, ErrInsufficientStock):
+				rejected++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, succeeded)
+	assert.Equal(t, buyers-10, rejected)
+	assert.Equal(t, 0, productStock(t, service, product.ID))
+}
+
+func TestConcurrentCheckoutsReserveAvailableStockOnly(t *testing.T) {
+	service := newTestReservationService(t)
+	product := createTestProduct(t, service, 5)
+
+	const shoppers = 20
+	userIDs := make([]uint, shoppers)
+	for i := range userIDs {
+		user := models.User{
+			Email:     fmt.Sprintf("shopper%d@example.com", i),
+			Password:  "secret",
+			FirstName: "Test",
+			LastName:  "Shopper",
+		}
+		require.NoError(t, service.db.Create(&user).Error)
+		cart := models.Cart{UserID: &user.ID}
+		require.NoError(t, service.db.Create(&cart).Error)
+		require.NoError(t, service.db.Create(&models.CartItem{CartID: cart.ID, ProductID: product.ID, VariantID: product.Variants[0].ID, Quantity: 2}).Error)
+		userIDs[i] = user.ID
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.StartCheckout(userID)
+			if err != nil && !errors.Is(err, ErrInsufficientStock) {
+				t.Error(err)
+			}
+			if err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Two shoppers get two units each, the last unit cannot cover a third cart
+	assert.Equal(t, 2, reserved)
+	assert.Equal(t, 1, productStock(t, service, product.ID))
+
+	released, err := service.ReleaseExpired(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, released)
+	assert.Equal(t, 5, productStock(t, service, product.ID))
+
+	// Every change went through the ledger
+	discrepancies, err := service.inventoryService.Reconcile()
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestCheckoutSplitsItemsAcrossWarehouses(t *testing.T) {
+	service := newTestReservationService(t)
+	product := createTestProduct(t, service, 2)
+
+	overflow := models.Warehouse{Code: "OVERFLOW", Name: "Overflow", Priority: 1, IsActive: true}
+	require.NoError(t, service.db.Create(&overflow).Error)
+	require.NoError(t, service.inventoryService.put(service.db, &product.Variants[0], overflow.ID, 5, movementFor(models.MovementReasonImport, 0, 0)))
+
+	user := models.User{Email: "split@example.com", Password: "secret", FirstName: "Test", LastName: "Shopper"}
+	require.NoError(t, service.db.Create(&user).Error)
+	cart := models.Cart{UserID: &user.ID}
+	require.NoError(t, service.db.Create(&cart).Error)
+	require.NoError(t, service.db.Create(&models.CartItem{CartID: cart.ID, ProductID: product.ID, VariantID: product.Variants[0].ID, Quantity: 4}).Error)
+
+	_, err := service.StartCheckout(user.ID)
+	require.NoError(t, err)
+
+	// The primary warehouse is emptied first, the rest comes from the overflow warehouse
+	var reservations []models.StockReservation
+	require.NoError(t, service.db.Where("user_id = ?", user.ID).Order("id ASC").Find(&reservations).Error)
+	require.Len(t, reservations, 2)
+	assert.Equal(t, primaryWarehouseID(t, service), reservations[0].WarehouseID)
+	assert.Equal(t, 2, reservations[0].Quantity)
+	assert.Equal(t, overflow.ID, reservations[1].WarehouseID)
+	assert.Equal(t, 2, reservations[1].Quantity)
+	assert.Equal(t, 3, productStock(t, service, product.ID))
+
+	// Released stock goes back to the warehouse it was held in
+	_, err = service.ReleaseExpired(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	levels, err := service.inventoryService.GetStockLevels(product.ID)
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+	assert.Equal(t, 2, levels[0].Stock)
+	assert.Equal(t, 5, levels[1].Stock)
+
+	discrepancies, err := service.inventoryService.Reconcile()
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestByVariantOrdersStockMoves(t *testing.T) {
+	items := []models.CartItem{{VariantID: 7}, {VariantID: 3}, {VariantID: 5}, {VariantID: 3}}
+	assert.Equal(t, []int{1, 3, 2, 0}, byVariant(items))
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAlreadyReviewed = errors.New("product has already been reviewed")
+
+// reviewSorts maps the sort options of the review listing to their ORDER BY clause
+var reviewSorts = map[string]string{
+	"newest":      "created_at DESC, id DESC",
+	"oldest":      "created_at ASC, id ASC",
+	"rating_desc": "rating DESC, created_at DESC",
+	"rating_asc":  "rating ASC, created_at DESC",
+}
+
+// ReviewService manages product reviews. New and edited reviews wait for an admin to
+// approve them, and the rating of a product only counts its approved reviews.
+type ReviewService struct {
+	db *gorm.DB
+}
+
+func NewReviewService(db *gorm.DB) *ReviewService {
+	return &ReviewService{db: db}
+}
+
+// GetProductReviews returns the approved reviews of an active product
+func (s *ReviewService) GetProductReviews(productID uint, query *dto.ReviewListQuery) ([]dto.ReviewResponse, *utils.PaginationMeta, error) {
+	if err := s.db.Select("id").Where("is_active = ?", true).First(&models.Product{}, productID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	filtered := s.db.Model(&models.Review{}).Where("product_id = ? AND status = ?", productID, models.ReviewStatusApproved)
+	if query.Rating > 0 {
+		filtered = filtered.Where("rating = ?", query.Rating)
+	}
+
+	sort, ok := reviewSorts[query.Sort]
+	if !ok {
+		sort = reviewSorts["newest"]
+	}
+	return s.listReviews(filtered, sort, query.Page, query.Limit)
+}
+
+// GetUserReviews returns the reviews a user wrote, whatever their status
+func (s *ReviewService) GetUserReviews(userID uint, page, limit int) ([]dto.ReviewResponse, *utils.PaginationMeta, error) {
+	filtered := s.db.Model(&models.Review{}).Where("user_id = ?", userID)
+	return s.listReviews(filtered, reviewSorts["newest"], page, limit)
+}
+
+// GetReviews returns the reviews for moderation, oldest first so none is left waiting
+func (s *ReviewService) GetReviews(query *dto.AdminReviewListQuery) ([]dto.ReviewResponse, *utils.PaginationMeta, error) {
+	filtered := s.db.Model(&models.Review{})
+	if query.Status != "" {
+		filtered = filtered.Where("status = ?", query.Status)
+	}
+	if query.ProductID != 0 {
+		filtered = filtered.Where("product_id = ?", query.ProductID)
+	}
+	return s.listReviews(filtered, reviewSorts["oldest"], query.Page, query.Limit)
+}
+
+func (s *ReviewService) listReviews(filtered *gorm.DB, sort string, page, limit int) ([]dto.ReviewResponse, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var reviews []models.Review
+	if err := filtered.Session(&gorm.Session{}).
+		Preload("User").
+		Order(sort).
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&reviews).Error; err != nil {
+		return nil, nil, err
+	}
+
+	response := make([]dto.ReviewResponse, len(reviews))
+	for i := range reviews {
+		response[i] = convertToReviewResponse(&reviews[i])
+	}
+	meta := &utils.PaginationMeta{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+	return response, meta, nil
+}
+
+// CreateReview adds the user's review of an active product. It is shown once an admin
+// approves it.
+func (s *ReviewService) CreateReview(userID, productID uint, req *dto.ReviewRequest) (*dto.ReviewResponse, error) {
+	review := models.Review{
+		ProductID: productID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Title:     req.Title,
+		Body:      req.Body,
+		Status:    models.ReviewStatusPending,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").Where("is_active = ?", true).First(&models.Product{}, productID).Error; err != nil {
+			return fmt.Errorf("product: %w", err)
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Review{}).Where("product_id = ? AND user_id = ?", productID, userID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyReviewed
+		}
+
+		var err error
+		review.VerifiedPurchase, err = purchased(tx, userID, productID)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&review).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getReview(s.db, review.ID)
+}
+
+// UpdateReview changes the user's own review. The edited review goes back to moderation and
+// no longer counts towards the rating until it is approved again.
+func (s *ReviewService) UpdateReview(userID, reviewID uint, req *dto.ReviewRequest) (*dto.ReviewResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&review, reviewID).Error; err != nil {
+			return err
+		}
+
+		if review.Counted() {
+			if err := adjustRating(tx, review.ProductID, -1, -review.Rating); err != nil {
+				return err
+			}
+		}
+
+		verified, err := purchased(tx, userID, review.ProductID)
+		if err != nil {
+			return err
+		}
+		review.Rating = req.Rating
+		review.Title = req.Title
+		review.Body = req.Body
+		review.VerifiedPurchase = verified
+		review.Status = models.ReviewStatusPending
+		review.ModeratedBy = nil
+		review.ModeratedAt = nil
+		return tx.Save(&review).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getReview(s.db, reviewID)
+}
+
+// DeleteReview removes the user's own review
+func (s *ReviewService) DeleteReview(userID, reviewID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&review, reviewID).Error; err != nil {
+			return err
+		}
+
+		if review.Counted() {
+			if err := adjustRating(tx, review.ProductID, -1, -review.Rating); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&review).Error
+	})
+}
+
+// ApproveReview shows a review and counts it towards the product's rating
+func (s *ReviewService) ApproveReview(reviewID, actorID uint) (*dto.ReviewResponse, error) {
+	return s.moderate(reviewID, actorID, models.ReviewStatusApproved)
+}
+
+// HideReview takes a review off the product and out of its rating
+func (s *ReviewService) HideReview(reviewID, actorID uint) (*dto.ReviewResponse, error) {
+	return s.moderate(reviewID, actorID, models.ReviewStatusHidden)
+}
+
+func (s *ReviewService) moderate(reviewID, actorID uint, status models.ReviewStatus) (*dto.ReviewResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, reviewID).Error; err != nil {
+			return err
+		}
+
+		wasCounted := review.Counted()
+		now := time.Now()
+		review.Status = status
+		review.ModeratedBy = &actorID
+		review.ModeratedAt = &now
+		if err := tx.Save(&review).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case review.Counted() && !wasCounted:
+			return adjustRating(tx, review.ProductID, 1, review.Rating)
+		case !review.Counted() && wasCounted:
+			return adjustRating(tx, review.ProductID, -1, -review.Rating)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getReview(s.db, reviewID)
+}
+
+func (s *ReviewService) getReview(db *gorm.DB, id uint) (*dto.ReviewResponse, error) {
+	var review models.Review
+	if err := db.Preload("User").First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	response := convertToReviewResponse(&review)
+	return &response, nil
+}
+
+// adjustRating moves the review count and rating total of a product by the change of a
+// single review, so the average never has to be computed over all reviews
+func adjustRating(tx *gorm.DB, productID uint, count, total int) error {
+	return tx.Model(&models.Product{}).Where("id = ?", productID).UpdateColumns(map[string]interface{}{
+		"review_count": gorm.Expr("review_count + ?", count),
+		"rating_total": gorm.Expr("rating_total + ?", total),
+	}).Error
+}
+
+// purchased reports whether the user has had the product delivered
+func purchased(tx *gorm.DB, userID, productID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ?", userID, models.OrderStatusDelivered, productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// verifyPurchases flags the reviews the customer already wrote for the products of an order
+// that has been delivered
+func verifyPurchases(tx *gorm.DB, order *models.Order) error {
+	return tx.Model(&models.Review{}).
+		Where("user_id = ? AND product_id IN (SELECT product_id FROM order_items WHERE order_id = ? AND deleted_at IS NULL)", order.UserID, order.ID).
+		UpdateColumn("verified_purchase", true).Error
+}
+
+func convertToReviewResponse(review *models.Review) dto.ReviewResponse {
+	return dto.ReviewResponse{
+		ID:               review.ID,
+		ProductID:        review.ProductID,
+		UserID:           review.UserID,
+		Author:           reviewAuthor(&review.User),
+		Rating:           review.Rating,
+		Title:            review.Title,
+		Body:             review.Body,
+		VerifiedPurchase: review.VerifiedPurchase,
+		Status:           string(review.Status),
+		CreatedAt:        review.CreatedAt.Format(defaultDateFormat),
+		UpdatedAt:        review.UpdatedAt.Format(defaultDateFormat),
+	}
+}
+
+// reviewAuthor shows the reviewer by first name and last initial only
+func reviewAuthor(user *models.User) string {
+	for _, initial := range user.LastName {
+		return fmt.Sprintf("%s %c.", user.FirstName, initial)
+	}
+	return user.FirstName
+}
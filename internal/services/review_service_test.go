@@ -0,0 +1,115 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func TestReviewAuthor(t *testing.T) {
+	assert.Equal(t, "Ada L.", reviewAuthor(&models.User{FirstName: "Ada", LastName: "Lovelace"}))
+	assert.Equal(t, "Zoë Ö.", reviewAuthor(&models.User{FirstName: "Zoë", LastName: "Öztürk"}))
+	assert.Equal(t, "Cher", reviewAuthor(&models.User{FirstName: "Cher"}))
+}
+
+func TestReviewsKeepProductRatingUpToDate(t *testing.T) {
+	reservations := newTestReservationService(t)
+	db := reservations.db
+	service := NewReviewService(db)
+	products := NewProductService(db, NewCurrencyService(db), reservations.inventoryService)
+	product := createTestProduct(t, reservations, 5)
+
+	newUser := func(email string) uint {
+		user := models.User{Email: email, Password: "secret", FirstName: "Test", LastName: "Shopper"}
+		require.NoError(t, db.Create(&user).Error)
+		return user.ID
+	}
+	buyer := newUser("buyer@example.com")
+	browser := newUser("browser@example.com")
+	admin := newUser("admin@example.com")
+
+	// Only the buyer has had the product delivered
+	order := models.Order{
+		UserID:         buyer,
+		Status:         models.OrderStatusDelivered,
+		SubtotalAmount: money.New(10_00, "USD"),
+		TaxAmount:      money.Zero("USD"),
+		TotalAmount:    money.New(10_00, "USD"),
+	}
+	require.NoError(t, db.Create(&order).Error)
+	require.NoError(t, db.Create(&models.OrderItem{
+		OrderID:   order.ID,
+		ProductID: product.ID,
+		VariantID: product.Variants[0].ID,
+		Quantity:  1,
+		Price:     money.New(10_00, "USD"),
+		TaxAmount: money.Zero("USD"),
+	}).Error)
+
+	verified, err := service.CreateReview(buyer, product.ID, &dto.ReviewRequest{Rating: 5, Title: "Great"})
+	require.NoError(t, err)
+	assert.True(t, verified.VerifiedPurchase)
+	assert.Equal(t, string(models.ReviewStatusPending), verified.Status)
+
+	unverified, err := service.CreateReview(browser, product.ID, &dto.ReviewRequest{Rating: 2, Title: "Meh"})
+	require.NoError(t, err)
+	assert.False(t, unverified.VerifiedPurchase)
+
+	_, err = service.CreateReview(browser, product.ID, &dto.ReviewRequest{Rating: 3, Title: "Again"})
+	assert.ErrorIs(t, err, ErrAlreadyReviewed)
+
+	rating := func() (float64, int) {
+		response, err := products.GetProduct(product.ID, "")
+		require.NoError(t, err)
+		return response.AverageRating, response.ReviewCount
+	}
+
+	// Pending reviews are not counted
+	average, count := rating()
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0.0, average)
+
+	_, err = service.ApproveReview(verified.ID, admin)
+	require.NoError(t, err)
+	_, err = service.ApproveReview(unverified.ID, admin)
+	require.NoError(t, err)
+	// Approving twice does not count the review twice
+	_, err = service.ApproveReview(unverified.ID, admin)
+	require.NoError(t, err)
+	average, count = rating()
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 3.5, average)
+
+	reviews, meta, err := service.GetProductReviews(product.ID, &dto.ReviewListQuery{Sort: "rating_desc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), meta.Total)
+	assert.Equal(t, verified.ID, reviews[0].ID)
+
+	// An edit goes back to moderation
+	_, err = service.UpdateReview(browser, unverified.ID, &dto.ReviewRequest{Rating: 4, Title: "Better"})
+	require.NoError(t, err)
+	average, count = rating()
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 5.0, average)
+
+	// Customers cannot touch the reviews of others
+	_, err = service.UpdateReview(buyer, unverified.ID, &dto.ReviewRequest{Rating: 1, Title: "Spam"})
+	assert.Error(t, err)
+	assert.Error(t, service.DeleteReview(buyer, unverified.ID))
+
+	_, err = service.HideReview(verified.ID, admin)
+	require.NoError(t, err)
+	average, count = rating()
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0.0, average)
+
+	_, err = service.ApproveReview(unverified.ID, admin)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteReview(browser, unverified.ID))
+	_, count = rating()
+	assert.Equal(t, 0, count)
+}
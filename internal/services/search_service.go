@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+)
+
+// searchPriceBuckets are the upper bounds of the price facet buckets in the base currency
+var searchPriceBuckets = []string{"25", "50", "100", "250", "500"}
+
+// searchHighlight wraps the matching words in <mark> tags
+const searchHighlight = "StartSel=<mark>, StopSel=</mark>"
+
+// htmlEscaped escapes a text column for HTML before it is highlighted, so the <mark> tags are
+// the only markup in a highlight
+func htmlEscaped(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`, column)
+}
+
+// SearchService searches active products through the full-text search vector the database
+// keeps for every product from its name, its and its variants' SKUs, category name and
+// description
+type SearchService struct {
+	db             *gorm.DB
+	productService *ProductService
+}
+
+func NewSearchService(db *gorm.DB, productService *ProductService) *SearchService {
+	return &SearchService{db: db, productService: productService}
+}
+
+// Search returns a page of the products matching the query, most relevant first, together
+// with facets counted over every match
+func (s *SearchService) Search(query *dto.SearchQuery, currency string) (*dto.SearchResponse, *utils.PaginationMeta, error) {
+	display, err := s.productService.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	// Words are stemmed for names and descriptions, SKUs are matched as they are
+	q := strings.TrimSpace(query.Q)
+	matched := s.db.Model(&models.Product{}).
+		Joins("CROSS JOIN (SELECT websearch_to_tsquery('english', ?) || websearch_to_tsquery('simple', ?) AS query) AS search", q, q).
+		Where("products.is_active = ? AND products.search_vector @@ search.query", true)
+
+	var total int64
+	if err := matched.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var hits []struct {
+		ID                   uint
+		Rank                 float64
+		NameHighlight        string
+		DescriptionHighlight string
+	}
+	if err := matched.Session(&gorm.Session{}).
+		Select("products.id, ts_rank_cd(products.search_vector, search.query) AS rank, "+
+			"ts_headline('english', "+htmlEscaped("products.name")+", search.query, ?) AS name_highlight, "+
+			"ts_headline('english', "+htmlEscaped("coalesce(products.description, '')")+", search.query, ?) AS description_highlight",
+			searchHighlight+", HighlightAll=true", searchHighlight+", MaxFragments=2").
+		Order("rank DESC, products.id ASC").
+		Offset((page - 1) * limit).Limit(limit).
+		Scan(&hits).Error; err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]uint, len(hits))
+	for i := range hits {
+		ids[i] = hits[i].ID
+	}
+	var products []models.Product
+	if len(ids) > 0 {
+		if err := s.productService.withVariants(s.db).Preload("Category").Preload("Images").
+			Where("id IN ?", ids).Find(&products).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	byID := make(map[uint]*models.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	response := &dto.SearchResponse{Results: make([]dto.SearchResultResponse, 0, len(hits))}
+	for _, hit := range hits {
+		product, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		response.Results = append(response.Results, dto.SearchResultResponse{
+			Product: s.productService.convertToProductResponse(product, display),
+			Rank:    hit.Rank,
+			Highlights: dto.SearchHighlightsResponse{
+				Name:        hit.NameHighlight,
+				Description: hit.DescriptionHighlight,
+			},
+		})
+	}
+
+	if err := matched.Session(&gorm.Session{}).
+		Select("categories.id AS category_id, categories.name, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Group("categories.id, categories.name").
+		Order("count DESC, categories.name ASC").
+		Scan(&response.Facets.Categories).Error; err != nil {
+		return nil, nil, err
+	}
+
+	response.Facets.PriceBuckets, err = s.priceBuckets(matched.Session(&gorm.Session{}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := &utils.PaginationMeta{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+	return response, meta, nil
+}
+
+// priceBuckets counts the matched products per price bucket, empty buckets included. Products
+// are bucketed by their cheapest active variant, the price the product listing filters on.
+func (s *SearchService) priceBuckets(matched *gorm.DB) ([]dto.PriceBucketResponse, error) {
+	var counts []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := matched.
+		Select(fmt.Sprintf("width_bucket(%s, ARRAY[%s]::numeric[]) AS bucket, COUNT(*) AS count", productFromPrice, strings.Join(searchPriceBuckets, ", "))).
+		Group("bucket").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := make([]dto.PriceBucketResponse, len(searchPriceBuckets)+1)
+	buckets[0].Min = money.Zero(money.BaseCurrency)
+	for i, bound := range searchPriceBuckets {
+		max, err := money.Parse(bound, money.BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		buckets[i].Max = &max
+		buckets[i+1].Min = max
+	}
+	for _, count := range counts {
+		buckets[count.Bucket].Count = count.Count
+	}
+	return buckets, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+)
+
+func TestSearchRanksMatchesAndCountsFacets(t *testing.T) {
+	reservations := newTestReservationService(t)
+	db := reservations.db
+	products := NewProductService(db, NewCurrencyService(db), reservations.inventoryService)
+	service := NewSearchService(db, products)
+
+	shoes := models.Category{Name: "Running Shoes", Slug: "running-shoes"}
+	socks := models.Category{Name: "Socks", Slug: "socks"}
+	require.NoError(t, db.Create(&shoes).Error)
+	require.NoError(t, db.Create(&socks).Error)
+
+	create := func(categoryID uint, name, description string, price int64) uint {
+		product := models.Product{
+			CategoryID:  categoryID,
+			Name:        name,
+			Slug:        utils.Slugify(name),
+			Description: description,
+			Price:       money.New(price, "USD"),
+			SKU:         fmt.Sprintf("SKU-%d", time.Now().UnixNano()),
+		}
+		require.NoError(t, db.Create(&product).Error)
+		return product.ID
+	}
+	trail := create(shoes.ID, "Trail Runner", "A light running shoe", 120_00)
+	wool := create(socks.ID, "Wool Socks", "Warm socks for <b>running</b> & hiking", 15_00)
+	create(socks.ID, "Cotton Socks", "Everyday socks", 8_00)
+
+	results, meta, err := service.Search(&dto.SearchQuery{Q: "running"}, "")
+	require.NoError(t, err)
+
+	// The name and category match weigh more than a word in the description
+	assert.Equal(t, int64(2), meta.Total)
+	require.Len(t, results.Results, 2)
+	assert.Equal(t, trail, results.Results[0].Product.ID)
+	assert.Contains(t, results.Results[0].Highlights.Name, "<mark>Runner</mark>")
+
+	// Markup in the text is escaped, only the highlight is HTML
+	assert.Equal(t, wool, results.Results[1].Product.ID)
+	assert.Contains(t, results.Results[1].Highlights.Description, "&lt;b&gt;")
+	assert.Contains(t, results.Results[1].Highlights.Description, "&amp; hiking")
+	assert.NotContains(t, results.Results[1].Highlights.Description, "<b>")
+
+	assert.ElementsMatch(t, []dto.CategoryFacetResponse{
+		{CategoryID: shoes.ID, Name: "Running Shoes", Count: 1},
+		{CategoryID: socks.ID, Name: "Socks", Count: 1},
+	}, results.Facets.Categories)
+	require.Len(t, results.Facets.PriceBuckets, len(searchPriceBuckets)+1)
+	assert.Equal(t, int64(1), results.Facets.PriceBuckets[0].Count)
+	assert.Equal(t, int64(1), results.Facets.PriceBuckets[3].Count)
+
+	// Products are bucketed by their cheapest active variant
+	sale := money.New(20_00, "USD")
+	require.NoError(t, db.Create(&models.ProductVariant{ProductID: trail, SKU: "ZX9000", Price: &sale, IsActive: true}).Error)
+	results, _, err = service.Search(&dto.SearchQuery{Q: "running"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), results.Facets.PriceBuckets[0].Count)
+	assert.Equal(t, int64(0), results.Facets.PriceBuckets[3].Count)
+
+	// A variant's SKU finds its product
+	results, _, err = service.Search(&dto.SearchQuery{Q: "ZX9000"}, "")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, trail, results.Results[0].Product.ID)
+
+	// Renaming the category changes what its products are found by
+	require.NoError(t, db.Model(&shoes).Update("name", "Trainers").Error)
+	results, _, err = service.Search(&dto.SearchQuery{Q: "trainers"}, "")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, trail, results.Results[0].Product.ID)
+}
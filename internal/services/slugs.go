@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSlugTaken = errors.New("slug is already in use")
+
+// sluggable is a table whose rows have a slug, together with the resource name its old
+// slugs are kept under
+type sluggable struct {
+	model    interface{}
+	resource string
+}
+
+var (
+	productSlugs  = sluggable{model: &models.Product{}, resource: models.SlugResourceProduct}
+	categorySlugs = sluggable{model: &models.Category{}, resource: models.SlugResourceCategory}
+)
+
+// initial is the slug of a new row, the requested one or one generated from its name
+func (s sluggable) initial(tx *gorm.DB, requested, name string) (string, error) {
+	if requested != "" {
+		return requested, s.claim(tx, requested, 0)
+	}
+	return s.generate(tx, name, 0)
+}
+
+// next is the slug of an updated row: the requested one, a new one generated when the row
+// was renamed, or the one it has
+func (s sluggable) next(tx *gorm.DB, id uint, current, requested, oldName, newName string) (string, error) {
+	switch {
+	case requested != "" && requested != current:
+		return requested, s.claim(tx, requested, id)
+	case requested == "" && newName != oldName:
+		return s.generate(tx, newName, id)
+	}
+	return current, nil
+}
+
+// generate derives a slug from the name that no other live row has, numbering it when the
+// plain one is taken
+func (s sluggable) generate(tx *gorm.DB, name string, excludeID uint) (string, error) {
+	base := utils.Slugify(name)
+	if base == "" {
+		base = s.resource
+	}
+
+	for n := 1; ; n++ {
+		slug := base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken, err := s.taken(tx, slug, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+}
+
+// claim checks that a slug chosen by hand is free
+func (s sluggable) claim(tx *gorm.DB, slug string, excludeID uint) error {
+	taken, err := s.taken(tx, slug, excludeID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("%w: %s", ErrSlugTaken, slug)
+	}
+	return nil
+}
+
+func (s sluggable) taken(tx *gorm.DB, slug string, excludeID uint) (bool, error) {
+	var count int64
+	if err := tx.Model(s.model).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// moved records that the row's old slug now leads to its new one. The new slug stops being
+// a redirect, it belongs to a live row now.
+func (s sluggable) moved(tx *gorm.DB, targetID uint, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := tx.Where("resource = ? AND slug = ?", s.resource, newSlug).Delete(&models.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	if oldSlug == "" {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target_id", "created_at"}),
+	}).Create(&models.SlugRedirect{Resource: s.resource, Slug: oldSlug, TargetID: targetID}).Error
+}
+
+// resolve finds the live row with the slug. For a slug the row had before, the row is found
+// through its redirect and moved is set.
+func (s sluggable) resolve(tx *gorm.DB, slug string) (id uint, moved bool, err error) {
+	var row struct{ ID uint }
+	err = tx.Model(s.model).Select("id").Where("slug = ?", slug).Take(&row).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return row.ID, false, err
+	}
+
+	var redirect models.SlugRedirect
+	if err := tx.Where("resource = ? AND slug = ?", s.resource, slug).First(&redirect).Error; err != nil {
+		return 0, false, err
+	}
+	if err := tx.Model(s.model).Select("id").Where("id = ?", redirect.TargetID).Take(&row).Error; err != nil {
+		return 0, false, err
+	}
+	return row.ID, true, nil
+}
@@ -1,4 +1,5 @@
 package services
+
 import (
 	"github.com/veetmoradiya3628/go-shop/internal/dto"
 	"github.com/veetmoradiya3628/go-shop/internal/models"
@@ -21,15 +22,8 @@ func (s *UserService) GetProfile(userID uint) (*dto.UserResponse, error) {
 		return nil, err
 	}
 
-	return &dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Phone:     user.Phone,
-		Role:      string(user.Role),
-		IsActive: user.IsActive,
-	}, nil
+	response := convertToUserResponse(&user)
+	return &response, nil
 }
 
 func (s *UserService) UpdateProfile(userID uint, req *dto.UpdateProfileRequest) (*dto.UserResponse, error) {
@@ -47,4 +41,146 @@ func (s *UserService) UpdateProfile(userID uint, req *dto.UpdateProfileRequest)
 	}
 
 	return s.GetProfile(userID)
-}
\ No newline at end of file
+}
+
+func (s *UserService) GetAddresses(userID uint) ([]dto.AddressResponse, error) {
+	var addresses []models.Address
+	if err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.AddressResponse, len(addresses))
+	for i := range addresses {
+		response[i] = convertToAddressResponse(&addresses[i])
+	}
+
+	return response, nil
+}
+
+func (s *UserService) CreateAddress(userID uint, req *dto.AddressRequest) (*dto.AddressResponse, error) {
+	address := models.Address{UserID: userID}
+	applyAddressRequest(&address, req)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		// The first address becomes the default for both shipping and billing
+		if count == 0 {
+			address.IsDefaultShipping = true
+			address.IsDefaultBilling = true
+		}
+
+		if err := tx.Create(&address).Error; err != nil {
+			return err
+		}
+
+		return s.clearOtherDefaults(tx, &address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := convertToAddressResponse(&address)
+	return &response, nil
+}
+
+func (s *UserService) UpdateAddress(userID, addressID uint, req *dto.AddressRequest) (*dto.AddressResponse, error) {
+	var address models.Address
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+			return err
+		}
+
+		applyAddressRequest(&address, req)
+		if err := tx.Save(&address).Error; err != nil {
+			return err
+		}
+
+		return s.clearOtherDefaults(tx, &address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := convertToAddressResponse(&address)
+	return &response, nil
+}
+
+func (s *UserService) DeleteAddress(userID, addressID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", addressID, userID).Delete(&models.Address{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// clearOtherDefaults keeps a single default shipping and billing address per user
+func (s *UserService) clearOtherDefaults(tx *gorm.DB, address *models.Address) error {
+	if address.IsDefaultShipping {
+		if err := tx.Model(&models.Address{}).
+			Where("user_id = ? AND id <> ?", address.UserID, address.ID).
+			Update("is_default_shipping", false).Error; err != nil {
+			return err
+		}
+	}
+	if address.IsDefaultBilling {
+		if err := tx.Model(&models.Address{}).
+			Where("user_id = ? AND id <> ?", address.UserID, address.ID).
+			Update("is_default_billing", false).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyAddressRequest(address *models.Address, req *dto.AddressRequest) {
+	address.Label = req.Label
+	address.FirstName = req.FirstName
+	address.LastName = req.LastName
+	address.Phone = req.Phone
+	address.Line1 = req.Line1
+	address.Line2 = req.Line2
+	address.City = req.City
+	address.State = req.State
+	address.PostalCode = req.PostalCode
+	address.Country = req.Country
+	address.IsDefaultShipping = req.IsDefaultShipping
+	address.IsDefaultBilling = req.IsDefaultBilling
+}
+
+func convertToAddressResponse(address *models.Address) dto.AddressResponse {
+	return dto.AddressResponse{
+		ID:                address.ID,
+		Label:             address.Label,
+		FirstName:         address.FirstName,
+		LastName:          address.LastName,
+		Phone:             address.Phone,
+		Line1:             address.Line1,
+		Line2:             address.Line2,
+		City:              address.City,
+		State:             address.State,
+		PostalCode:        address.PostalCode,
+		Country:           address.Country,
+		IsDefaultShipping: address.IsDefaultShipping,
+		IsDefaultBilling:  address.IsDefaultBilling,
+	}
+}
+
+func convertToUserResponse(user *models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Phone:     user.Phone,
+		Role:      string(user.Role),
+		IsActive:  user.IsActive,
+	}
+}
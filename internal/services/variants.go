@@ -0,0 +1,301 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/notifications"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidVariantOptions = errors.New("invalid variant options")
+	ErrDefaultVariant        = errors.New("the default variant cannot be deleted")
+	ErrVariantHasStock       = errors.New("variant still has stock")
+)
+
+// CreateVariant adds a variant to a product. Its initial stock is booked in the inventory
+// ledger and kept in the primary warehouse.
+func (s *ProductService) CreateVariant(productID uint, req *dto.ProductVariantRequest, actorID uint) (*dto.ProductResponse, error) {
+	var restocked *notifications.BackInStockEvent
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return err
+		}
+		if _, err := s.createVariant(tx, &product, req, false, actorID); err != nil {
+			return err
+		}
+
+		var err error
+		restocked, err = s.inventoryService.backInStock(tx, product.ID, product.Stock)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.inventoryService.publishBackInStock(restocked)
+
+	return s.GetProduct(productID, "")
+}
+
+// UpdateVariant updates a variant of a product. A changed stock count is booked as a manual
+// adjustment in the primary warehouse.
+func (s *ProductService) UpdateVariant(productID, variantID uint, req *dto.ProductVariantRequest, actorID uint) (*dto.ProductResponse, error) {
+	var restocked *notifications.BackInStockEvent
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return err
+		}
+		variant, err := findVariant(tx, productID, variantID)
+		if err != nil {
+			return err
+		}
+
+		values, err := s.optionValues(tx, productID, req.Options)
+		if err != nil {
+			return err
+		}
+		if err := checkCombination(tx, productID, variant.ID, values); err != nil {
+			return err
+		}
+
+		variant.SKU = req.SKU
+		variant.Price = req.Price
+		if req.IsActive != nil {
+			variant.IsActive = *req.IsActive
+		}
+		if err := tx.Omit("Stock", "OptionValues").Save(variant).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(variant).Association("OptionValues").Replace(values); err != nil {
+			return err
+		}
+
+		if err := s.inventoryService.setTotal(tx, variant, req.Stock, movementFor(models.MovementReasonAdjustment, 0, actorID)); err != nil {
+			return err
+		}
+
+		restocked, err = s.inventoryService.backInStock(tx, product.ID, product.Stock)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.inventoryService.publishBackInStock(restocked)
+
+	return s.GetProduct(productID, "")
+}
+
+// DeleteVariant removes a variant from a product and from the carts it is in. The default
+// variant and variants that still hold or reserve stock are kept.
+func (s *ProductService) DeleteVariant(productID, variantID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		variant, err := findVariant(tx, productID, variantID)
+		if err != nil {
+			return err
+		}
+		if variant.IsDefault {
+			return ErrDefaultVariant
+		}
+		if variant.Stock > 0 {
+			return fmt.Errorf("%w: %d units left", ErrVariantHasStock, variant.Stock)
+		}
+
+		var reserved int64
+		if err := tx.Model(&models.StockReservation{}).
+			Where("variant_id = ? AND status = ?", variant.ID, models.ReservationStatusActive).
+			Count(&reserved).Error; err != nil {
+			return err
+		}
+		if reserved > 0 {
+			return fmt.Errorf("%w: it is reserved by a checkout in progress", ErrVariantHasStock)
+		}
+
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ProductImage{}).Where("variant_id = ?", variant.ID).Update("variant_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(variant).Error
+	})
+}
+
+// createVariant creates a variant of a locked product and puts its stock in the primary
+// warehouse
+func (s *ProductService) createVariant(tx *gorm.DB, product *models.Product, req *dto.ProductVariantRequest, isDefault bool, actorID uint) (*models.ProductVariant, error) {
+	values, err := s.optionValues(tx, product.ID, req.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCombination(tx, product.ID, 0, values); err != nil {
+		return nil, err
+	}
+
+	variant := models.ProductVariant{
+		ProductID:    product.ID,
+		SKU:          req.SKU,
+		Price:        req.Price,
+		IsDefault:    isDefault,
+		OptionValues: values,
+	}
+	if err := tx.Create(&variant).Error; err != nil {
+		return nil, err
+	}
+	// IsActive defaults to true in the database, so an inactive variant needs its own update
+	if req.IsActive != nil && !*req.IsActive {
+		if err := tx.Model(&variant).Update("is_active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Stock == 0 {
+		return &variant, nil
+	}
+	warehouse, err := s.inventoryService.primaryWarehouse(tx)
+	if err != nil {
+		return nil, err
+	}
+	m := movementFor(models.MovementReasonAdjustment, 0, actorID)
+	m.Note = "initial stock"
+	if err := s.inventoryService.put(tx, &variant, warehouse.ID, req.Stock, m); err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// optionValues resolves a variant's option values, adding the options and values the
+// product does not have yet. Once a product has options every variant has to pick a value
+// for each of them.
+func (s *ProductService) optionValues(tx *gorm.DB, productID uint, options map[string]string) ([]models.ProductOptionValue, error) {
+	var existing []models.ProductOption
+	if err := tx.Preload("Values").Where("product_id = ?", productID).Order("position ASC").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(options))
+	for name, value := range options {
+		if strings.TrimSpace(name) == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("%w: option names and values cannot be empty", ErrInvalidVariantOptions)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(existing) > 0 {
+		for _, option := range existing {
+			if _, ok := options[option.Name]; !ok {
+				return nil, fmt.Errorf("%w: missing a value for option %s", ErrInvalidVariantOptions, option.Name)
+			}
+		}
+		if len(options) != len(existing) {
+			return nil, fmt.Errorf("%w: the product's options are %s", ErrInvalidVariantOptions, optionNames(existing))
+		}
+	}
+
+	values := make([]models.ProductOptionValue, 0, len(names))
+	for _, name := range names {
+		option := findOption(existing, name)
+		if option == nil {
+			existing = append(existing, models.ProductOption{ProductID: productID, Name: name, Position: len(existing)})
+			option = &existing[len(existing)-1]
+			if err := tx.Create(option).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		value := findOptionValue(option.Values, options[name])
+		if value == nil {
+			option.Values = append(option.Values, models.ProductOptionValue{OptionID: option.ID, Value: options[name], Position: len(option.Values)})
+			value = &option.Values[len(option.Values)-1]
+			if err := tx.Create(value).Error; err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, *value)
+	}
+	return values, nil
+}
+
+// checkCombination makes sure no other variant of the product has the same option values
+func checkCombination(tx *gorm.DB, productID, variantID uint, values []models.ProductOptionValue) error {
+	var variants []models.ProductVariant
+	if err := tx.Preload("OptionValues").
+		Where("product_id = ? AND id <> ?", productID, variantID).
+		Find(&variants).Error; err != nil {
+		return err
+	}
+
+	key := combinationKey(values)
+	for i := range variants {
+		if combinationKey(variants[i].OptionValues) == key {
+			return fmt.Errorf("%w: variant %s has the same options", ErrInvalidVariantOptions, variants[i].SKU)
+		}
+	}
+	return nil
+}
+
+// findVariant loads a variant of the product, its default variant when variantID is 0
+func findVariant(tx *gorm.DB, productID, variantID uint) (*models.ProductVariant, error) {
+	query := tx.Where("product_id = ?", productID)
+	if variantID == 0 {
+		query = query.Where("is_default = ?", true)
+	} else {
+		query = query.Where("id = ?", variantID)
+	}
+
+	var variant models.ProductVariant
+	if err := query.First(&variant).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func findOption(options []models.ProductOption, name string) *models.ProductOption {
+	for i := range options {
+		if options[i].Name == name {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+func findOptionValue(values []models.ProductOptionValue, value string) *models.ProductOptionValue {
+	for i := range values {
+		if values[i].Value == value {
+			return &values[i]
+		}
+	}
+	return nil
+}
+
+func optionNames(options []models.ProductOption) string {
+	names := make([]string, len(options))
+	for i := range options {
+		names[i] = options[i].Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func combinationKey(values []models.ProductOptionValue) string {
+	ids := make([]int, len(values))
+	for i := range values {
+		ids[i] = int(values[i].ID)
+	}
+	sort.Ints(ids)
+	return fmt.Sprint(ids)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/money"
+)
+
+func TestCreateProductWithVariants(t *testing.T) {
+	reservations := newTestReservationService(t)
+	service := NewProductService(reservations.db, NewCurrencyService(reservations.db), reservations.inventoryService)
+
+	category := models.Category{Name: "Shirts", Slug: "shirts"}
+	require.NoError(t, reservations.db.Create(&category).Error)
+
+	sku := fmt.Sprintf("SHIRT-%d", time.Now().UnixNano())
+	large := money.New(12_00, "USD")
+	product, err := service.CreateProduct(&dto.CreateProductRequest{
+		CategoryID: category.ID,
+		Name:       "Shirt",
+		Price:      money.New(10_00, "USD"),
+		SKU:        sku,
+		Variants: []dto.ProductVariantRequest{
+			{SKU: sku + "-M-RED", Stock: 3, Options: map[string]string{"size": "M", "color": "red"}},
+			{SKU: sku + "-L-RED", Stock: 2, Price: &large, Options: map[string]string{"size": "L", "color": "red"}},
+		},
+	}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, product.Stock)
+	require.Len(t, product.Variants, 2)
+	assert.True(t, product.Variants[0].IsDefault)
+	assert.Equal(t, "10.00", product.Variants[0].Price.String())
+	assert.Equal(t, "12.00", product.Variants[1].Price.String())
+	assert.Equal(t, map[string]string{"size": "L", "color": "red"}, product.Variants[1].Options)
+	assert.Equal(t, []dto.ProductOptionResponse{
+		{Name: "color", Values: []string{"red"}},
+		{Name: "size", Values: []string{"M", "L"}},
+	}, product.Options)
+
+	// The same combination twice, or a variant missing an option, is rejected
+	_, err = service.CreateVariant(product.ID, &dto.ProductVariantRequest{SKU: sku + "-M-RED-2", Options: map[string]string{"size": "M", "color": "red"}}, 0)
+	assert.ErrorIs(t, err, ErrInvalidVariantOptions)
+	_, err = service.CreateVariant(product.ID, &dto.ProductVariantRequest{SKU: sku + "-S", Options: map[string]string{"size": "S"}}, 0)
+	assert.ErrorIs(t, err, ErrInvalidVariantOptions)
+
+	// Variants holding stock and the default variant stay
+	assert.ErrorIs(t, service.DeleteVariant(product.ID, product.Variants[1].ID), ErrVariantHasStock)
+	assert.ErrorIs(t, service.DeleteVariant(product.ID, product.Variants[0].ID), ErrDefaultVariant)
+}
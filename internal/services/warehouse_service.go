@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWarehouseCodeTaken = errors.New("warehouse code is already in use")
+	ErrWarehouseNotEmpty  = errors.New("warehouse still holds stock")
+)
+
+type WarehouseService struct {
+	db *gorm.DB
+}
+
+func NewWarehouseService(db *gorm.DB) *WarehouseService {
+	return &WarehouseService{db: db}
+}
+
+func (s *WarehouseService) GetWarehouses() ([]dto.WarehouseResponse, error) {
+	var warehouses []models.Warehouse
+	if err := s.db.Order("priority DESC, id ASC").Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+
+	totals, err := s.totalStock(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.WarehouseResponse, len(warehouses))
+	for i := range warehouses {
+		response[i] = s.convertToWarehouseResponse(&warehouses[i], totals[warehouses[i].ID])
+	}
+	return response, nil
+}
+
+func (s *WarehouseService) CreateWarehouse(req *dto.WarehouseRequest) (*dto.WarehouseResponse, error) {
+	warehouse := models.Warehouse{IsActive: true}
+	if err := s.applyWarehouseRequest(s.db, &warehouse, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&warehouse).Error; err != nil {
+		return nil, err
+	}
+	// gorm skips false on create because of the column default
+	if !warehouse.IsActive {
+		if err := s.db.Model(&warehouse).Update("is_active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.getWarehouse(s.db, warehouse.ID)
+}
+
+// UpdateWarehouse changes a warehouse. A warehouse can only be deactivated once its stock
+// has been moved elsewhere, since checkout does not allocate from inactive warehouses.
+func (s *WarehouseService) UpdateWarehouse(id uint, req *dto.WarehouseRequest) (*dto.WarehouseResponse, error) {
+	var response *dto.WarehouseResponse
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var warehouse models.Warehouse
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&warehouse, id).Error; err != nil {
+			return err
+		}
+
+		if err := s.applyWarehouseRequest(tx, &warehouse, req); err != nil {
+			return err
+		}
+		if !warehouse.IsActive {
+			if err := s.ensureEmpty(tx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Save(&warehouse).Error; err != nil {
+			return err
+		}
+
+		var err error
+		response, err = s.getWarehouse(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteWarehouse removes an empty warehouse. Its ledger entries and order allocations keep
+// pointing at the soft deleted row.
+func (s *WarehouseService) DeleteWarehouse(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var warehouse models.Warehouse
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&warehouse, id).Error; err != nil {
+			return err
+		}
+		if err := s.ensureEmpty(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Where("warehouse_id = ?", id).Delete(&models.StockLevel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&warehouse).Error
+	})
+}
+
+func (s *WarehouseService) ensureEmpty(tx *gorm.DB, id uint) error {
+	var held int64
+	if err := tx.Model(&models.StockLevel{}).
+		Where("warehouse_id = ? AND stock > 0", id).Count(&held).Error; err != nil {
+		return err
+	}
+	if held > 0 {
+		return ErrWarehouseNotEmpty
+	}
+
+	var reserved int64
+	if err := tx.Model(&models.StockReservation{}).
+		Where("warehouse_id = ? AND status = ?", id, models.ReservationStatusActive).Count(&reserved).Error; err != nil {
+		return err
+	}
+	if reserved > 0 {
+		return ErrWarehouseNotEmpty
+	}
+	return nil
+}
+
+func (s *WarehouseService) getWarehouse(tx *gorm.DB, id uint) (*dto.WarehouseResponse, error) {
+	var warehouse models.Warehouse
+	if err := tx.First(&warehouse, id).Error; err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := tx.Model(&models.StockLevel{}).Where("warehouse_id = ?", id).
+		Select("COALESCE(SUM(stock), 0)").Scan(&total).Error; err != nil {
+		return nil, err
+	}
+
+	response := s.convertToWarehouseResponse(&warehouse, total)
+	return &response, nil
+}
+
+func (s *WarehouseService) totalStock(tx *gorm.DB) (map[uint]int, error) {
+	var rows []struct {
+		WarehouseID uint
+		Total       int
+	}
+	if err := tx.Model(&models.StockLevel{}).
+		Select("warehouse_id, SUM(stock) AS total").
+		Group("warehouse_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		totals[row.WarehouseID] = row.Total
+	}
+	return totals, nil
+}
+
+func (s *WarehouseService) applyWarehouseRequest(tx *gorm.DB, warehouse *models.Warehouse, req *dto.WarehouseRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	var taken int64
+	if err := tx.Unscoped().Model(&models.Warehouse{}).
+		Where("code = ? AND id <> ?", code, warehouse.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrWarehouseCodeTaken
+	}
+
+	warehouse.Code = code
+	warehouse.Name = req.Name
+	warehouse.Country = strings.ToUpper(req.Country)
+	warehouse.Region = req.Region
+	warehouse.Priority = req.Priority
+	if req.IsActive != nil {
+		warehouse.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+func (s *WarehouseService) convertToWarehouseResponse(warehouse *models.Warehouse, totalStock int) dto.WarehouseResponse {
+	return dto.WarehouseResponse{
+		ID:         warehouse.ID,
+		Code:       warehouse.Code,
+		Name:       warehouse.Name,
+		Country:    warehouse.Country,
+		Region:     warehouse.Region,
+		Priority:   warehouse.Priority,
+		IsActive:   warehouse.IsActive,
+		TotalStock: totalStock,
+		CreatedAt:  warehouse.CreatedAt.Format(defaultDateFormat),
+	}
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWishlistNameTaken = errors.New("a wishlist with this name already exists")
+	ErrCannotMoveToCart  = errors.New("wishlist item cannot be added to the cart")
+)
+
+// WishlistService manages the named wishlists of users. Items are moved to the cart through
+// the cart service, so they go through the same stock checks as any item added to the cart.
+type WishlistService struct {
+	db              *gorm.DB
+	currencyService *CurrencyService
+	cartService     *CartService
+}
+
+func NewWishlistService(db *gorm.DB, currencyService *CurrencyService, cartService *CartService) *WishlistService {
+	return &WishlistService{db: db, currencyService: currencyService, cartService: cartService}
+}
+
+func (s *WishlistService) GetWishlists(userID uint, currency string) ([]dto.WishlistResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var wishlists []models.Wishlist
+	if err := withWishlistItems(s.db).Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&wishlists).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.WishlistResponse, len(wishlists))
+	for i := range wishlists {
+		response[i] = convertToWishlistResponse(&wishlists[i], display, true)
+	}
+	return response, nil
+}
+
+func (s *WishlistService) GetWishlist(userID, id uint, currency string) (*dto.WishlistResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	return s.getWishlist(userID, id, display)
+}
+
+// GetSharedWishlist returns the wishlist shared through the token, without anything that
+// would let others change it or share it further
+func (s *WishlistService) GetSharedWishlist(token, currency string) (*dto.WishlistResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var wishlist models.Wishlist
+	if err := withWishlistItems(s.db).Where("share_token = ?", token).First(&wishlist).Error; err != nil {
+		return nil, err
+	}
+	response := convertToWishlistResponse(&wishlist, display, false)
+	return &response, nil
+}
+
+func (s *WishlistService) CreateWishlist(userID uint, req *dto.WishlistRequest) (*dto.WishlistResponse, error) {
+	wishlist := models.Wishlist{UserID: userID, Name: req.Name}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureWishlistNameFree(tx, userID, 0, req.Name); err != nil {
+			return err
+		}
+		return tx.Create(&wishlist).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWishlist(userID, wishlist.ID, "")
+}
+
+func (s *WishlistService) UpdateWishlist(userID, id uint, req *dto.WishlistRequest) (*dto.WishlistResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := lockWishlist(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := ensureWishlistNameFree(tx, userID, id, req.Name); err != nil {
+			return err
+		}
+		return tx.Model(wishlist).Update("name", req.Name).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWishlist(userID, id, "")
+}
+
+func (s *WishlistService) DeleteWishlist(userID, id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := lockWishlist(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("wishlist_id = ?", wishlist.ID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		// The share link stops working with the wishlist
+		if err := tx.Model(wishlist).Update("share_token", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(wishlist).Error
+	})
+}
+
+// AddItem puts a product on the wishlist. A product that is already on it is left as it is.
+func (s *WishlistService) AddItem(userID, id uint, req *dto.AddWishlistItemRequest, currency string) (*dto.WishlistResponse, error) {
+	display, err := s.currencyService.displayCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := lockWishlist(tx, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Select("id").Where("is_active = ?", true).First(&models.Product{}, req.ProductID).Error; err != nil {
+			return fmt.Errorf("product: %w", err)
+		}
+		item := models.WishlistItem{WishlistID: wishlist.ID, ProductID: req.ProductID}
+		if req.VariantID != 0 {
+			variant, err := findVariant(tx, req.ProductID, req.VariantID)
+			if err != nil {
+				return fmt.Errorf("variant: %w", err)
+			}
+			item.VariantID = &variant.ID
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getWishlist(userID, id, display)
+}
+
+func (s *WishlistService) RemoveItem(userID, id, itemID uint) error {
+	return s.db.Where("id = ? AND wishlist_id IN (?)", itemID,
+		s.db.Select("id").Table("wishlists").
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID)).
+		Delete(&models.WishlistItem{}).Error
+}
+
+// MoveToCart adds a wishlist item to the user's cart and takes it off the wishlist, both in
+// one transaction. The item stays on the wishlist when the cart refuses it, such as when it is
+// out of stock.
+func (s *WishlistService) MoveToCart(userID, id, itemID uint, req *dto.MoveToCartRequest, currency string) (*dto.CartResponse, error) {
+	if _, err := s.currencyService.displayCurrency(currency); err != nil {
+		return nil, err
+	}
+
+	owner := CartOwner{UserID: userID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var item models.WishlistItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "wishlist_items"}}).
+			Joins("JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id AND wishlists.deleted_at IS NULL").
+			Where("wishlist_items.id = ? AND wishlists.id = ? AND wishlists.user_id = ?", itemID, id, userID).
+			First(&item).Error; err != nil {
+			return err
+		}
+
+		quantity := req.Quantity
+		if quantity < 1 {
+			quantity = 1
+		}
+		addToCart := dto.AddToCartRequest{ProductID: item.ProductID, Quantity: quantity}
+		if item.VariantID != nil {
+			addToCart.VariantID = *item.VariantID
+		}
+
+		if _, err := s.cartService.addToCart(tx, owner, &addToCart); err != nil {
+			return fmt.Errorf("%w: %v", ErrCannotMoveToCart, err)
+		}
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cartService.GetCart(owner, currency)
+}
+
+// ShareWishlist makes the wishlist readable by anyone with its share token. Sharing a shared
+// wishlist keeps the token it has.
+func (s *WishlistService) ShareWishlist(userID, id uint) (*dto.WishlistResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := lockWishlist(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if wishlist.ShareToken != nil {
+			return nil
+		}
+		return tx.Model(wishlist).Update("share_token", uuid.New().String()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWishlist(userID, id, "")
+}
+
+// UnshareWishlist turns off the public link. Sharing again gives a new token, so the old
+// link keeps not working.
+func (s *WishlistService) UnshareWishlist(userID, id uint) (*dto.WishlistResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := lockWishlist(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		return tx.Model(wishlist).Update("share_token", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWishlist(userID, id, "")
+}
+
+func (s *WishlistService) getWishlist(userID, id uint, display displayCurrency) (*dto.WishlistResponse, error) {
+	var wishlist models.Wishlist
+	if err := withWishlistItems(s.db).Where("user_id = ?", userID).First(&wishlist, id).Error; err != nil {
+		return nil, err
+	}
+	response := convertToWishlistResponse(&wishlist, display, true)
+	return &response, nil
+}
+
+// withWishlistItems preloads the items of wishlists with what is needed to show their current
+// price and stock, oldest first
+func withWishlistItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, id ASC")
+	}).Preload("Items.Product.Category").
+		Preload("Items.Product.Variants", "is_default = ?", true).
+		Preload("Items.Variant.OptionValues")
+}
+
+func lockWishlist(tx *gorm.DB, userID, id uint) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&wishlist, id).Error; err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func ensureWishlistNameFree(tx *gorm.DB, userID, excludeID uint, name string) error {
+	var count int64
+	if err := tx.Model(&models.Wishlist{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, excludeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrWishlistNameTaken, name)
+	}
+	return nil
+}
+
+// convertToWishlistResponse shows the wishlist with the current price and stock of its
+// items. The share token is only shown to the owner.
+func convertToWishlistResponse(wishlist *models.Wishlist, display displayCurrency, owner bool) dto.WishlistResponse {
+	items := make([]dto.WishlistItemResponse, 0, len(wishlist.Items))
+	for i := range wishlist.Items {
+		item := &wishlist.Items[i]
+		// Items of deleted products are not shown
+		if item.Product.ID == 0 {
+			continue
+		}
+
+		variant := item.Variant
+		if variant == nil && len(item.Product.Variants) > 0 {
+			variant = &item.Product.Variants[0]
+		}
+		response := dto.WishlistItemResponse{
+			ID:      item.ID,
+			Product: cartProductResponse(&item.Product, display),
+			Price:   display.convert(item.Product.Price),
+			InStock: item.Product.IsActive && item.Product.Stock > 0,
+			AddedAt: item.CreatedAt.Format(defaultDateFormat),
+		}
+		if variant != nil {
+			response.SKU = variant.SKU
+			response.VariantName = variant.Name()
+			response.Price = display.convert(variant.UnitPrice(&item.Product))
+			response.InStock = item.Product.IsActive && variant.IsActive && variant.Stock > 0
+		}
+		if item.VariantID != nil {
+			response.VariantID = *item.VariantID
+		}
+		items = append(items, response)
+	}
+
+	response := dto.WishlistResponse{
+		ID:        wishlist.ID,
+		Name:      wishlist.Name,
+		Items:     items,
+		CreatedAt: wishlist.CreatedAt.Format(defaultDateFormat),
+		UpdatedAt: wishlist.UpdatedAt.Format(defaultDateFormat),
+	}
+	if owner && wishlist.ShareToken != nil {
+		response.ShareToken = *wishlist.ShareToken
+	}
+	return response
+}
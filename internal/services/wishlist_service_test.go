@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veetmoradiya3628/go-shop/internal/dto"
+	"github.com/veetmoradiya3628/go-shop/internal/models"
+	"github.com/veetmoradiya3628/go-shop/internal/providers"
+)
+
+func TestWishlistMoveToCartAndSharing(t *testing.T) {
+	reservations := newTestReservationService(t)
+	db := reservations.db
+	currencies := NewCurrencyService(db)
+	carts := NewCartService(db, currencies, NewCouponService(db), NewPromotionService(db), providers.NewTableTaxCalculator(nil))
+	service := NewWishlistService(db, currencies, carts)
+
+	user := models.User{Email: "wisher@example.com", Password: "secret", FirstName: "Test", LastName: "Shopper"}
+	require.NoError(t, db.Create(&user).Error)
+	inStock := createTestProduct(t, reservations, 2)
+	soldOut := createTestProduct(t, reservations, 0)
+
+	wishlist, err := service.CreateWishlist(user.ID, &dto.WishlistRequest{Name: "Birthday"})
+	require.NoError(t, err)
+	_, err = service.CreateWishlist(user.ID, &dto.WishlistRequest{Name: "Birthday"})
+	assert.ErrorIs(t, err, ErrWishlistNameTaken)
+
+	_, err = service.AddItem(user.ID, wishlist.ID, &dto.AddWishlistItemRequest{ProductID: inStock.ID}, "")
+	require.NoError(t, err)
+	// Adding a product twice keeps one item
+	_, err = service.AddItem(user.ID, wishlist.ID, &dto.AddWishlistItemRequest{ProductID: inStock.ID}, "")
+	require.NoError(t, err)
+	wishlist, err = service.AddItem(user.ID, wishlist.ID, &dto.AddWishlistItemRequest{ProductID: soldOut.ID}, "")
+	require.NoError(t, err)
+	require.Len(t, wishlist.Items, 2)
+	assert.True(t, wishlist.Items[0].InStock)
+	assert.False(t, wishlist.Items[1].InStock)
+	assert.Equal(t, "10.00", wishlist.Items[0].Price.String())
+
+	// The cart's stock checks apply, a refused item stays on the wishlist
+	_, err = service.MoveToCart(user.ID, wishlist.ID, wishlist.Items[1].ID, &dto.MoveToCartRequest{}, "")
+	assert.ErrorIs(t, err, ErrCannotMoveToCart)
+	_, err = service.MoveToCart(user.ID, wishlist.ID, wishlist.Items[0].ID, &dto.MoveToCartRequest{Quantity: 3}, "")
+	assert.ErrorIs(t, err, ErrCannotMoveToCart)
+
+	cart, err := service.MoveToCart(user.ID, wishlist.ID, wishlist.Items[0].ID, &dto.MoveToCartRequest{Quantity: 2}, "")
+	require.NoError(t, err)
+	require.Len(t, cart.CartItems, 1)
+	assert.Equal(t, 2, cart.CartItems[0].Quantity)
+
+	wishlist, err = service.GetWishlist(user.ID, wishlist.ID, "")
+	require.NoError(t, err)
+	require.Len(t, wishlist.Items, 1)
+
+	// The share link shows the wishlist without its token, until sharing is turned off
+	shared, err := service.ShareWishlist(user.ID, wishlist.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, shared.ShareToken)
+	public, err := service.GetSharedWishlist(shared.ShareToken, "")
+	require.NoError(t, err)
+	assert.Empty(t, public.ShareToken)
+	assert.Len(t, public.Items, 1)
+
+	_, err = service.UnshareWishlist(user.ID, wishlist.ID)
+	require.NoError(t, err)
+	_, err = service.GetSharedWishlist(shared.ShareToken, "")
+	assert.Error(t, err)
+}
@@ -41,6 +41,16 @@ func CreatedResponse(c *gin.Context, message string, data interface{}) {
 	})
 }
 
+// MovedPermanentlyResponse points the client at the new location of a resource
+func MovedPermanentlyResponse(c *gin.Context, location, message string, data interface{}) {
+	c.Header("Location", location)
+	c.JSON(http.StatusMovedPermanently, Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
 func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
 	response := Response{
 		Success: false,
@@ -70,6 +80,10 @@ func NotFoundResponse(c *gin.Context, message string) {
 	ErrorResponse(c, http.StatusNotFound, message, nil)
 }
 
+func ConflictResponse(c *gin.Context, message string, err error) {
+	ErrorResponse(c, http.StatusConflict, message, err)
+}
+
 func InternalServerErrorResponse(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusInternalServerError, message, err)
 }
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength bounds generated slugs, so a numbered suffix still fits the column
+const MaxSlugLength = 100
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// Slugify turns a name into a URL slug of lowercase ASCII letters and digits separated by
+// single hyphens, e.g. "Café Crème, 250g" becomes "cafe-creme-250g". Accents and apostrophes
+// are dropped and anything else becomes a separator, so the result can be empty.
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			// accents decomposed from their letter and apostrophes are dropped
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		default:
+			pendingHyphen = true
+		}
+		if b.Len() >= MaxSlugLength {
+			break
+		}
+	}
+	return strings.TrimRight(b.String()[:min(b.Len(), MaxSlugLength)], "-")
+}
+
+// IsSlug reports whether the value is a well-formed slug
+func IsSlug(value string) bool {
+	return len(value) <= MaxSlugLength && slugPattern.MatchString(value)
+}
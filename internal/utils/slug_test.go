@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "cafe-creme-250g", Slugify("Café Crème, 250g"))
+	assert.Equal(t, "mens-t-shirt", Slugify("  Men's T-Shirt!! "))
+	assert.Equal(t, "", Slugify("★★★"))
+	assert.LessOrEqual(t, len(Slugify(strings.Repeat("ab ", 100))), MaxSlugLength)
+	assert.True(t, IsSlug(Slugify(strings.Repeat("ab ", 100))))
+}
+
+func TestIsSlug(t *testing.T) {
+	assert.True(t, IsSlug("trail-runner-2"))
+	assert.False(t, IsSlug("Trail-Runner"))
+	assert.False(t, IsSlug("trail--runner"))
+	assert.False(t, IsSlug("-trail"))
+	assert.False(t, IsSlug(""))
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidWebhookSignature is returned when the signature header is missing, malformed or does not match
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrStaleWebhook is returned when the signed timestamp is outside the accepted tolerance
+	ErrStaleWebhook = errors.New("webhook timestamp outside tolerance")
+)
+
+// SignWebhookPayload builds the signature header value for a webhook body in the
+// "t=<unix timestamp>,v1=<hex hmac>" format. It is used by tests and local tooling
+// to produce payloads the webhook endpoint accepts.
+func SignWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, computeWebhookMAC(secret, ts, body))
+}
+
+// VerifyWebhookSignature checks the signature header against the raw body and rejects
+// timestamps that are further than tolerance away from now. Nothing verifies against an empty
+// secret, as anyone could produce its signatures.
+func VerifyWebhookSignature(header, secret string, body []byte, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return ErrInvalidWebhookSignature
+	}
+
+	var ts, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	if ts == "" || signature == "" {
+		return ErrInvalidWebhookSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+
+	expected := computeWebhookMAC(secret, ts, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidWebhookSignature
+	}
+
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleWebhook
+	}
+
+	return nil
+}
+
+func computeWebhookMAC(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
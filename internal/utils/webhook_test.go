@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":"evt_1","type":"payment.captured"}`)
+	now := time.Now()
+
+	header := SignWebhookPayload(secret, now, body)
+	assert.NoError(t, VerifyWebhookSignature(header, secret, body, 5*time.Minute, now))
+
+	// tampered body
+	assert.ErrorIs(t, VerifyWebhookSignature(header, secret, []byte(`{"id":"evt_2"}`), 5*time.Minute, now), ErrInvalidWebhookSignature)
+
+	// wrong secret
+	assert.ErrorIs(t, VerifyWebhookSignature(header, "other", body, 5*time.Minute, now), ErrInvalidWebhookSignature)
+
+	// no secret configured
+	assert.ErrorIs(t, VerifyWebhookSignature(SignWebhookPayload("", now, body), "", body, 5*time.Minute, now), ErrInvalidWebhookSignature)
+
+	// malformed headers
+	assert.ErrorIs(t, VerifyWebhookSignature("", secret, body, 5*time.Minute, now), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, VerifyWebhookSignature("t=abc,v1=def", secret, body, 5*time.Minute, now), ErrInvalidWebhookSignature)
+}
+
+func TestVerifyWebhookSignatureStale(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+
+	old := SignWebhookPayload(secret, now.Add(-10*time.Minute), body)
+	assert.ErrorIs(t, VerifyWebhookSignature(old, secret, body, 5*time.Minute, now), ErrStaleWebhook)
+
+	future := SignWebhookPayload(secret, now.Add(10*time.Minute), body)
+	assert.ErrorIs(t, VerifyWebhookSignature(future, secret, body, 5*time.Minute, now), ErrStaleWebhook)
+}